@@ -1,13 +1,21 @@
 package baseapp
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
 
+	"github.com/gogo/protobuf/proto"
 	"github.com/pkg/errors"
 
 	abci "github.com/tendermint/abci/types"
+	crypto "github.com/tendermint/go-crypto"
+	tmtypes "github.com/tendermint/tendermint/types"
 	cmn "github.com/tendermint/tmlibs/common"
 	dbm "github.com/tendermint/tmlibs/db"
 	"github.com/tendermint/tmlibs/log"
@@ -16,29 +24,64 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
-// Key to store the header in the DB itself.
+// Key to store the header of each committed version in the DB itself.
 // Use the db directly instead of a store to avoid
 // conflicts with handlers writing to the store
 // and to avoid affecting the Merkle root.
-var dbHeaderKey = []byte("header")
+const (
+	dbHeaderKeyFmt    = "baseapp/header/%d" // baseapp/header/<version>
+	dbHeaderKeyPrefix = "baseapp/header/"
+)
+
+func dbHeaderKey(version int64) []byte {
+	return []byte(fmt.Sprintf(dbHeaderKeyFmt, version))
+}
+
+// Key to store the validator set after each committed version,
+// next to its header, so that the state can be exported with it.
+const dbValidatorsKeyFmt = "baseapp/validators/%d" // baseapp/validators/<version>
+
+func dbValidatorsKey(version int64) []byte {
+	return []byte(fmt.Sprintf(dbValidatorsKeyFmt, version))
+}
 
 // The ABCI application
 type BaseApp struct {
 	// initialized on creation
-	Logger log.Logger
-	name   string               // application name from abci.Info
-	db     dbm.DB               // common DB backend
-	cms    sdk.CommitMultiStore // Main (uncached) state
-	router Router               // handle any kind of message
+	Logger      log.Logger
+	name        string                       // application name from abci.Info
+	db          dbm.DB                       // common DB backend
+	cms         sdk.CommitMultiStore         // Main (uncached) state
+	router      Router                       // handle any kind of message
+	queryRouter QueryRouter                  // router for redirecting query calls
+	options     map[string]sdk.OptionHandler // node-local settings changed by SetOption
 
 	// must be set
 	txDecoder   sdk.TxDecoder   // unmarshal []byte into sdk.Tx
 	anteHandler sdk.AnteHandler // ante handler for fee and auth
 
 	// may be nil
-	initChainer  sdk.InitChainer  // initialize state with validators and state blob
-	beginBlocker sdk.BeginBlocker // logic to run before any txs
-	endBlocker   sdk.EndBlocker   // logic to run after all txs, and to determine valset changes
+	initChainer  sdk.InitChainer   // initialize state with validators and state blob
+	genesis      []genesisHandler  // initialize module state from the app state blob
+	exporters    []genesisExporter // export module state to an app state blob
+	beginBlocker sdk.BeginBlocker  // logic to run before any txs
+	endBlocker   sdk.EndBlocker    // logic to run after all txs, and to determine valset changes
+
+	setOptionQueries bool      // allow SetOption through "/app/setoption" queries
+	minimumGasPrices sdk.Coins // node-local minimum gas prices for CheckTx
+	snapshotDir      string    // where the periodic snapshots are written
+	snapshotInterval int64     // snapshot every snapshotInterval blocks, or never if 0
+
+	// the periodic snapshot running in the background, if any
+	snapshotRunning int32 // 1 while it runs, accessed atomically
+	snapshotDone    sync.WaitGroup
+
+	// may be nil
+	stateSink store.StateSink // receives the state changes of each committed block
+
+	// the headers and validator sets are pruned like the stores
+	pruning            sdk.PruningStrategy
+	headersPrunedUntil int64 // the ones below it are pruned, none if 0
 
 	//--------------------
 	// Volatile
@@ -47,22 +90,43 @@ type BaseApp struct {
 	// See methods setCheckState and setDeliverState.
 	// .valUpdates accumulate in DeliverTx and are reset in BeginBlock.
 	// QUESTION: should we put valUpdates in the deliverState.ctx?
-	checkState   *state           // for CheckTx
-	deliverState *state           // for DeliverTx
-	valUpdates   []abci.Validator // cached validator changes from DeliverTx
+	checkState   *state              // for CheckTx
+	deliverState *state              // for DeliverTx
+	valUpdates   []abci.Validator    // cached validator changes from DeliverTx
+	validators   []abci.Validator    // validator set after the last EndBlock, nil if unknown
+	txIndex      int64               // index in the block of the next DeliverTx
+	stateChanges []store.StateChange // writes of the block for the stateSink
 }
 
 var _ abci.Application = (*BaseApp)(nil)
 
 // Create and name new BaseApp
 // NOTE: The db is used to store the version number for now.
-func NewBaseApp(name string, logger log.Logger, db dbm.DB) *BaseApp {
-	return &BaseApp{
-		Logger: logger,
-		name:   name,
-		db:     db,
-		cms:    store.NewCommitMultiStore(db),
-		router: NewRouter(),
+// Options, eg. SetPruning, are applied to the BaseApp before it is returned,
+// so they apply to stores loaded by the app's constructor.
+func NewBaseApp(name string, logger log.Logger, db dbm.DB, options ...func(*BaseApp)) *BaseApp {
+	app := &BaseApp{
+		Logger:      logger,
+		name:        name,
+		db:          db,
+		cms:         store.NewCommitMultiStore(db),
+		router:      NewRouter(),
+		queryRouter: NewQueryRouter(),
+		options:     make(map[string]sdk.OptionHandler),
+	}
+	for _, option := range options {
+		option(app)
+	}
+	return app
+}
+
+// SetPruning returns an option for NewBaseApp which sets the pruning
+// strategy of the stores mounted without their own strategy, and of the
+// headers and validator sets stored with each version.
+func SetPruning(pruning sdk.PruningStrategy) func(*BaseApp) {
+	return func(app *BaseApp) {
+		app.cms.SetPruning(pruning)
+		app.pruning = pruning
 	}
 }
 
@@ -80,8 +144,14 @@ func (app *BaseApp) MountStoresIAVL(keys ...*sdk.KVStoreKey) {
 }
 
 // Mount a store to the provided key in the BaseApp multistore
-func (app *BaseApp) MountStoreWithDB(key sdk.StoreKey, typ sdk.StoreType, db dbm.DB) {
-	app.cms.MountStoreWithDB(key, typ, db)
+func (app *BaseApp) MountStoreWithDB(key sdk.StoreKey, typ sdk.StoreType, db dbm.DB, opts ...sdk.StoreOption) {
+	app.cms.MountStoreWithDB(key, typ, db, opts...)
+}
+
+// Mount a nested multistore to the provided key in the BaseApp multistore,
+// returning it so that its stores can be mounted
+func (app *BaseApp) MountMultiStore(key sdk.StoreKey, opts ...sdk.StoreOption) sdk.CommitMultiStore {
+	return app.cms.MountMultiStore(key, app.db, opts...)
 }
 
 // Mount a store to the provided key in the BaseApp multistore
@@ -106,17 +176,109 @@ func (app *BaseApp) SetAnteHandler(ah sdk.AnteHandler) {
 	app.anteHandler = ah
 }
 
+// RegisterInitGenesis registers a module's InitGenesis for the
+// value under key in the genesis app state. InitChain runs them
+// in the order they were registered, after the InitChainer.
+// Panics if key is already registered.
+func (app *BaseApp) RegisterInitGenesis(key string, initGenesis sdk.InitGenesis) {
+	for _, h := range app.genesis {
+		if h.key == key {
+			panic(fmt.Sprintf("InitGenesis for %s has already been registered", key))
+		}
+	}
+	app.genesis = append(app.genesis, genesisHandler{key, initGenesis})
+}
+
+// RegisterExportGenesis registers a module's ExportGenesis for the
+// value under key in the app state produced by ExportGenesis.
+// It should be the counterpart of the InitGenesis registered for key.
+// Panics if key is already registered.
+func (app *BaseApp) RegisterExportGenesis(key string, exportGenesis sdk.ExportGenesis) {
+	for _, e := range app.exporters {
+		if e.key == key {
+			panic(fmt.Sprintf("ExportGenesis for %s has already been registered", key))
+		}
+	}
+	app.exporters = append(app.exporters, genesisExporter{key, exportGenesis})
+}
+
+// option keys are segments of alphanumerics and underscores separated by "/"
+var isOptionKey = regexp.MustCompile(`^[a-zA-Z0-9_]+(/[a-zA-Z0-9_]+)*$`).MatchString
+
+// RegisterOption registers the handler for a node-local option
+// which SetOption may change at runtime, eg. "auth/min_gas_prices".
+// Panics if key is malformed or already registered.
+func (app *BaseApp) RegisterOption(key string, handler sdk.OptionHandler) {
+	if !isOptionKey(key) {
+		panic(fmt.Sprintf("option key %q is not segments separated by '/'", key))
+	}
+	if _, ok := app.options[key]; ok {
+		panic(fmt.Sprintf("option %s has already been registered", key))
+	}
+	app.options[key] = handler
+}
+
+// Options returns the registered option keys, sorted.
+func (app *BaseApp) Options() []string {
+	keys := make([]string, 0, len(app.options))
+	for key := range app.options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetOptionQueries allows SetOption to be called with "/app/setoption/<key>"
+// queries, whose data is the value. Tendermint doesn't forward SetOption
+// over RPC, so this lets clients set options remotely.
+// Anyone who can query the node can then change its options, as queries
+// are public RPC, even without Tendermint's unsafe RPC: it is off by default.
+func (app *BaseApp) SetOptionQueries(enabled bool) {
+	app.setOptionQueries = enabled
+}
+
+// SetMinimumGasPrices sets the minimum price per unit of gas, per denom,
+// of the fees of transactions accepted by CheckTx. The prices are put in
+// the context of CheckTx for the ante handler to enforce.
+// They are node-local: DeliverTx never sees them.
+func (app *BaseApp) SetMinimumGasPrices(prices sdk.Coins) {
+	app.minimumGasPrices = prices
+}
+
+// SetStateSink sets the sink which receives the writes to the stores of
+// each block once committed, in order, with the index of their tx.
+func (app *BaseApp) SetStateSink(sink store.StateSink) {
+	app.stateSink = sink
+}
+
+// SetSnapshots makes the app snapshot its stores in dir on every commit
+// of a height multiple of interval. An interval of 0 disables snapshots.
+func (app *BaseApp) SetSnapshots(dir string, interval int64) {
+	app.snapshotDir = dir
+	app.snapshotInterval = interval
+}
+
 func (app *BaseApp) Router() Router { return app.router }
 
-// load latest application version
+// QueryRouter returns the router for "/custom/<route>" queries.
+func (app *BaseApp) QueryRouter() QueryRouter { return app.queryRouter }
+
+// load latest application version, rolling back the stores of an
+// interrupted commit
 func (app *BaseApp) LoadLatestVersion(mainKey sdk.StoreKey) error {
-	app.cms.LoadLatestVersion()
+	err := app.cms.LoadLatestVersion()
+	if err != nil {
+		return err
+	}
 	return app.initFromStore(mainKey)
 }
 
 // load application version
 func (app *BaseApp) LoadVersion(version int64, mainKey sdk.StoreKey) error {
-	app.cms.LoadVersion(version)
+	err := app.cms.LoadVersion(version)
+	if err != nil {
+		return err
+	}
 	return app.initFromStore(mainKey)
 }
 
@@ -140,50 +302,84 @@ func (app *BaseApp) initFromStore(mainKey sdk.StoreKey) error {
 		return errors.New("BaseApp expects MultiStore with 'main' KVStore")
 	}
 
-	// XXX: Do we really need the header? What does it have that we want
-	// here that's not already in the CommitID ? If an app wants to have it,
-	// they can do so in their BeginBlocker. If we force it in baseapp,
-	// then either we force the AppHash to change with every block (since the header
-	// will be in the merkle store) or we can't write the state and the header to the
-	// db atomically without doing some surgery on the store interfaces ...
-
-	// if we've committed before, we expect <dbHeaderKey> to exist in the db
-	/*
-		var lastCommitID = app.cms.LastCommitID()
-		var header abci.Header
-
-		if !lastCommitID.IsZero() {
-			headerBytes := app.db.Get(dbHeaderKey)
-			if len(headerBytes) == 0 {
-				errStr := fmt.Sprintf("Version > 0 but missing key %s", dbHeaderKey)
-				return errors.New(errStr)
-			}
-			err := proto.Unmarshal(headerBytes, &header)
-			if err != nil {
-				return errors.Wrap(err, "Failed to parse Header")
-			}
-			lastVersion := lastCommitID.Version
-			if header.Height != lastVersion {
-				errStr := fmt.Sprintf("Expected db://%s.Height %v but got %v", dbHeaderKey, lastVersion, header.Height)
-				return errors.New(errStr)
-			}
-		}
-	*/
+	// if we've committed before, we expect the header of the
+	// last committed version to exist in the db
+	header, err := app.loadHeader(app.cms.LastCommitID())
+	if err != nil {
+		return err
+	}
+	app.validators, err = app.loadValidators(app.cms.LastCommitID())
+	if err != nil {
+		return err
+	}
 
 	// initialize Check state
-	app.setCheckState(abci.Header{})
+	app.setCheckState(header)
 
 	return nil
 }
 
+// loadHeader returns the header that was committed with the given
+// version, or an empty header if nothing has been committed yet.
+func (app *BaseApp) loadHeader(commitID sdk.CommitID) (header abci.Header, err error) {
+	if commitID.IsZero() {
+		return header, nil
+	}
+	headerBytes := app.db.Get(dbHeaderKey(commitID.Version))
+	if len(headerBytes) == 0 {
+		errStr := fmt.Sprintf("Version %v but missing key %s", commitID.Version, dbHeaderKey(commitID.Version))
+		return header, errors.New(errStr)
+	}
+	err = proto.Unmarshal(headerBytes, &header)
+	if err != nil {
+		return header, errors.Wrap(err, "Failed to parse Header")
+	}
+	if header.Height != commitID.Version {
+		errStr := fmt.Sprintf("Expected db://%s.Height %v but got %v",
+			dbHeaderKey(commitID.Version), commitID.Version, header.Height)
+		return header, errors.New(errStr)
+	}
+	return header, nil
+}
+
+// loadValidators returns the validator set that was committed with the
+// given version, or nil if it is unknown, eg. for a version committed
+// before the validator sets were stored or restored from a snapshot.
+func (app *BaseApp) loadValidators(commitID sdk.CommitID) ([]abci.Validator, error) {
+	if commitID.IsZero() {
+		return nil, nil
+	}
+	bz := app.db.Get(dbValidatorsKey(commitID.Version))
+	if len(bz) == 0 {
+		return nil, nil
+	}
+	validators := []abci.Validator{}
+	err := json.Unmarshal(bz, &validators)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse the validator set")
+	}
+	return validators, nil
+}
+
 // NewContext returns a new Context with the correct store, the given header, and nil txBytes.
 func (app *BaseApp) NewContext(isCheckTx bool, header abci.Header) sdk.Context {
 	if isCheckTx {
-		return sdk.NewContext(app.checkState.ms, header, true, nil)
+		return sdk.NewContext(app.checkState.ms, header, true, nil).
+			WithMinimumGasPrices(app.minimumGasPrices)
 	}
 	return sdk.NewContext(app.deliverState.ms, header, false, nil)
 }
 
+type genesisHandler struct {
+	key         string
+	initGenesis sdk.InitGenesis
+}
+
+type genesisExporter struct {
+	key           string
+	exportGenesis sdk.ExportGenesis
+}
+
 type state struct {
 	ms  sdk.CacheMultiStore
 	ctx sdk.Context
@@ -224,48 +420,278 @@ func (app *BaseApp) Info(req abci.RequestInfo) abci.ResponseInfo {
 }
 
 // Implements ABCI
+// Runs the handler registered for req.Key with the new value.
 func (app *BaseApp) SetOption(req abci.RequestSetOption) (res abci.ResponseSetOption) {
-	// TODO: Implement
-	return
+	err := app.setOption(req.Key, req.Value)
+	if err != nil {
+		return abci.ResponseSetOption{
+			Code: uint32(err.ABCICode()),
+			Log:  err.ABCILog(),
+		}
+	}
+	app.Logger.Info("Set option", "key", req.Key, "value", req.Value)
+	return abci.ResponseSetOption{
+		Log: fmt.Sprintf("%s set to %s", req.Key, req.Value),
+	}
+}
+
+func (app *BaseApp) setOption(key, value string) sdk.Error {
+	handler, ok := app.options[key]
+	if !ok {
+		return sdk.ErrUnknownRequest(fmt.Sprintf("unknown option: %s", key))
+	}
+	err := handler(value)
+	if err != nil {
+		return sdk.ErrInvalidOption(fmt.Sprintf("invalid value for %s: %s", key, err))
+	}
+	return nil
 }
 
 // Implements ABCI
 // InitChain runs the initialization logic directly on the CommitMultiStore and commits it.
+// NOTE: ResponseInitChain has no fields in ABCI v0.10, so InitChain can
+// neither return the genesis errors nor a validator set to Tendermint:
+// Tendermint starts with the validators of its genesis file. As starting
+// from a partially initialized state is worse than halting, genesis
+// errors are logged and panic.
 func (app *BaseApp) InitChain(req abci.RequestInitChain) (res abci.ResponseInitChain) {
-	if app.initChainer == nil {
+	res, err := app.initChain(req)
+	if err != nil {
+		app.Logger.Error("InitChain failed", "err", err)
+		panic(err)
+	}
+	return res
+}
+
+// initChain initializes the deliver state with the InitChainer and
+// the registered module genesis, returning the first error.
+func (app *BaseApp) initChain(req abci.RequestInitChain) (res abci.ResponseInitChain, err sdk.Error) {
+	app.validators = updateValidators(nil, req.Validators)
+	if app.initChainer == nil && len(app.genesis) == 0 {
 		// TODO: should we have some default handling of validators?
 		return
 	}
 
 	// Initialize the deliver state and run initChain
 	app.setDeliverState(abci.Header{})
-	app.initChainer(app.deliverState.ctx, req) // no error
+	app.runOutsideTxs(func(ctx sdk.Context) {
+		if app.initChainer != nil {
+			res = app.initChainer(ctx, req)
+		}
 
-	// Initialize module genesis state
-	genesisState := new(map[string]json.RawMessage)
-	err := json.Unmarshal(req.AppStateBytes, genesisState)
+		// Initialize module genesis state
+		err = app.initGenesis(ctx, req.AppStateBytes)
+	})
 	if err != nil {
-		// TODO Return something intelligent
-		panic(err)
+		return res, err
 	}
 
 	// NOTE: we don't commit, but BeginBlock for block 1
 	// starts from this deliverState
 
-	return
+	return res, nil
+}
+
+// Dispatch each module's value in the genesis app state to its InitGenesis.
+func (app *BaseApp) initGenesis(ctx sdk.Context, appState []byte) sdk.Error {
+	if len(app.genesis) == 0 {
+		return nil
+	}
+	genesisState := make(map[string]json.RawMessage)
+	err := json.Unmarshal(appState, &genesisState)
+	if err != nil {
+		return sdk.ErrGenesisParse("").TraceCause(err, "app state")
+	}
+	for _, h := range app.genesis {
+		err = h.initGenesis(ctx, genesisState[h.key])
+		if err != nil {
+			return sdk.ErrGenesisParse("").TraceCause(err, h.key)
+		}
+	}
+	return nil
+}
+
+// ExportGenesis returns the genesis app state for the last committed
+// version, built from the registered module ExportGenesis functions.
+// Feeding it back through InitChain reproduces the module state.
+// Load the version to export with LoadVersion first.
+func (app *BaseApp) ExportGenesis() (json.RawMessage, error) {
+	// export from a cache so exporters can't modify the committed state
+	ctx := sdk.NewContext(app.cms.CacheMultiStore(), app.checkState.ctx.BlockHeader(), false, nil)
+	genesisState := make(map[string]json.RawMessage)
+	for _, e := range app.exporters {
+		data := e.exportGenesis(ctx)
+		if data == nil {
+			continue
+		}
+		genesisState[e.key] = data
+	}
+	return json.Marshal(genesisState)
+}
+
+// ExportValidators returns the validator set after the last committed
+// version, the one ExportGenesis exports the state of, as genesis
+// validators. It fails if the set is unknown, eg. after a snapshot restore.
+func (app *BaseApp) ExportValidators() ([]tmtypes.GenesisValidator, error) {
+	if app.validators == nil {
+		return nil, fmt.Errorf("the validator set of version %d is unknown", app.LastBlockHeight())
+	}
+	validators := make([]tmtypes.GenesisValidator, len(app.validators))
+	for i, v := range app.validators {
+		pubKey, err := crypto.PubKeyFromBytes(v.PubKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to parse the pubkey of a validator")
+		}
+		validators[i] = tmtypes.GenesisValidator{PubKey: pubKey, Power: v.Power}
+	}
+	return validators, nil
 }
 
 // Implements ABCI.
-// Delegates to CommitMultiStore if it implements Queryable
+// Paths are of the form:
+//   - /store/<storeName>/<subpath>: raw queries against a substore,
+//     delegated to the CommitMultiStore if it implements Queryable
+//   - /custom/<route>/<subpath>: queries answered by the QueryRouter
+//   - /app/setoption/<key>: SetOption with the query data as value,
+//     if enabled with SetOptionQueries
 func (app *BaseApp) Query(req abci.RequestQuery) (res abci.ResponseQuery) {
+	path := splitPath(req.Path)
+	if len(path) == 0 {
+		msg := "no query path provided"
+		return sdk.ErrUnknownRequest(msg).QueryResult()
+	}
+	switch path[0] {
+	case "store":
+		return app.handleQueryStore(path, req)
+	case "custom":
+		return app.handleQueryCustom(path, req)
+	case "app":
+		return app.handleQueryApp(path, req)
+	}
+	msg := fmt.Sprintf("unknown query path: %s", req.Path)
+	return sdk.ErrUnknownRequest(msg).QueryResult()
+}
+
+// AppRoutes are the routes of the Msgs and custom queries of an app,
+// which the "/app/routes" query returns as JSON.
+type AppRoutes struct {
+	Msgs    []string `json:"msgs"`
+	Queries []string `json:"queries"`
+}
+
+func (app *BaseApp) handleQueryApp(path []string, req abci.RequestQuery) (res abci.ResponseQuery) {
+	if len(path) == 2 && path[1] == "routes" {
+		bz, err := json.Marshal(AppRoutes{
+			Msgs:    app.router.Routes(),
+			Queries: app.queryRouter.Routes(),
+		})
+		if err != nil {
+			return sdk.ErrInternal(err.Error()).QueryResult()
+		}
+		return abci.ResponseQuery{
+			Code:  uint32(sdk.CodeOK),
+			Value: bz,
+		}
+	}
+	if len(path) >= 3 && path[1] == "setoption" {
+		if !app.setOptionQueries {
+			return sdk.ErrUnauthorized("setting options by query is disabled").QueryResult()
+		}
+		key := strings.Join(path[2:], "/")
+		res := app.SetOption(abci.RequestSetOption{Key: key, Value: string(req.Data)})
+		return abci.ResponseQuery{
+			Code: res.Code,
+			Log:  res.Log,
+		}
+	}
+	msg := fmt.Sprintf("unknown query path: %s", req.Path)
+	return sdk.ErrUnknownRequest(msg).QueryResult()
+}
+
+func (app *BaseApp) handleQueryStore(path []string, req abci.RequestQuery) (res abci.ResponseQuery) {
 	queryable, ok := app.cms.(sdk.Queryable)
 	if !ok {
-		msg := "application doesn't support queries"
+		msg := "multistore doesn't support queries"
 		return sdk.ErrUnknownRequest(msg).QueryResult()
 	}
+	// trim the "/store" prefix
+	req.Path = "/" + strings.Join(path[1:], "/")
 	return queryable.Query(req)
 }
 
+func (app *BaseApp) handleQueryCustom(path []string, req abci.RequestQuery) (res abci.ResponseQuery) {
+	if len(path) < 2 || path[1] == "" {
+		msg := "no route for custom query specified"
+		return sdk.ErrUnknownRequest(msg).QueryResult()
+	}
+	route, querier := app.queryRouter.Route(strings.Join(path[1:], "/"))
+	if querier == nil {
+		msg := fmt.Sprintf("no custom querier found for route %s", path[1])
+		return sdk.ErrUnknownRequest(msg).QueryResult()
+	}
+	subpath := path[1+len(strings.Split(route, "/")):]
+
+	height := req.Height
+	if height == 0 {
+		height = app.LastBlockHeight()
+	}
+	ctx, err := app.queryContext(height)
+	if err != nil {
+		return err.QueryResult()
+	}
+
+	resBytes, err := querier(ctx, subpath, req)
+	if err != nil {
+		return abci.ResponseQuery{
+			Code:   uint32(err.ABCICode()),
+			Log:    err.ABCILog(),
+			Height: height,
+		}
+	}
+	return abci.ResponseQuery{
+		Code:   uint32(sdk.CodeOK),
+		Value:  resBytes,
+		Height: height,
+	}
+}
+
+// queryContext returns a read-only context of the state committed at
+// height, with the header of the block of that height.
+func (app *BaseApp) queryContext(height int64) (sdk.Context, sdk.Error) {
+	latest := app.LastBlockHeight()
+	if height == latest {
+		// Cache wrap the committed state so that the querier can't write to it.
+		header := app.checkState.ctx.BlockHeader()
+		return sdk.NewContext(app.cms.CacheMultiStore(), header, true, nil), nil
+	}
+	if height < 0 || height > latest {
+		msg := fmt.Sprintf("height %d is not committed, the latest height is %d", height, latest)
+		return sdk.Context{}, sdk.ErrUnknownRequest(msg)
+	}
+	ms, err := app.cms.CacheMultiStoreWithVersion(height)
+	if err != nil {
+		msg := fmt.Sprintf("height %d is not retained, it may have been pruned: %v", height, err)
+		return sdk.Context{}, sdk.ErrUnknownRequest(msg)
+	}
+	header, err := app.loadHeader(sdk.CommitID{Version: height})
+	if err != nil {
+		msg := fmt.Sprintf("header of height %d is not retained: %v", height, err)
+		return sdk.Context{}, sdk.ErrUnknownRequest(msg)
+	}
+	return sdk.NewContext(ms, header, true, nil), nil
+}
+
+// splitPath splits a query path like "/custom/stake/candidates"
+// into its elements, ignoring the leading slash.
+func splitPath(requestPath string) (path []string) {
+	path = strings.Split(requestPath, "/")
+	// first element is empty string
+	if len(path) > 0 && path[0] == "" {
+		path = path[1:]
+	}
+	return path
+}
+
 // Implements ABCI
 func (app *BaseApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBeginBlock) {
 	// Initialize the DeliverTx state.
@@ -274,10 +700,20 @@ func (app *BaseApp) BeginBlock(req abci.RequestBeginBlock) (res abci.ResponseBeg
 	// if this is a test and InitChain was never called.
 	if app.deliverState == nil {
 		app.setDeliverState(req.Header)
+	} else {
+		// In the first block, app.deliverState.ctx was initialized by
+		// InitChain, before the header was known.
+		app.deliverState.ctx = app.deliverState.ctx.
+			WithBlockHeader(req.Header).
+			WithBlockHeight(req.Header.Height).
+			WithChainID(req.Header.ChainID)
 	}
 	app.valUpdates = nil
+	app.txIndex = 0
 	if app.beginBlocker != nil {
-		res = app.beginBlocker(app.deliverState.ctx, req)
+		app.runOutsideTxs(func(ctx sdk.Context) {
+			res = app.beginBlocker(ctx, req)
+		})
 	}
 	return
 }
@@ -316,6 +752,7 @@ func (app *BaseApp) DeliverTx(txBytes []byte) (res abci.ResponseDeliverTx) {
 	} else {
 		result = app.runTx(false, txBytes, tx)
 	}
+	app.txIndex++
 
 	// After-handler hooks.
 	if result.IsOK() {
@@ -342,102 +779,232 @@ func (app *BaseApp) Check(tx sdk.Tx) (result sdk.Result) {
 	return app.runTx(true, nil, tx)
 }
 func (app *BaseApp) Deliver(tx sdk.Tx) (result sdk.Result) {
+	defer func() { app.txIndex++ }()
 	return app.runTx(false, nil, tx)
 }
 
 // txBytes may be nil in some cases, eg. in tests.
 // Also, in the future we may support "internal" transactions.
 func (app *BaseApp) runTx(isCheckTx bool, txBytes []byte, tx sdk.Tx) (result sdk.Result) {
-	// Handle any panics.
+	var ctx sdk.Context
+
+	// Handle any panics, and report the gas consumed.
+	// NOTE: the ante handler is expected to install a limited GasMeter,
+	// otherwise the tx's own infinite meter is reported.
 	defer func() {
 		if r := recover(); r != nil {
-			log := fmt.Sprintf("Recovered: %v\nstack:\n%v", r, string(debug.Stack()))
-			result = sdk.ErrInternal(log).Result()
+			switch rType := r.(type) {
+			case sdk.ErrorOutOfGas:
+				log := fmt.Sprintf("Out of gas in location: %v", rType.Descriptor)
+				result = sdk.ErrOutOfGas(log).Result()
+			default:
+				log := fmt.Sprintf("Recovered: %v\nstack:\n%v", r, string(debug.Stack()))
+				result = sdk.ErrInternal(log).Result()
+			}
+		}
+		if !ctx.IsZero() {
+			result.GasWanted = ctx.GasMeter().Limit()
+			result.GasUsed = ctx.GasMeter().GasConsumed()
 		}
 	}()
 
-	// Get the Msg.
-	var msg = tx.GetMsg()
-	if msg == nil {
-		return sdk.ErrInternal("Tx.GetMsg() returned nil").Result()
+	// Get the context, with a meter of its own,
+	// so the gas of the block so far isn't reported.
+	ctx = app.getState(isCheckTx).ctx.
+		WithTxBytes(txBytes).
+		WithGasMeter(sdk.NewInfiniteGasMeter())
+	if isCheckTx {
+		ctx = ctx.WithMinimumGasPrices(app.minimumGasPrices)
 	}
 
-	// Validate the Msg.
-	err := msg.ValidateBasic()
-	if err != nil {
-		return err.Result()
+	// Get the Msgs.
+	var msgs = tx.GetMsgs()
+	if len(msgs) == 0 {
+		return sdk.ErrInternal("Tx.GetMsgs() must return at least one Msg").Result()
 	}
 
-	// Get the context
-	var ctx sdk.Context
-	if isCheckTx {
-		ctx = app.checkState.ctx.WithTxBytes(txBytes)
-	} else {
-		ctx = app.deliverState.ctx.WithTxBytes(txBytes)
+	// Validate the Msgs and match their routes.
+	handlers := make([]sdk.Handler, len(msgs))
+	for i, msg := range msgs {
+		if msg == nil {
+			return sdk.ErrInternal(fmt.Sprintf("Tx.GetMsgs()[%d] is nil", i)).Result()
+		}
+		err := msg.ValidateBasic()
+		if err != nil {
+			return err.Result()
+		}
+		msgType := msg.Type()
+		handlers[i] = app.router.Route(msgType)
+		if handlers[i] == nil {
+			return sdk.ErrUnknownRequest("Unrecognized Msg type: " + msgType).Result()
+		}
 	}
 
 	// Run the ante handler.
+	// It runs on its own cache so that running out of gas half way
+	// through leaves no partial writes behind.
 	if app.anteHandler != nil {
-		newCtx, result, abort := app.anteHandler(ctx, tx)
-		if abort {
-			return result
-		}
+		anteCache := app.cacheTxState(isCheckTx)
+		newCtx, result, abort := app.anteHandler(ctx.WithMultiStore(anteCache), tx)
 		if !newCtx.IsZero() {
 			ctx = newCtx
 		}
+		if abort {
+			return result
+		}
+		anteCache.Write()
 	}
 
-	// Match route.
-	msgType := msg.Type()
-	handler := app.router.Route(msgType)
-	if handler == nil {
-		return sdk.ErrUnknownRequest("Unrecognized Msg type: " + msgType).Result()
+	// CacheWrap app.checkState.ms or app.deliverState.ms in case it fails.
+	// All Msgs run on the same cache, so they succeed or fail together.
+	msCache := app.cacheTxState(isCheckTx)
+	ctx = ctx.WithMultiStore(msCache)
+
+	result = runMsgs(ctx, msgs, handlers)
+
+	// If all Msgs were successful, write to app.checkState.ms or app.deliverState.ms
+	// NOTE: if a handler ran out of gas we never get here,
+	// so msCache is discarded.
+	if result.IsOK() {
+		msCache.Write()
 	}
 
-	// Get the correct cache
-	var msCache sdk.CacheMultiStore
-	if isCheckTx == true {
-		// CacheWrap app.checkState.ms in case it fails.
-		msCache = app.checkState.CacheMultiStore()
-		ctx = ctx.WithMultiStore(msCache)
-	} else {
-		// CacheWrap app.deliverState.ms in case it fails.
-		msCache = app.deliverState.CacheMultiStore()
-		ctx = ctx.WithMultiStore(msCache)
+	return result
+}
 
+// runMsgs runs each Msg through its handler in order, stopping at the
+// first failure. The Data, Tags and ValidatorUpdates of each Msg are
+// appended together.
+func runMsgs(ctx sdk.Context, msgs []sdk.Msg, handlers []sdk.Handler) (result sdk.Result) {
+	logs := make([]string, 0, len(msgs))
+	for i, msg := range msgs {
+		msgResult := handlers[i](ctx, msg)
+
+		result.Data = append(result.Data, msgResult.Data...)
+		result.Tags = append(result.Tags, msgResult.Tags...)
+		result.ValidatorUpdates = append(result.ValidatorUpdates, msgResult.ValidatorUpdates...)
+
+		// Stop execution and return on first failed Msg.
+		if !msgResult.IsOK() {
+			logs = append(logs, fmt.Sprintf("Msg %d failed: %s", i, msgResult.Log))
+			result.Code = msgResult.Code
+			break
+		}
+		logs = append(logs, fmt.Sprintf("Msg %d: %s", i, msgResult.Log))
 	}
+	result.Log = strings.Join(logs, "\n")
+	return result
+}
 
-	result = handler(ctx, msg)
+// getState returns the state used by CheckTx or DeliverTx.
+func (app *BaseApp) getState(isCheckTx bool) *state {
+	if isCheckTx {
+		return app.checkState
+	}
+	return app.deliverState
+}
 
-	// If result was successful, write to app.checkState.ms or app.deliverState.ms
-	if result.IsOK() {
-		msCache.Write()
+// cacheTxState cache-wraps the state of CheckTx or DeliverTx for a tx.
+// The writes of a delivered tx are recorded for the state sink.
+func (app *BaseApp) cacheTxState(isCheckTx bool) sdk.CacheMultiStore {
+	ms := app.getState(isCheckTx).CacheMultiStore()
+	if !isCheckTx && app.stateSink != nil {
+		ms.SetWriteListener(stateRecorder{app, app.txIndex})
 	}
+	return ms
+}
 
-	return result
+// runOutsideTxs runs f with the deliver context, eg. for BeginBlock.
+// With a state sink, f runs on a cache of the deliver state, which is
+// then written, so that its writes are recorded apart from those of txs.
+func (app *BaseApp) runOutsideTxs(f func(ctx sdk.Context)) {
+	if app.stateSink == nil {
+		f(app.deliverState.ctx)
+		return
+	}
+	ms := app.deliverState.CacheMultiStore()
+	ms.SetWriteListener(stateRecorder{app, -1})
+	f(app.deliverState.ctx.WithMultiStore(ms))
+	ms.Write()
+}
+
+// stateRecorder records the writes of the block for the state sink.
+type stateRecorder struct {
+	app     *BaseApp
+	txIndex int64
+}
+
+// Implements sdk.WriteListener.
+func (r stateRecorder) OnWrite(storeName string, key, value []byte, deleted bool) {
+	r.app.stateChanges = append(r.app.stateChanges, store.StateChange{
+		TxIndex:   r.txIndex,
+		StoreName: storeName,
+		Key:       key,
+		Value:     value,
+		Deleted:   deleted,
+	})
 }
 
 // Implements ABCI
 func (app *BaseApp) EndBlock(req abci.RequestEndBlock) (res abci.ResponseEndBlock) {
 	if app.endBlocker != nil {
-		res = app.endBlocker(app.deliverState.ctx, req)
+		app.runOutsideTxs(func(ctx sdk.Context) {
+			res = app.endBlocker(ctx, req)
+		})
 	} else {
 		res.ValidatorUpdates = app.valUpdates
 	}
+	if app.validators != nil {
+		app.validators = updateValidators(app.validators, res.ValidatorUpdates)
+	}
 	return
 }
 
+// updateValidators returns a copy of the validator set with the updates
+// applied: the validators of power zero are removed, the others set.
+func updateValidators(validators []abci.Validator, updates []abci.Validator) []abci.Validator {
+	updated := make([]abci.Validator, 0, len(validators)+len(updates))
+	updated = append(updated, validators...)
+	for _, update := range updates {
+		i := 0
+		for i < len(updated) && !bytes.Equal(updated[i].PubKey, update.PubKey) {
+			i++
+		}
+		switch {
+		case i < len(updated) && update.Power == 0:
+			updated = append(updated[:i], updated[i+1:]...)
+		case i < len(updated):
+			updated[i].Power = update.Power
+		case update.Power != 0:
+			updated = append(updated, update)
+		}
+	}
+	return updated
+}
+
 // Implements ABCI
 func (app *BaseApp) Commit() (res abci.ResponseCommit) {
 	header := app.deliverState.ctx.BlockHeader()
-	/*
-		// Write the latest Header to the store
-			headerBytes, err := proto.Marshal(&header)
-			if err != nil {
-				panic(err)
-			}
-			app.db.SetSync(dbHeaderKey, headerBytes)
-	*/
+
+	// Write the latest Header and the validator set to the db, keyed by
+	// the version we are about to commit. They are synced before the
+	// MultiStore commits, so the header of the last committed version
+	// is always there, even if we crash in between.
+	headerBytes, err := proto.Marshal(&header)
+	if err != nil {
+		panic(err)
+	}
+	version := app.LastBlockHeight() + 1
+	batch := app.db.NewBatch()
+	batch.Set(dbHeaderKey(version), headerBytes)
+	if app.validators != nil {
+		validatorsBytes, err := json.Marshal(app.validators)
+		if err != nil {
+			panic(err)
+		}
+		batch.Set(dbValidatorsKey(version), validatorsBytes)
+	}
+	batch.WriteSync()
 
 	// Write the Deliver state and commit the MultiStore
 	app.deliverState.ms.Write()
@@ -445,6 +1012,13 @@ func (app *BaseApp) Commit() (res abci.ResponseCommit) {
 	app.Logger.Debug("Commit synced",
 		"commit", commitID,
 	)
+	app.pruneHeaders(commitID.Version)
+	if app.stateSink != nil {
+		app.writeStateChanges(commitID.Version)
+	}
+	if app.snapshotInterval > 0 && commitID.Version%app.snapshotInterval == 0 {
+		app.startSnapshot(commitID.Version, headerBytes)
+	}
 
 	// Reset the Check state to the latest committed
 	// NOTE: safe because Tendermint holds a lock on the mempool for Commit.
@@ -458,3 +1032,61 @@ func (app *BaseApp) Commit() (res abci.ResponseCommit) {
 		Data: commitID.Hash,
 	}
 }
+
+// pruneHeaders deletes the headers and validator sets of the versions
+// which are no longer recent once version is committed, unless they are
+// kept, in step with the stores. The first time, it checks all the
+// stored headers, eg. which an earlier strategy kept, and then only the
+// versions which became old since.
+func (app *BaseApp) pruneHeaders(version int64) {
+	last := version - app.pruning.KeepRecent
+	if app.pruning.KeepRecent == 0 || last < app.headersPrunedUntil {
+		return
+	}
+	var versions []int64
+	if app.headersPrunedUntil == 0 {
+		versions = storedHeaderVersions(app.db)
+	} else {
+		for v := app.headersPrunedUntil; v <= last; v++ {
+			versions = append(versions, v)
+		}
+	}
+	batch := app.db.NewBatch()
+	for _, v := range versions {
+		if v <= last && app.pruning.PruneVersion(v, version) {
+			batch.Delete(dbHeaderKey(v))
+			batch.Delete(dbValidatorsKey(v))
+		}
+	}
+	batch.Write()
+	app.headersPrunedUntil = last + 1
+}
+
+// storedHeaderVersions returns the versions of the headers in db.
+func storedHeaderVersions(db dbm.DB) []int64 {
+	var versions []int64
+	iter := dbm.IteratePrefix(db, []byte(dbHeaderKeyPrefix))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var version int64
+		_, err := fmt.Sscanf(string(iter.Key()), dbHeaderKeyFmt, &version)
+		if err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions
+}
+
+// writeStateChanges sends the writes of the block committed at height
+// to the state sink, logging failures: the chain goes on without them.
+func (app *BaseApp) writeStateChanges(height int64) {
+	changes := app.stateChanges
+	app.stateChanges = nil
+	for i := range changes {
+		changes[i].Height = height
+	}
+	err := app.stateSink.WriteBlock(height, changes)
+	if err != nil {
+		app.Logger.Error("State sink failed", "height", height, "err", err)
+	}
+}
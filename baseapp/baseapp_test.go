@@ -4,17 +4,22 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	abci "github.com/tendermint/abci/types"
 	"github.com/tendermint/go-crypto"
+	tmtypes "github.com/tendermint/tendermint/types"
 	cmn "github.com/tendermint/tmlibs/common"
 	dbm "github.com/tendermint/tmlibs/db"
 	"github.com/tendermint/tmlibs/log"
 
+	"github.com/cosmos/cosmos-sdk/store"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
@@ -78,10 +83,14 @@ func TestLoadVersion(t *testing.T) {
 	assert.Equal(t, emptyCommitID, lastID)
 
 	// execute some blocks
-	header := abci.Header{Height: 1}
+	header := abci.Header{ChainID: "test-chain", Height: 1}
 	app.BeginBlock(abci.RequestBeginBlock{Header: header})
 	res := app.Commit()
-	commitID := sdk.CommitID{1, res.Data}
+	commitID1 := sdk.CommitID{1, res.Data}
+	header2 := abci.Header{ChainID: "test-chain", Height: 2}
+	app.BeginBlock(abci.RequestBeginBlock{Header: header2})
+	res = app.Commit()
+	commitID2 := sdk.CommitID{2, res.Data}
 
 	// reload
 	app = NewBaseApp(name, logger, db)
@@ -91,8 +100,374 @@ func TestLoadVersion(t *testing.T) {
 
 	lastHeight = app.LastBlockHeight()
 	lastID = app.LastCommitID()
-	assert.Equal(t, int64(1), lastHeight)
-	assert.Equal(t, commitID, lastID)
+	assert.Equal(t, int64(2), lastHeight)
+	assert.Equal(t, commitID2, lastID)
+
+	// the header of the last block is restored
+	ctx := app.checkState.ctx
+	assert.Equal(t, header2, ctx.BlockHeader())
+	assert.Equal(t, "test-chain", ctx.ChainID())
+	assert.Equal(t, int64(2), ctx.BlockHeight())
+
+	// reload an older version with its header
+	app = NewBaseApp(name, logger, db)
+	app.MountStoresIAVL(capKey)
+	err = app.LoadVersion(1, capKey)
+	assert.Nil(t, err)
+	assert.Equal(t, commitID1, app.LastCommitID())
+	assert.Equal(t, header, app.checkState.ctx.BlockHeader())
+}
+
+// Test that the headers and validator sets are pruned with the stores.
+func TestPruneHeaders(t *testing.T) {
+	logger := defaultLogger()
+	db := dbm.NewMemDB()
+	name := t.Name()
+	capKey := sdk.NewKVStoreKey("main")
+	newApp := func(pruning sdk.PruningStrategy) *BaseApp {
+		app := NewBaseApp(name, logger, db, SetPruning(pruning))
+		app.MountStoresIAVL(capKey)
+		err := app.LoadLatestVersion(capKey)
+		require.Nil(t, err)
+		return app
+	}
+	commit := func(app *BaseApp) {
+		height := app.LastBlockHeight() + 1
+		app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: height}})
+		app.EndBlock(abci.RequestEndBlock{})
+		app.Commit()
+	}
+	stored := func(height int64) bool {
+		header := db.Get(dbHeaderKey(height))
+		validators := db.Get(dbValidatorsKey(height))
+		assert.Equal(t, header != nil, validators != nil, "height %d", height)
+		return header != nil
+	}
+
+	app := newApp(sdk.PruneNothing)
+	app.InitChain(abci.RequestInitChain{})
+	for i := 0; i < 10; i++ {
+		commit(app)
+	}
+	for h := int64(1); h <= 10; h++ {
+		assert.True(t, stored(h), "height %d", h)
+	}
+
+	// the old ones are pruned once there is a strategy, then the ones
+	// which become old
+	app = newApp(sdk.NewPruningStrategy(2, 4))
+	for i := 0; i < 10; i++ {
+		commit(app)
+		for h := int64(1); h <= app.LastBlockHeight(); h++ {
+			kept := h > app.LastBlockHeight()-2 || h%4 == 0
+			assert.Equal(t, kept, stored(h), "height %d at %d", h, app.LastBlockHeight())
+		}
+	}
+
+	// the retained versions can still be loaded
+	app = NewBaseApp(name, logger, db)
+	app.MountStoresIAVL(capKey)
+	assert.Nil(t, app.LoadVersion(16, capKey))
+	assert.NotNil(t, app.LoadVersion(17, capKey))
+}
+
+// Test that loading fails if the header of the last
+// committed version is missing or inconsistent.
+func TestLoadVersionBadHeader(t *testing.T) {
+	logger := defaultLogger()
+	db := dbm.NewMemDB()
+	name := t.Name()
+	app := NewBaseApp(name, logger, db)
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey)
+	assert.Nil(t, err)
+
+	// the header height doesn't match the committed version
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 5}})
+	app.Commit()
+
+	app = NewBaseApp(name, logger, db)
+	app.MountStoresIAVL(capKey)
+	err = app.LoadLatestVersion(capKey)
+	assert.NotNil(t, err)
+
+	// the header is missing
+	db.Delete(dbHeaderKey(1))
+	app = NewBaseApp(name, logger, db)
+	app.MountStoresIAVL(capKey)
+	err = app.LoadLatestVersion(capKey)
+	assert.NotNil(t, err)
+}
+
+// Test that the app snapshots its stores periodically,
+// and that a new app resumes from a snapshot.
+func TestSnapshots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	logger := defaultLogger()
+	name := t.Name()
+	capKey := sdk.NewKVStoreKey("main")
+	app := NewBaseApp(name, logger, dbm.NewMemDB())
+	app.MountStoreWithDB(capKey, sdk.StoreTypeIAVL, dbm.NewMemDB())
+	err = app.LoadLatestVersion(capKey)
+	require.Nil(t, err)
+	app.SetSnapshots(dir, 2)
+
+	for height := int64(1); height <= 3; height++ {
+		header := abci.Header{Height: height, ChainID: "test-chain"}
+		app.BeginBlock(abci.RequestBeginBlock{Header: header})
+		app.deliverState.ctx.KVStore(capKey).Set([]byte{byte(height)}, []byte("value"))
+		app.Commit()
+		// the snapshot runs in the background
+		app.waitSnapshot()
+	}
+	snapshots, err := store.ListSnapshots(dir)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(snapshots))
+	require.Equal(t, int64(2), snapshots[0].Version())
+
+	_, err = app.CreateSnapshot(dir, 4)
+	require.NotNil(t, err)
+
+	// a new app resumes at the height of the snapshot with its header
+	db := dbm.NewMemDB()
+	app2 := NewBaseApp(name, logger, db)
+	app2.MountStoreWithDB(capKey, sdk.StoreTypeIAVL, dbm.NewMemDB())
+	err = app2.LoadLatestVersion(capKey)
+	require.Nil(t, err)
+	_, err = app2.RestoreSnapshot(dir, 2)
+	require.Nil(t, err)
+	require.Equal(t, snapshots[0].CommitID, app2.LastCommitID())
+	header := abci.Header{Height: 2, ChainID: "test-chain"}
+	require.Equal(t, header, app2.checkState.ctx.BlockHeader())
+	require.Equal(t, []byte("value"), app2.checkState.ctx.KVStore(capKey).Get([]byte{2}))
+	require.Nil(t, app2.checkState.ctx.KVStore(capKey).Get([]byte{3}))
+
+	// and commits the next block like the app it was snapshotted from
+	header = abci.Header{Height: 3, ChainID: "test-chain"}
+	app2.BeginBlock(abci.RequestBeginBlock{Header: header})
+	app2.deliverState.ctx.KVStore(capKey).Set([]byte{3}, []byte("value"))
+	app2.Commit()
+	require.Equal(t, app.LastCommitID(), app2.LastCommitID())
+
+	// it can't restore over a committed state
+	_, err = app2.RestoreSnapshot(dir, 2)
+	require.NotNil(t, err)
+}
+
+// Test that each module's genesis is dispatched to its InitGenesis,
+// and that genesis errors are returned.
+func TestInitGenesis(t *testing.T) {
+	app := newBaseApp(t.Name())
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey)
+	assert.Nil(t, err)
+
+	var order []string
+	initGenesis := func(key string) sdk.InitGenesis {
+		return func(ctx sdk.Context, data json.RawMessage) error {
+			order = append(order, key)
+			if string(data) == `"bad"` {
+				return fmt.Errorf("bad genesis for %s", key)
+			}
+			if data != nil {
+				ctx.KVStore(capKey).Set([]byte(key), data)
+			}
+			return nil
+		}
+	}
+	app.RegisterInitGenesis("second", initGenesis("second"))
+	app.RegisterInitGenesis("first", initGenesis("first"))
+	app.RegisterInitGenesis("missing", initGenesis("missing"))
+	assert.Panics(t, func() { app.RegisterInitGenesis("first", initGenesis("first")) })
+
+	// modules run in registration order with their own value
+	_, sdkErr := app.initChain(abci.RequestInitChain{AppStateBytes: []byte(`{"first": 1, "second": 2, "other": 3}`)})
+	require.Nil(t, sdkErr)
+	assert.Equal(t, []string{"second", "first", "missing"}, order)
+	store := app.deliverState.ctx.KVStore(capKey)
+	assert.Equal(t, []byte("1"), store.Get([]byte("first")))
+	assert.Equal(t, []byte("2"), store.Get([]byte("second")))
+	assert.Nil(t, store.Get([]byte("missing")))
+
+	// module errors are returned
+	_, sdkErr = app.initChain(abci.RequestInitChain{AppStateBytes: []byte(`{"first": "bad"}`)})
+	require.NotNil(t, sdkErr)
+	assert.Equal(t, sdk.CodeGenesisParse, sdkErr.ABCICode())
+
+	// so are parse errors
+	_, sdkErr = app.initChain(abci.RequestInitChain{AppStateBytes: []byte(`not json`)})
+	require.NotNil(t, sdkErr)
+	assert.Equal(t, sdk.CodeGenesisParse, sdkErr.ABCICode())
+	assert.Panics(t, func() {
+		app.InitChain(abci.RequestInitChain{AppStateBytes: []byte(`not json`)})
+	})
+}
+
+func TestExportGenesis(t *testing.T) {
+	app := newBaseApp(t.Name())
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey)
+	assert.Nil(t, err)
+
+	app.RegisterInitGenesis("value", func(ctx sdk.Context, data json.RawMessage) error {
+		ctx.KVStore(capKey).Set([]byte("value"), data)
+		return nil
+	})
+	exportGenesis := func(key string) sdk.ExportGenesis {
+		return func(ctx sdk.Context) json.RawMessage {
+			// writes must not reach the committed state
+			ctx.KVStore(capKey).Set([]byte(key), []byte(`"exported"`))
+			return ctx.KVStore(capKey).Get([]byte(key))
+		}
+	}
+	app.RegisterExportGenesis("value", exportGenesis("value"))
+	app.RegisterExportGenesis("nothing", func(ctx sdk.Context) json.RawMessage { return nil })
+	assert.Panics(t, func() { app.RegisterExportGenesis("value", exportGenesis("value")) })
+
+	app.InitChain(abci.RequestInitChain{AppStateBytes: []byte(`{"value": 1}`)})
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	app.Commit()
+
+	// modules without state are left out
+	appState, err := app.ExportGenesis()
+	require.Nil(t, err)
+	assert.Equal(t, `{"value":"exported"}`, string(appState))
+	store := app.checkState.ctx.KVStore(capKey)
+	assert.Equal(t, []byte("1"), store.Get([]byte("value")))
+}
+
+func TestExportValidators(t *testing.T) {
+	logger := defaultLogger()
+	db := dbm.NewMemDB()
+	name := t.Name()
+	app := NewBaseApp(name, logger, db)
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey)
+	assert.Nil(t, err)
+
+	pubKeys := make([]crypto.PubKey, 3)
+	for i := range pubKeys {
+		pubKeys[i] = crypto.GenPrivKeyEd25519().PubKey()
+	}
+	var updates []abci.Validator
+	app.SetEndBlocker(func(ctx sdk.Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+		return abci.ResponseEndBlock{ValidatorUpdates: updates}
+	})
+
+	// the validator set is the genesis one updated by EndBlock
+	app.InitChain(abci.RequestInitChain{Validators: []abci.Validator{
+		{PubKey: pubKeys[0].Bytes(), Power: 10},
+		{PubKey: pubKeys[1].Bytes(), Power: 5},
+	}})
+	updates = []abci.Validator{
+		{PubKey: pubKeys[1].Bytes(), Power: 0},
+		{PubKey: pubKeys[2].Bytes(), Power: 7},
+		{PubKey: pubKeys[0].Bytes(), Power: 20},
+	}
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+	expected := []tmtypes.GenesisValidator{
+		{PubKey: pubKeys[0], Power: 20},
+		{PubKey: pubKeys[2], Power: 7},
+	}
+	validators, err := app.ExportValidators()
+	require.Nil(t, err)
+	assert.Equal(t, expected, validators)
+
+	// it is restored with its version
+	updates = []abci.Validator{{PubKey: pubKeys[2].Bytes(), Power: 0}}
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 2}})
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+	app = NewBaseApp(name, logger, db)
+	app.MountStoresIAVL(capKey)
+	err = app.LoadVersion(1, capKey)
+	assert.Nil(t, err)
+	validators, err = app.ExportValidators()
+	require.Nil(t, err)
+	assert.Equal(t, expected, validators)
+
+	// and the export fails if it is unknown
+	db.Delete(dbValidatorsKey(2))
+	app = NewBaseApp(name, logger, db)
+	app.MountStoresIAVL(capKey)
+	err = app.LoadLatestVersion(capKey)
+	assert.Nil(t, err)
+	_, err = app.ExportValidators()
+	assert.NotNil(t, err)
+}
+
+func TestSetOption(t *testing.T) {
+	app := newBaseApp(t.Name())
+
+	var minFee int64
+	app.RegisterOption("test/min_fee", sdk.Int64Option(
+		func(fee int64) error {
+			if fee < 0 {
+				return fmt.Errorf("negative fee %d", fee)
+			}
+			return nil
+		},
+		func(fee int64) { minFee = fee },
+	))
+	assert.Panics(t, func() { app.RegisterOption("test/min_fee", sdk.BoolOption(func(bool) {})) })
+	assert.Panics(t, func() { app.RegisterOption("test/", sdk.BoolOption(func(bool) {})) })
+	assert.Equal(t, []string{"test/min_fee"}, app.Options())
+
+	res := app.SetOption(abci.RequestSetOption{Key: "test/min_fee", Value: "10"})
+	assert.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+	assert.Equal(t, int64(10), minFee)
+
+	// invalid values are rejected
+	res = app.SetOption(abci.RequestSetOption{Key: "test/min_fee", Value: "-1"})
+	assert.Equal(t, uint32(sdk.CodeInvalidOption), res.Code, res.Log)
+	assert.Equal(t, int64(10), minFee)
+
+	res = app.SetOption(abci.RequestSetOption{Key: "test/other", Value: "1"})
+	assert.Equal(t, uint32(sdk.CodeUnknownRequest), res.Code, res.Log)
+
+	// options can only be set by query when enabled
+	query := abci.RequestQuery{Path: "/app/setoption/test/min_fee", Data: []byte("20")}
+	qres := app.Query(query)
+	assert.Equal(t, uint32(sdk.CodeUnauthorized), qres.Code, qres.Log)
+	assert.Equal(t, int64(10), minFee)
+
+	app.SetOptionQueries(true)
+	qres = app.Query(query)
+	assert.Equal(t, uint32(sdk.CodeOK), qres.Code, qres.Log)
+	assert.Equal(t, int64(20), minFee)
+}
+
+// Test that the first block gets its header from BeginBlock,
+// even though its deliverState was created in InitChain.
+func TestInitChainBeginBlockHeader(t *testing.T) {
+	app := newBaseApp(t.Name())
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey)
+	assert.Nil(t, err)
+	app.SetInitChainer(func(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
+		return abci.ResponseInitChain{}
+	})
+
+	app.InitChain(abci.RequestInitChain{AppStateBytes: []byte("{}")})
+	header := abci.Header{ChainID: "test-chain", Height: 1}
+	app.BeginBlock(abci.RequestBeginBlock{Header: header})
+	assert.Equal(t, "test-chain", app.deliverState.ctx.ChainID())
+	assert.Equal(t, int64(1), app.deliverState.ctx.BlockHeight())
+
+	// the check state uses the committed header
+	app.Commit()
+	assert.Equal(t, header, app.checkState.ctx.BlockHeader())
+	assert.Equal(t, "test-chain", app.checkState.ctx.ChainID())
 }
 
 // Test that the app hash is static
@@ -173,7 +548,7 @@ func TestInitChainer(t *testing.T) {
 	}
 
 	query := abci.RequestQuery{
-		Path: "/main/key",
+		Path: "/store/main/key",
 		Data: key,
 	}
 
@@ -185,6 +560,7 @@ func TestInitChainer(t *testing.T) {
 	// set initChainer and try again - should see the value
 	app.SetInitChainer(initChainer)
 	app.InitChain(abci.RequestInitChain{AppStateBytes: []byte("{}")}) // must have valid JSON genesis file, even if empty
+	app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
 	app.Commit()
 	res = app.Query(query)
 	assert.Equal(t, value, res.Value)
@@ -287,7 +663,7 @@ func TestQuery(t *testing.T) {
 	})
 
 	query := abci.RequestQuery{
-		Path: "/main/key",
+		Path: "/store/main/key",
 		Data: key,
 	}
 
@@ -314,10 +690,465 @@ func TestQuery(t *testing.T) {
 	assert.Equal(t, value, res.Value)
 }
 
+// Test that custom queries are routed to the registered querier
+// and can't write to the committed state.
+func TestQueryCustom(t *testing.T) {
+	app := newBaseApp(t.Name())
+
+	// make a cap key and mount the store
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey) // needed to make stores non-nil
+	assert.Nil(t, err)
+
+	key, value := []byte("hello"), []byte("goodbye")
+	app.SetAnteHandler(func(ctx sdk.Context, tx sdk.Tx) (newCtx sdk.Context, res sdk.Result, abort bool) { return })
+	app.Router().AddRoute(msgType, func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		ctx.KVStore(capKey).Set(key, value)
+		return sdk.Result{}
+	})
+	app.QueryRouter().AddRoute("test", func(ctx sdk.Context, path []string, req abci.RequestQuery) ([]byte, sdk.Error) {
+		if len(path) != 1 || path[0] != "value" {
+			return nil, sdk.ErrUnknownRequest("unknown test query")
+		}
+		store := ctx.KVStore(capKey)
+		res := store.Get(req.Data)
+		// writes are discarded
+		store.Set(req.Data, []byte("overwritten"))
+		return res, nil
+	})
+	app.QueryRouter().AddRoute("test/sub", func(ctx sdk.Context, path []string, req abci.RequestQuery) ([]byte, sdk.Error) {
+		return []byte(strings.Join(path, ",")), nil
+	})
+
+	app.BeginBlock(abci.RequestBeginBlock{})
+	app.Deliver(testUpdatePowerTx{})
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+
+	query := abci.RequestQuery{Path: "/custom/test/value", Data: key}
+	res := app.Query(query)
+	require.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+	assert.Equal(t, value, res.Value)
+	assert.Equal(t, app.LastBlockHeight(), res.Height)
+
+	// the querier's write didn't go through
+	res = app.Query(query)
+	assert.Equal(t, value, res.Value)
+	res = app.Query(abci.RequestQuery{Path: "/store/main/key", Data: key})
+	assert.Equal(t, value, res.Value)
+
+	// the querier of the longest route gets the rest of the path
+	res = app.Query(abci.RequestQuery{Path: "/custom/test/sub/a/b"})
+	require.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+	assert.Equal(t, "a,b", string(res.Value))
+
+	// querier errors are returned
+	res = app.Query(abci.RequestQuery{Path: "/custom/test/other", Data: key})
+	assert.Equal(t, uint32(sdk.CodeUnknownRequest), res.Code)
+
+	// unknown routes and paths are rejected
+	res = app.Query(abci.RequestQuery{Path: "/custom/nope/value", Data: key})
+	assert.Equal(t, uint32(sdk.CodeUnknownRequest), res.Code)
+	res = app.Query(abci.RequestQuery{Path: "/custom", Data: key})
+	assert.Equal(t, uint32(sdk.CodeUnknownRequest), res.Code)
+	res = app.Query(abci.RequestQuery{Path: "/main/key", Data: key})
+	assert.Equal(t, uint32(sdk.CodeUnknownRequest), res.Code)
+
+	// the routes are listed
+	res = app.Query(abci.RequestQuery{Path: "/app/routes"})
+	require.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+	var routes AppRoutes
+	require.Nil(t, json.Unmarshal(res.Value, &routes))
+	assert.Equal(t, AppRoutes{Msgs: []string{msgType}, Queries: []string{"test", "test/sub"}}, routes)
+}
+
+// Test that custom and store queries read the state of past heights,
+// and that heights which aren't retained are errors.
+func TestQueryHistorical(t *testing.T) {
+	logger := defaultLogger()
+	app := NewBaseApp(t.Name(), logger, dbm.NewMemDB(), SetPruning(sdk.NewPruningStrategy(2, 0)))
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey)
+	require.Nil(t, err)
+
+	key := []byte("key")
+	app.QueryRouter().AddRoute("test", func(ctx sdk.Context, path []string, req abci.RequestQuery) ([]byte, sdk.Error) {
+		value := ctx.KVStore(capKey).Get(key)
+		ctx.KVStore(capKey).Set(key, []byte("overwritten"))
+		return []byte(fmt.Sprintf("%d:%s", ctx.BlockHeight(), value)), nil
+	})
+	for height := int64(1); height <= 4; height++ {
+		app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: height}})
+		app.deliverState.ctx.KVStore(capKey).Set(key, []byte(fmt.Sprintf("value%d", height)))
+		app.Commit()
+	}
+
+	// the retained heights, and 0 for the latest
+	for _, height := range []int64{0, 3, 4} {
+		expected := height
+		if height == 0 {
+			expected = 4
+		}
+		res := app.Query(abci.RequestQuery{Path: "/custom/test", Height: height})
+		require.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+		require.Equal(t, fmt.Sprintf("%d:value%d", expected, expected), string(res.Value))
+		require.Equal(t, expected, res.Height)
+		if height != 0 {
+			res = app.Query(abci.RequestQuery{Path: "/store/main/key", Data: key, Height: height})
+			require.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+			require.Equal(t, fmt.Sprintf("value%d", height), string(res.Value))
+		}
+	}
+
+	// the querier's writes at a past height didn't go through
+	res := app.Query(abci.RequestQuery{Path: "/store/main/key", Data: key, Height: 3})
+	require.Equal(t, "value3", string(res.Value))
+
+	// pruned and future heights
+	for _, height := range []int64{-1, 1, 2, 5} {
+		res := app.Query(abci.RequestQuery{Path: "/custom/test", Height: height})
+		require.Equal(t, uint32(sdk.CodeUnknownRequest), res.Code, "height %d", height)
+		res = app.Query(abci.RequestQuery{Path: "/store/main/key", Data: key, Height: height})
+		require.Equal(t, uint32(sdk.CodeUnknownRequest), res.Code, "height %d", height)
+	}
+}
+
+// Test that gas is metered across the ante handler and the handler,
+// and that running out of gas aborts the tx without writing state.
+func TestGasConsumption(t *testing.T) {
+	app := newBaseApp(t.Name())
+
+	// make a cap key and mount the store
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey) // needed to make stores non-nil
+	assert.Nil(t, err)
+
+	key, value := []byte("hello"), []byte("goodbye")
+
+	var gasLimit int64
+	app.SetAnteHandler(func(ctx sdk.Context, tx sdk.Tx) (newCtx sdk.Context, res sdk.Result, abort bool) {
+		newCtx = ctx.WithGasMeter(sdk.NewGasMeter(gasLimit))
+		return
+	})
+	app.Router().AddRoute(msgType, func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		store := ctx.KVStore(capKey)
+		store.Set(key, value)
+		return sdk.Result{}
+	})
+
+	tx := testUpdatePowerTx{} // doesn't matter
+	app.BeginBlock(abci.RequestBeginBlock{})
+
+	// not enough gas to write the value
+	gasLimit = 10
+	res := app.Deliver(tx)
+	assert.Equal(t, sdk.CodeOutOfGas, res.Code, res.Log)
+	assert.Equal(t, gasLimit, res.GasWanted)
+	assert.True(t, res.GasUsed > gasLimit)
+
+	// enough gas, GasUsed is reported
+	gasLimit = 10000
+	res = app.Deliver(tx)
+	assert.True(t, res.IsOK(), res.Log)
+	assert.Equal(t, gasLimit, res.GasWanted)
+	assert.True(t, res.GasUsed > 0)
+	assert.True(t, res.GasUsed <= gasLimit)
+	app.Commit()
+
+	query := abci.RequestQuery{
+		Path: "/store/main/key",
+		Data: key,
+	}
+	qres := app.Query(query)
+	assert.Equal(t, value, qres.Value)
+}
+
+// Test that each tx reports its own gas, not that of the block so far,
+// whether or not the ante handler installs a meter or aborts.
+func TestGasUsedPerTx(t *testing.T) {
+	app := newBaseApp(t.Name())
+
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey) // needed to make stores non-nil
+	assert.Nil(t, err)
+
+	key, value := []byte("hello"), []byte("goodbye")
+	app.Router().AddRoute(msgType, func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		ctx.KVStore(capKey).Set(key, value)
+		return sdk.Result{}
+	})
+
+	tx := testUpdatePowerTx{} // doesn't matter
+	app.BeginBlock(abci.RequestBeginBlock{})
+
+	// without an ante handler
+	res1 := app.Deliver(tx)
+	require.True(t, res1.IsOK(), res1.Log)
+	require.True(t, res1.GasUsed > 0)
+	res2 := app.Deliver(tx)
+	require.True(t, res2.IsOK(), res2.Log)
+	assert.Equal(t, res1.GasUsed, res2.GasUsed)
+
+	// an aborting ante handler reports the gas of its meter
+	var gasLimit int64 = 1000
+	app.SetAnteHandler(func(ctx sdk.Context, tx sdk.Tx) (newCtx sdk.Context, res sdk.Result, abort bool) {
+		newCtx = ctx.WithGasMeter(sdk.NewGasMeter(gasLimit))
+		newCtx.GasMeter().ConsumeGas(10, "test")
+		return newCtx, sdk.ErrUnauthorized("abort").Result(), true
+	})
+	for i := 0; i < 2; i++ {
+		res := app.Deliver(tx)
+		assert.Equal(t, sdk.CodeUnauthorized, res.Code, res.Log)
+		assert.Equal(t, gasLimit, res.GasWanted)
+		assert.Equal(t, int64(10), res.GasUsed)
+	}
+
+	// failing before the ante handler, on an unknown route
+	res := app.Deliver(testMultiMsgTx{[]sdk.Msg{testWriteMsg{}}})
+	require.False(t, res.IsOK())
+	assert.Equal(t, int64(0), res.GasUsed)
+}
+
+// Test that the minimum gas prices are only seen by CheckTx.
+func TestMinimumGasPrices(t *testing.T) {
+	app := newBaseApp(t.Name())
+
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey) // needed to make stores non-nil
+	assert.Nil(t, err)
+
+	var prices sdk.Coins
+	app.SetAnteHandler(func(ctx sdk.Context, tx sdk.Tx) (newCtx sdk.Context, res sdk.Result, abort bool) {
+		prices = ctx.MinimumGasPrices()
+		return
+	})
+	app.Router().AddRoute(msgType, func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		return sdk.Result{}
+	})
+
+	tx := testUpdatePowerTx{} // doesn't matter
+	app.BeginBlock(abci.RequestBeginBlock{})
+
+	res := app.Check(tx)
+	assert.True(t, res.IsOK(), res.Log)
+	assert.Nil(t, prices)
+
+	minimum := sdk.Coins{{"atom", 2}}
+	app.SetMinimumGasPrices(minimum)
+	res = app.Check(tx)
+	assert.True(t, res.IsOK(), res.Log)
+	assert.Equal(t, minimum, prices)
+	assert.Equal(t, minimum, app.NewContext(true, abci.Header{}).MinimumGasPrices())
+
+	res = app.Deliver(tx)
+	assert.True(t, res.IsOK(), res.Log)
+	assert.Nil(t, prices)
+}
+
+// Test that an out-of-gas tx leaves no trace in the store.
+func TestOutOfGasRollback(t *testing.T) {
+	app := newBaseApp(t.Name())
+
+	// make a cap key and mount the store
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey) // needed to make stores non-nil
+	assert.Nil(t, err)
+
+	key, value := []byte("hello"), []byte("goodbye")
+
+	app.SetAnteHandler(func(ctx sdk.Context, tx sdk.Tx) (newCtx sdk.Context, res sdk.Result, abort bool) {
+		newCtx = ctx.WithGasMeter(sdk.NewGasMeter(1000))
+		return
+	})
+	app.Router().AddRoute(msgType, func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		store := ctx.KVStore(capKey)
+		store.Set(key, value)
+		// burn through the rest of the gas after writing
+		for {
+			store.Get(key)
+		}
+	})
+
+	tx := testUpdatePowerTx{} // doesn't matter
+	app.BeginBlock(abci.RequestBeginBlock{})
+	res := app.Deliver(tx)
+	assert.Equal(t, sdk.CodeOutOfGas, res.Code, res.Log)
+	app.Commit()
+
+	query := abci.RequestQuery{
+		Path: "/store/main/key",
+		Data: key,
+	}
+	qres := app.Query(query)
+	assert.Equal(t, 0, len(qres.Value))
+}
+
 //----------------------
 // TODO: clean this up
 
 // A mock transaction to update a validator's voting power.
+// Test that all Msgs in a tx are run in order, and that a failing
+// Msg reverts the writes of the Msgs before it.
+func TestMultiMsgDeliverTx(t *testing.T) {
+	app := newBaseApp(t.Name())
+
+	// make a cap key and mount the store
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey) // needed to make stores non-nil
+	assert.Nil(t, err)
+
+	app.SetAnteHandler(func(ctx sdk.Context, tx sdk.Tx) (newCtx sdk.Context, res sdk.Result, abort bool) { return })
+	app.Router().AddRoute(testWriteMsgType, func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		wmsg := msg.(testWriteMsg)
+		if wmsg.fail {
+			return sdk.ErrUnknownRequest("failing msg").Result()
+		}
+		ctx.KVStore(capKey).Set(wmsg.key, wmsg.value)
+		return sdk.Result{}
+	})
+
+	key1, key2, key3 := []byte("key1"), []byte("key2"), []byte("key3")
+	value := []byte("value")
+	getValue := func(key []byte) []byte {
+		return app.Query(abci.RequestQuery{Path: "/store/main/key", Data: key}).Value
+	}
+
+	// both msgs succeed
+	app.BeginBlock(abci.RequestBeginBlock{})
+	tx := testMultiMsgTx{[]sdk.Msg{
+		testWriteMsg{key: key1, value: value},
+		testWriteMsg{key: key2, value: value},
+	}}
+	res := app.Deliver(tx)
+	require.True(t, res.IsOK(), res.Log)
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+	assert.Equal(t, value, getValue(key1))
+	assert.Equal(t, value, getValue(key2))
+
+	// second msg fails, so the first is reverted
+	app.BeginBlock(abci.RequestBeginBlock{})
+	tx = testMultiMsgTx{[]sdk.Msg{
+		testWriteMsg{key: key3, value: value},
+		testWriteMsg{fail: true},
+	}}
+	res = app.Deliver(tx)
+	require.Equal(t, sdk.CodeUnknownRequest, res.Code)
+	app.EndBlock(abci.RequestEndBlock{})
+	app.Commit()
+	assert.Equal(t, 0, len(getValue(key3)))
+
+	// a tx with no msgs is rejected
+	app.BeginBlock(abci.RequestBeginBlock{})
+	res = app.Deliver(testMultiMsgTx{})
+	require.False(t, res.IsOK())
+}
+
+// stateSinkRecorder records the blocks of state changes it receives.
+type stateSinkRecorder struct {
+	heights []int64
+	changes []store.StateChange
+}
+
+func (r *stateSinkRecorder) WriteBlock(height int64, changes []store.StateChange) error {
+	r.heights = append(r.heights, height)
+	r.changes = append(r.changes, changes...)
+	return nil
+}
+
+// Test that the committed writes of each block are sent to the state
+// sink in order, with the index of their tx.
+func TestStateSink(t *testing.T) {
+	app := newBaseApp(t.Name())
+	capKey := sdk.NewKVStoreKey("main")
+	app.MountStoresIAVL(capKey)
+	err := app.LoadLatestVersion(capKey)
+	require.Nil(t, err)
+	sink := &stateSinkRecorder{}
+	app.SetStateSink(sink)
+
+	app.SetAnteHandler(func(ctx sdk.Context, tx sdk.Tx) (newCtx sdk.Context, res sdk.Result, abort bool) {
+		ctx.KVStore(capKey).Set([]byte("ante"), []byte("fee"))
+		return
+	})
+	app.Router().AddRoute(testWriteMsgType, func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		wmsg := msg.(testWriteMsg)
+		ctx.KVStore(capKey).Set(wmsg.key, wmsg.value)
+		if wmsg.fail {
+			return sdk.ErrUnknownRequest("failing msg").Result()
+		}
+		return sdk.Result{}
+	})
+	app.SetBeginBlocker(func(ctx sdk.Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
+		ctx.KVStore(capKey).Set([]byte("begin"), []byte("1"))
+		return abci.ResponseBeginBlock{}
+	})
+	app.SetEndBlocker(func(ctx sdk.Context, req abci.RequestEndBlock) abci.ResponseEndBlock {
+		ctx.KVStore(capKey).Delete([]byte("begin"))
+		return abci.ResponseEndBlock{}
+	})
+
+	for height := int64(1); height <= 2; height++ {
+		sink.changes = nil
+		app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: height}})
+		res := app.Deliver(testMultiMsgTx{[]sdk.Msg{testWriteMsg{key: []byte("b"), value: []byte("1")}}})
+		require.True(t, res.IsOK(), res.Log)
+		// the writes of the failed msg are reverted, not those of the ante handler
+		res = app.Deliver(testMultiMsgTx{[]sdk.Msg{testWriteMsg{key: []byte("c"), value: []byte("2"), fail: true}}})
+		require.False(t, res.IsOK())
+		res = app.Deliver(testMultiMsgTx{[]sdk.Msg{testWriteMsg{key: []byte("a"), value: []byte("3")}}})
+		require.True(t, res.IsOK(), res.Log)
+		app.EndBlock(abci.RequestEndBlock{})
+		app.Commit()
+
+		change := func(txIndex int64, key, value string, deleted bool) store.StateChange {
+			c := store.StateChange{Height: height, TxIndex: txIndex, StoreName: "main", Key: []byte(key), Deleted: deleted}
+			if value != "" {
+				c.Value = []byte(value)
+			}
+			return c
+		}
+		require.Equal(t, []store.StateChange{
+			change(-1, "begin", "1", false),
+			change(0, "ante", "fee", false),
+			change(0, "b", "1", false),
+			change(1, "ante", "fee", false),
+			change(2, "ante", "fee", false),
+			change(2, "a", "3", false),
+			change(-1, "begin", "", true),
+		}, sink.changes)
+	}
+	require.Equal(t, []int64{1, 2}, sink.heights)
+}
+
+const testWriteMsgType = "testWrite"
+
+type testWriteMsg struct {
+	key   []byte
+	value []byte
+	fail  bool
+}
+
+func (msg testWriteMsg) Type() string                            { return testWriteMsgType }
+func (msg testWriteMsg) Get(key interface{}) (value interface{}) { return nil }
+func (msg testWriteMsg) GetSignBytes() []byte                    { return nil }
+func (msg testWriteMsg) ValidateBasic() sdk.Error                { return nil }
+func (msg testWriteMsg) GetSigners() []sdk.Address               { return nil }
+
+type testMultiMsgTx struct {
+	msgs []sdk.Msg
+}
+
+func (tx testMultiMsgTx) GetMsgs() []sdk.Msg                { return tx.msgs }
+func (tx testMultiMsgTx) GetSignatures() []sdk.StdSignature { return nil }
+
 type testUpdatePowerTx struct {
 	Addr     []byte
 	NewPower int64
@@ -327,7 +1158,7 @@ const msgType = "testUpdatePowerTx"
 
 func (tx testUpdatePowerTx) Type() string                            { return msgType }
 func (tx testUpdatePowerTx) Get(key interface{}) (value interface{}) { return nil }
-func (tx testUpdatePowerTx) GetMsg() sdk.Msg                         { return tx }
+func (tx testUpdatePowerTx) GetMsgs() []sdk.Msg                      { return []sdk.Msg{tx} }
 func (tx testUpdatePowerTx) GetSignBytes() []byte                    { return nil }
 func (tx testUpdatePowerTx) ValidateBasic() sdk.Error                { return nil }
 func (tx testUpdatePowerTx) GetSigners() []sdk.Address               { return nil }
@@ -0,0 +1,66 @@
+package baseapp
+
+import (
+	"fmt"
+	"sort"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// QueryRouter provides queriers for each query path.
+type QueryRouter interface {
+	AddRoute(r string, h sdk.Querier) (rtr QueryRouter)
+	Route(path string) (r string, h sdk.Querier)
+	Routes() []string
+}
+
+type queryRouter struct {
+	routes map[string]sdk.Querier
+}
+
+// nolint
+// NewQueryRouter - create new query router
+func NewQueryRouter() *queryRouter {
+	return &queryRouter{
+		routes: make(map[string]sdk.Querier),
+	}
+}
+
+// AddRoute - register a querier for the paths after "/custom" which are
+// r or start with r + "/", like the routes of a Router.
+// Panics if r is malformed or already registered.
+func (rtr *queryRouter) AddRoute(r string, h sdk.Querier) QueryRouter {
+	if !isRoute(r) {
+		panic("route expressions can only contain alphanumeric characters separated by '/'")
+	}
+	if _, ok := rtr.routes[r]; ok {
+		panic(fmt.Sprintf("query route %s has already been registered", r))
+	}
+	rtr.routes[r] = h
+
+	return rtr
+}
+
+// Route - returns the route registered for the longest prefix of path,
+// matching whole segments only, and its querier.
+// Returns a nil querier if no route matches.
+func (rtr *queryRouter) Route(path string) (r string, h sdk.Querier) {
+	r, ok := longestRoute(path, func(r string) bool {
+		_, ok := rtr.routes[r]
+		return ok
+	})
+	if !ok {
+		return "", nil
+	}
+	return r, rtr.routes[r]
+}
+
+// Routes - returns all registered routes in sorted order.
+func (rtr *queryRouter) Routes() []string {
+	routes := make([]string, 0, len(rtr.routes))
+	for r := range rtr.routes {
+		routes = append(routes, r)
+	}
+	sort.Strings(routes)
+	return routes
+}
@@ -1,7 +1,10 @@
 package baseapp
 
 import (
+	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
@@ -10,16 +13,11 @@ import (
 type Router interface {
 	AddRoute(r string, h sdk.Handler) (rtr Router)
 	Route(path string) (h sdk.Handler)
-}
-
-// map a transaction type to a handler and an initgenesis function
-type route struct {
-	r string
-	h sdk.Handler
+	Routes() []string
 }
 
 type router struct {
-	routes []route
+	routes map[string]sdk.Handler
 }
 
 // nolint
@@ -27,29 +25,63 @@ type router struct {
 // TODO either make Function unexported or make return type (router) Exported
 func NewRouter() *router {
 	return &router{
-		routes: make([]route, 0),
+		routes: make(map[string]sdk.Handler),
 	}
 }
 
-var isAlpha = regexp.MustCompile(`^[a-zA-Z]+$`).MatchString
+// routes are one or more alphanumeric segments separated by "/", eg. "stake/delegate"
+var isRoute = regexp.MustCompile(`^[a-zA-Z0-9]+(/[a-zA-Z0-9]+)*$`).MatchString
 
-// AddRoute - TODO add description
+// AddRoute - register a handler for all Msgs whose type is r or starts with r + "/".
+// Panics if r is malformed or already registered.
 func (rtr *router) AddRoute(r string, h sdk.Handler) Router {
-	if !isAlpha(r) {
-		panic("route expressions can only contain alphanumeric characters")
+	if !isRoute(r) {
+		panic("route expressions can only contain alphanumeric characters separated by '/'")
+	}
+	if _, ok := rtr.routes[r]; ok {
+		panic(fmt.Sprintf("route %s has already been registered", r))
 	}
-	rtr.routes = append(rtr.routes, route{r, h})
+	rtr.routes[r] = h
 
 	return rtr
 }
 
-// Route - TODO add description
-// TODO handle expressive matches.
+// Route - returns the handler registered for the longest prefix of path,
+// matching whole segments only, eg. "stake/delegate" is handled by the
+// route "stake/delegate" if present, otherwise by "stake".
+// Returns nil if no route matches.
 func (rtr *router) Route(path string) (h sdk.Handler) {
-	for _, route := range rtr.routes {
-		if route.r == path {
-			return route.h
+	r, ok := longestRoute(path, func(r string) bool {
+		_, ok := rtr.routes[r]
+		return ok
+	})
+	if !ok {
+		return nil
+	}
+	return rtr.routes[r]
+}
+
+// longestRoute returns the longest prefix of path, of whole segments,
+// which is a registered route.
+func longestRoute(path string, registered func(r string) bool) (string, bool) {
+	for {
+		if registered(path) {
+			return path, true
+		}
+		i := strings.LastIndex(path, "/")
+		if i < 0 {
+			return "", false
 		}
+		path = path[:i]
+	}
+}
+
+// Routes - returns all registered routes in sorted order.
+func (rtr *router) Routes() []string {
+	routes := make([]string, 0, len(rtr.routes))
+	for r := range rtr.routes {
+		routes = append(routes, r)
 	}
-	return nil
+	sort.Strings(routes)
+	return routes
 }
@@ -0,0 +1,96 @@
+package baseapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// returns a handler which reports the route it was registered for in Result.Log
+func routeHandler(r string) sdk.Handler {
+	return func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		return sdk.Result{Log: r}
+	}
+}
+
+func TestRouterRoute(t *testing.T) {
+	rtr := NewRouter()
+	rtr.AddRoute("bank", routeHandler("bank")).
+		AddRoute("stake", routeHandler("stake")).
+		AddRoute("stake/delegate", routeHandler("stake/delegate"))
+
+	cases := []struct {
+		path  string
+		route string // empty if no handler expected
+	}{
+		{"bank", "bank"},
+		{"bank/send", "bank"},
+		{"stake", "stake"},
+		{"stake/unbond", "stake"},
+		{"stake/delegate", "stake/delegate"},
+		{"stake/delegate/more", "stake/delegate"},
+		{"stakes", ""},
+		{"bankrupt/send", ""},
+		{"ibc", ""},
+		{"", ""},
+	}
+
+	for _, tc := range cases {
+		h := rtr.Route(tc.path)
+		if tc.route == "" {
+			assert.Nil(t, h, tc.path)
+			continue
+		}
+		require.NotNil(t, h, tc.path)
+		assert.Equal(t, tc.route, h(sdk.Context{}, nil).Log, tc.path)
+	}
+}
+
+func TestRouterAddRoute(t *testing.T) {
+	rtr := NewRouter()
+	rtr.AddRoute("stake/delegate", routeHandler("stake/delegate"))
+
+	// duplicates are rejected
+	assert.Panics(t, func() { rtr.AddRoute("stake/delegate", routeHandler("dup")) })
+
+	// malformed routes are rejected
+	for _, r := range []string{"", "/stake", "stake/", "stake//delegate", "stake-delegate", "stake delegate"} {
+		assert.Panics(t, func() { rtr.AddRoute(r, routeHandler(r)) }, r)
+	}
+
+	rtr.AddRoute("stake", routeHandler("stake")).
+		AddRoute("bank", routeHandler("bank"))
+	assert.Equal(t, []string{"bank", "stake", "stake/delegate"}, rtr.Routes())
+}
+
+func TestQueryRouterRoute(t *testing.T) {
+	querier := func(ctx sdk.Context, path []string, req abci.RequestQuery) ([]byte, sdk.Error) {
+		return nil, nil
+	}
+	rtr := NewQueryRouter()
+	rtr.AddRoute("bank", querier).
+		AddRoute("stake/pool", querier)
+	assert.Panics(t, func() { rtr.AddRoute("bank", querier) })
+	assert.Panics(t, func() { rtr.AddRoute("stake-pool", querier) })
+	assert.Equal(t, []string{"bank", "stake/pool"}, rtr.Routes())
+
+	cases := []struct {
+		path  string
+		route string // empty if no querier expected
+	}{
+		{"bank", "bank"},
+		{"bank/supply", "bank"},
+		{"stake/pool/atom", "stake/pool"},
+		{"stake", ""},
+		{"stake/params", ""},
+	}
+	for _, tc := range cases {
+		r, h := rtr.Route(tc.path)
+		assert.Equal(t, tc.route, r, tc.path)
+		assert.Equal(t, tc.route != "", h != nil, tc.path)
+	}
+}
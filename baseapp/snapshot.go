@@ -0,0 +1,96 @@
+package baseapp
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/cosmos/cosmos-sdk/store"
+)
+
+// CreateSnapshot snapshots the stores at the committed height in dir,
+// or at the latest height if height is 0. The header of the height is
+// kept in the snapshot to restore the app from it.
+func (app *BaseApp) CreateSnapshot(dir string, height int64) (store.Snapshot, error) {
+	snapshotter, ok := app.cms.(store.Snapshotter)
+	if !ok {
+		return store.Snapshot{}, errors.New("the multistore can't be snapshotted")
+	}
+	if height == 0 {
+		height = app.LastBlockHeight()
+	}
+	if height <= 0 || height > app.LastBlockHeight() {
+		return store.Snapshot{}, fmt.Errorf("height %d isn't committed", height)
+	}
+	headerBytes := app.db.Get(dbHeaderKey(height))
+	if len(headerBytes) == 0 {
+		return store.Snapshot{}, fmt.Errorf("missing header of height %d", height)
+	}
+	return snapshotter.CreateSnapshot(dir, height, store.DefaultSnapshotChunkSize, headerBytes)
+}
+
+// RestoreSnapshot restores the stores from the snapshot of height in dir
+// and resumes the app at that height. The app must not have committed yet.
+// NOTE: only the state of the app is restored, not the blocks or the
+// state of Tendermint.
+func (app *BaseApp) RestoreSnapshot(dir string, height int64) (store.Snapshot, error) {
+	snapshotter, ok := app.cms.(store.Snapshotter)
+	if !ok {
+		return store.Snapshot{}, errors.New("the multistore can't be snapshotted")
+	}
+	snapshot, err := snapshotter.RestoreSnapshot(dir, height)
+	if err != nil {
+		return snapshot, err
+	}
+	var header abci.Header
+	err = proto.Unmarshal(snapshot.Metadata, &header)
+	if err != nil {
+		return snapshot, errors.Wrap(err, "Failed to parse Header of the snapshot")
+	}
+	if header.Height != height {
+		return snapshot, fmt.Errorf("snapshot of height %d has the header of height %d", height, header.Height)
+	}
+	app.db.SetSync(dbHeaderKey(height), snapshot.Metadata)
+	app.setCheckState(header)
+
+	// the snapshot doesn't hold the validator set
+	app.validators = nil
+	return snapshot, nil
+}
+
+// startSnapshot snapshots the stores at the height just committed in the
+// background, as the committed version doesn't change, so that Commit
+// doesn't wait for it. The snapshot is skipped if the previous one still
+// runs, and it fails if the height is pruned before it completes.
+// Failures are logged: the chain goes on without the snapshot.
+func (app *BaseApp) startSnapshot(height int64, headerBytes []byte) {
+	snapshotter, ok := app.cms.(store.Snapshotter)
+	if !ok {
+		app.Logger.Error("Snapshot failed, the multistore can't be snapshotted", "height", height)
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&app.snapshotRunning, 0, 1) {
+		app.Logger.Info("Snapshot skipped, the previous one is still running", "height", height)
+		return
+	}
+	app.snapshotDone.Add(1)
+	go func() {
+		defer app.snapshotDone.Done()
+		defer atomic.StoreInt32(&app.snapshotRunning, 0)
+		snapshot, err := snapshotter.CreateSnapshot(app.snapshotDir, height, store.DefaultSnapshotChunkSize, headerBytes)
+		if err != nil {
+			app.Logger.Error("Snapshot failed", "height", height, "err", err)
+			return
+		}
+		app.Logger.Info("Snapshot created", "height", height, "chunks", len(snapshot.ChunkHashes))
+	}()
+}
+
+// waitSnapshot waits for the snapshot running in the background, if any.
+func (app *BaseApp) waitSnapshot() {
+	app.snapshotDone.Wait()
+}
@@ -15,6 +15,10 @@ func NewCoreContextFromViper() core.CoreContext {
 	if nodeURI != "" {
 		rpc = rpcclient.NewHTTP(nodeURI, "/websocket")
 	}
+	gas := viper.GetInt64(client.FlagGas)
+	if gas == 0 {
+		gas = client.DefaultGasLimit
+	}
 	return core.CoreContext{
 		ChainID:         viper.GetString(client.FlagChainID),
 		Height:          viper.GetInt64(client.FlagHeight),
@@ -22,6 +26,7 @@ func NewCoreContextFromViper() core.CoreContext {
 		FromAddressName: viper.GetString(client.FlagName),
 		NodeURI:         nodeURI,
 		Sequence:        viper.GetInt64(client.FlagSequence),
+		Gas:             gas,
 		Client:          rpc,
 	}
 }
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"github.com/tendermint/tendermint/lite"
 	rpcclient "github.com/tendermint/tendermint/rpc/client"
 )
 
@@ -11,7 +12,9 @@ type CoreContext struct {
 	NodeURI         string
 	FromAddressName string
 	Sequence        int64
+	Gas             int64
 	Client          rpcclient.Client
+	Certifier       lite.Certifier
 }
 
 func (c CoreContext) WithChainID(chainID string) CoreContext {
@@ -44,7 +47,17 @@ func (c CoreContext) WithSequence(sequence int64) CoreContext {
 	return c
 }
 
+func (c CoreContext) WithGas(gas int64) CoreContext {
+	c.Gas = gas
+	return c
+}
+
 func (c CoreContext) WithClient(client rpcclient.Client) CoreContext {
 	c.Client = client
 	return c
 }
+
+func (c CoreContext) WithCertifier(certifier lite.Certifier) CoreContext {
+	c.Certifier = certifier
+	return c
+}
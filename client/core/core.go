@@ -1,6 +1,7 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/pkg/errors"
@@ -10,8 +11,10 @@ import (
 	ctypes "github.com/tendermint/tendermint/rpc/core/types"
 	cmn "github.com/tendermint/tmlibs/common"
 
+	"github.com/cosmos/cosmos-sdk/baseapp"
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/keys"
+	"github.com/cosmos/cosmos-sdk/store"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
@@ -43,8 +46,86 @@ func (ctx CoreContext) BroadcastTx(tx []byte) (*ctypes.ResultBroadcastTxCommit,
 
 // Query from Tendermint with the provided key and storename
 func (ctx CoreContext) Query(key cmn.HexBytes, storeName string) (res []byte, err error) {
+	path := fmt.Sprintf("/store/%s/key", storeName)
+	return ctx.query(path, key)
+}
+
+// QueryRange returns a page of the pairs of a store with keys in a
+// range, see store.RangeQuery. The following pages start at the
+// NextKey of the result.
+func (ctx CoreContext) QueryRange(storeName string, query store.RangeQuery) (result store.RangeResult, err error) {
+	path := fmt.Sprintf("/store/%s/range", storeName)
+	return ctx.queryRange(path, query)
+}
+
+// QuerySubspace returns a page of the pairs of a store with keys with
+// a prefix, see store.SubspaceQuery.
+func (ctx CoreContext) QuerySubspace(storeName string, query store.SubspaceQuery) (result store.RangeResult, err error) {
+	path := fmt.Sprintf("/store/%s/subspace", storeName)
+	return ctx.queryRange(path, query)
+}
+
+func (ctx CoreContext) queryRange(path string, query interface{}) (result store.RangeResult, err error) {
+	cdc := wire.NewCodec()
+	data, err := cdc.MarshalBinary(query)
+	if err != nil {
+		return result, err
+	}
+	res, err := ctx.query(path, data)
+	if err != nil {
+		return result, err
+	}
+	err = cdc.UnmarshalBinary(res, &result)
+	return result, err
+}
+
+// QueryCustom calls the querier registered by the application for
+// the given route, eg. QueryCustom("bank/supply", nil) queries
+// the path "/custom/bank/supply".
+// The responses of queriers come without proofs, so the node is
+// trusted for them whatever the TrustNode of the context.
+func (ctx CoreContext) QueryCustom(route string, data []byte) (res []byte, err error) {
+	path := fmt.Sprintf("/custom/%s", route)
+	return ctx.WithTrustNode(true).query(path, data)
+}
+
+// QueryRoutes returns the routes of the Msgs and custom queries of the
+// application. Like those of custom queries, the response comes without
+// proofs, so the node is trusted for it.
+func (ctx CoreContext) QueryRoutes() (routes baseapp.AppRoutes, err error) {
+	res, err := ctx.WithTrustNode(true).query("/app/routes", nil)
+	if err != nil {
+		return routes, err
+	}
+	err = json.Unmarshal(res, &routes)
+	return routes, err
+}
 
-	path := fmt.Sprintf("/%s/key", storeName)
+// SetOption changes a node-local option of the application, eg. its log level.
+// Tendermint doesn't forward SetOption over RPC, so it is sent as an
+// "/app/setoption/<key>" query, which the node only accepts when started
+// with --unsafe_set_option_queries. Returns the log of the application on success.
+func (ctx CoreContext) SetOption(key, value string) (string, error) {
+	node, err := ctx.GetNode()
+	if err != nil {
+		return "", err
+	}
+
+	path := fmt.Sprintf("/app/setoption/%s", key)
+	opts := rpcclient.ABCIQueryOptions{Trusted: true}
+	result, err := node.ABCIQueryWithOptions(path, []byte(value), opts)
+	if err != nil {
+		return "", err
+	}
+	resp := result.Response
+	if resp.Code != uint32(0) {
+		return "", errors.Errorf("SetOption failed: (%d) %s", resp.Code, resp.Log)
+	}
+	return resp.Log, nil
+}
+
+// query an ABCI path with the height and trust settings of the context
+func (ctx CoreContext) query(path string, data cmn.HexBytes) (res []byte, err error) {
 	node, err := ctx.GetNode()
 	if err != nil {
 		return res, err
@@ -54,7 +135,7 @@ func (ctx CoreContext) Query(key cmn.HexBytes, storeName string) (res []byte, er
 		Height:  ctx.Height,
 		Trusted: ctx.TrustNode,
 	}
-	result, err := node.ABCIQueryWithOptions(path, key, opts)
+	result, err := node.ABCIQueryWithOptions(path, data, opts)
 	if err != nil {
 		return res, err
 	}
@@ -62,6 +143,14 @@ func (ctx CoreContext) Query(key cmn.HexBytes, storeName string) (res []byte, er
 	if resp.Code != uint32(0) {
 		return res, errors.Errorf("Query failed: (%d) %s", resp.Code, resp.Log)
 	}
+
+	// the node can't be trusted, check its response
+	if !ctx.TrustNode {
+		err = ctx.verifyProof(path, data, resp)
+		if err != nil {
+			return res, err
+		}
+	}
 	return resp.Value, nil
 }
 
@@ -95,7 +184,8 @@ func (ctx CoreContext) SignAndBuild(name, passphrase string, msg sdk.Msg, cdc *w
 	signMsg := sdk.StdSignMsg{
 		ChainID:   chainID,
 		Sequences: []int64{sequence},
-		Msg:       msg,
+		Fee:       sdk.NewStdFee(ctx.Gas),
+		Msgs:      []sdk.Msg{msg},
 	}
 
 	keybase, err := keys.GetKeyBase()
@@ -117,11 +207,23 @@ func (ctx CoreContext) SignAndBuild(name, passphrase string, msg sdk.Msg, cdc *w
 	}}
 
 	// marshal bytes
-	tx := sdk.NewStdTx(signMsg.Msg, signMsg.Fee, sigs)
+	tx := sdk.NewStdTx(signMsg.Msgs, signMsg.Fee, sigs)
 
 	return cdc.MarshalBinary(tx)
 }
 
+// BuildUnsigned builds the transaction of msg for its signers to sign
+// offline, eg. when they are the members of a multisig account.
+// The signatures are empty but for their sequences, ctx.Sequence.
+func (ctx CoreContext) BuildUnsigned(msg sdk.Msg, cdc *wire.Codec) ([]byte, error) {
+	sigs := make([]sdk.StdSignature, len(msg.GetSigners()))
+	for i := range sigs {
+		sigs[i].Sequence = ctx.Sequence
+	}
+	tx := sdk.NewStdTx([]sdk.Msg{msg}, sdk.NewStdFee(ctx.Gas), sigs)
+	return cdc.MarshalBinary(tx)
+}
+
 // sign and build the transaction from the msg
 func (ctx CoreContext) SignBuildBroadcast(name string, msg sdk.Msg, cdc *wire.Codec) (*ctypes.ResultBroadcastTxCommit, error) {
 	passphrase, err := ctx.GetPassphraseFromStdin(name)
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	rpcclient "github.com/tendermint/tendermint/rpc/client"
+	"github.com/tendermint/tendermint/rpc/client/mock"
+	ctypes "github.com/tendermint/tendermint/rpc/core/types"
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+// queryApp answers every query with its path, without proof
+type queryApp struct {
+	abci.BaseApplication
+}
+
+func (queryApp) Query(req abci.RequestQuery) abci.ResponseQuery {
+	return abci.ResponseQuery{Value: []byte(req.Path)}
+}
+
+// queryClient sends the queries to queryApp
+type queryClient struct {
+	mock.Client
+}
+
+func (queryClient) ABCIQueryWithOptions(path string, data cmn.HexBytes, opts rpcclient.ABCIQueryOptions) (*ctypes.ResultABCIQuery, error) {
+	return mock.ABCIApp{App: queryApp{}}.ABCIQueryWithOptions(path, data, opts)
+}
+
+func TestQueryCustomUntrusted(t *testing.T) {
+	// the context of the default flags doesn't trust the node
+	ctx := CoreContext{ChainID: "test", NodeURI: "tcp://localhost:46657", Client: queryClient{}}
+
+	// custom queries can't be proven, so they trust the node
+	res, err := ctx.QueryCustom("bank/supply", nil)
+	require.Nil(t, err)
+	assert.Equal(t, "/custom/bank/supply", string(res))
+
+	// store queries need a proof
+	_, err = ctx.Query([]byte("key"), "main")
+	assert.NotNil(t, err)
+}
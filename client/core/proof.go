@@ -0,0 +1,127 @@
+package core
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	abci "github.com/tendermint/abci/types"
+	"github.com/tendermint/tendermint/lite"
+	tmliteProxy "github.com/tendermint/tendermint/lite/proxy"
+	"github.com/tendermint/tmlibs/cli"
+
+	"github.com/cosmos/cosmos-sdk/store"
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+// GetCertifier returns the certifier of the context. If it has none,
+// it returns a certifier which starts from the latest commit of the node
+// and keeps the validator sets it learns in the "lite" dir of the home dir.
+func (ctx CoreContext) GetCertifier() (lite.Certifier, error) {
+	if ctx.Certifier != nil {
+		return ctx.Certifier, nil
+	}
+	if ctx.ChainID == "" {
+		return nil, errors.New("Must define chain ID to verify proofs")
+	}
+	if ctx.NodeURI == "" {
+		return nil, errors.New("Must define node URI to verify proofs")
+	}
+	dir := filepath.Join(viper.GetString(cli.HomeFlag), "lite")
+	return tmliteProxy.GetCertifier(ctx.ChainID, dir, ctx.NodeURI)
+}
+
+// verifyProof checks the proof of the response to a store query,
+// "/store/<storeName>/key", "/store/<storeName>/range" or
+// "/store/<storeName>/subspace", against the AppHash of the certified
+// header which commits to the state at the height of the response.
+// Other queries can't be verified.
+func (ctx CoreContext) verifyProof(path string, data []byte, resp abci.ResponseQuery) error {
+	storeName, subpath, ok := parseStorePath(path)
+	if !ok {
+		return errors.Errorf("Can't verify the response to %s, the node must be trusted with --trust-node", path)
+	}
+	if len(resp.Proof) == 0 {
+		return errors.New("Node returned no proof")
+	}
+	if subpath == "key" && !bytes.Equal(resp.Key, data) {
+		return errors.Errorf("Node returned a proof of key %X instead of %X", resp.Key, data)
+	}
+
+	node, err := ctx.GetNode()
+	if err != nil {
+		return err
+	}
+	cert, err := ctx.GetCertifier()
+	if err != nil {
+		return err
+	}
+
+	// the AppHash of the state at height H is in the header of block H+1
+	commit, err := tmliteProxy.GetCertifiedCommit(resp.Height+1, node, cert)
+	if err != nil {
+		return err
+	}
+	appHash := commit.Header.AppHash
+
+	switch subpath {
+	case "key":
+		var value []byte
+		if len(resp.Value) > 0 {
+			value = resp.Value
+		}
+		err = store.VerifyMultiStoreProof(resp.Proof, storeName, data, value, appHash)
+	default:
+		err = verifyRangeProof(subpath, storeName, data, resp, appHash)
+	}
+	if err != nil {
+		return errors.Wrap(err, "Couldn't verify proof")
+	}
+	return nil
+}
+
+// verifyRangeProof checks the proof of the response to the range or
+// subspace query of data.
+func verifyRangeProof(subpath, storeName string, data []byte, resp abci.ResponseQuery, appHash []byte) error {
+	cdc := wire.NewCodec()
+	var query store.RangeQuery
+	if subpath == "range" {
+		err := cdc.UnmarshalBinary(data, &query)
+		if err != nil {
+			return err
+		}
+	} else {
+		var subspace store.SubspaceQuery
+		err := cdc.UnmarshalBinary(data, &subspace)
+		if err != nil {
+			return err
+		}
+		query, err = subspace.Range()
+		if err != nil {
+			return err
+		}
+	}
+	var result store.RangeResult
+	err := cdc.UnmarshalBinary(resp.Value, &result)
+	if err != nil {
+		return err
+	}
+	return store.VerifyMultiStoreRangeProof(resp.Proof, storeName, query, result, appHash)
+}
+
+// parseStorePath returns the store name and the query of a
+// "/store/<storeName>/<key|range|subspace>" path
+func parseStorePath(path string) (storeName, subpath string, ok bool) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 4 || parts[0] != "" || parts[1] != "store" || parts[2] == "" {
+		return "", "", false
+	}
+	switch parts[3] {
+	case "key", "range", "subspace":
+		return parts[2], parts[3], true
+	}
+	return "", "", false
+}
@@ -0,0 +1,39 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	abci "github.com/tendermint/abci/types"
+)
+
+func TestParseStorePath(t *testing.T) {
+	cases := []struct {
+		path      string
+		storeName string
+		subpath   string
+		ok        bool
+	}{
+		{"/store/main/key", "main", "key", true},
+		{"/store/stake/key", "stake", "key", true},
+		{"/store/main/subspace", "main", "subspace", true},
+		{"/store/main/range", "main", "range", true},
+		{"/store/main/other", "", "", false},
+		{"/store//key", "", "", false},
+		{"/custom/stake/candidates", "", "", false},
+		{"store/main/key", "", "", false},
+		{"/store/main/key/extra", "", "", false},
+	}
+	for _, tc := range cases {
+		storeName, subpath, ok := parseStorePath(tc.path)
+		assert.Equal(t, tc.ok, ok, tc.path)
+		assert.Equal(t, tc.storeName, storeName, tc.path)
+		assert.Equal(t, tc.subpath, subpath, tc.path)
+	}
+}
+
+func TestVerifyProofUnprovablePath(t *testing.T) {
+	ctx := CoreContext{ChainID: "test", NodeURI: "tcp://localhost:46657"}
+	err := ctx.verifyProof("/custom/stake/candidates", nil, abci.ResponseQuery{})
+	assert.NotNil(t, err)
+}
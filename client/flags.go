@@ -11,8 +11,13 @@ const (
 	FlagName      = "name"
 	FlagSequence  = "sequence"
 	FlagFee       = "fee"
+	FlagGas       = "gas"
 )
 
+// DefaultGasLimit is the gas limit used to sign transactions
+// when none is given on the command line.
+const DefaultGasLimit = 200000
+
 // LineBreak can be included in a command list to provide a blank line
 // to help with readability
 var LineBreak = &cobra.Command{Run: func(*cobra.Command, []string) {}}
@@ -20,11 +25,10 @@ var LineBreak = &cobra.Command{Run: func(*cobra.Command, []string) {}}
 // GetCommands adds common flags to query commands
 func GetCommands(cmds ...*cobra.Command) []*cobra.Command {
 	for _, c := range cmds {
-		// TODO: make this default false when we support proofs
-		c.Flags().Bool(FlagTrustNode, true, "Don't verify proofs for responses")
+		c.Flags().Bool(FlagTrustNode, false, "Don't verify proofs for responses")
 		c.Flags().String(FlagChainID, "", "Chain ID of tendermint node")
 		c.Flags().String(FlagNode, "tcp://localhost:46657", "<host>:<port> to tendermint rpc interface for this chain")
-		c.Flags().Int64(FlagHeight, 0, "block height to query, omit to get most recent provable block")
+		c.Flags().Int64(FlagHeight, 0, "block height to query, which must be retained by the node; omit to get most recent provable block")
 	}
 	return cmds
 }
@@ -35,6 +39,7 @@ func PostCommands(cmds ...*cobra.Command) []*cobra.Command {
 		c.Flags().String(FlagName, "", "Name of private key with which to sign")
 		c.Flags().Int64(FlagSequence, 0, "Sequence number to sign the tx")
 		c.Flags().String(FlagFee, "", "Fee to pay along with transaction")
+		c.Flags().Int64(FlagGas, DefaultGasLimit, "Gas limit to set per transaction")
 		c.Flags().String(FlagChainID, "", "Chain ID of tendermint node")
 		c.Flags().String(FlagNode, "tcp://localhost:46657", "<host>:<port> to tendermint rpc interface for this chain")
 	}
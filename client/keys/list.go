@@ -53,7 +53,7 @@ func QueryKeysRequestHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	keysOutput := make([]KeyOutput, len(infos))
 	for i, info := range infos {
-		keysOutput[i] = KeyOutput{Name: info.Name, Address: info.PubKey.Address().String()}
+		keysOutput[i] = NewKeyOutput(info)
 	}
 	output, err := json.MarshalIndent(keysOutput, "", "  ")
 	if err != nil {
@@ -25,8 +25,16 @@ var (
 type KeyOutput struct {
 	Name    string `json:"name"`
 	Address string `json:"address"`
-	// TODO add pubkey?
-	// Pubkey  string `json:"pubkey"`
+	PubKey  string `json:"pub_key"` // hex of the encoded key
+}
+
+// NewKeyOutput returns the output of info.
+func NewKeyOutput(info keys.Info) KeyOutput {
+	return KeyOutput{
+		Name:    info.Name,
+		Address: info.PubKey.Address().String(),
+		PubKey:  fmt.Sprintf("%X", info.PubKey.Bytes()),
+	}
 }
 
 // GetKeyBase initializes a keybase based on the configuration
@@ -55,7 +63,7 @@ func printInfo(info keys.Info) {
 		if len(info.Name) > 7 {
 			sep = "\t"
 		}
-		fmt.Printf("%s%s%s\n", info.Name, sep, addr)
+		fmt.Printf("%s%s%s\t%X\n", info.Name, sep, addr, info.PubKey.Bytes())
 	case "json":
 		json, err := MarshalJSON(info)
 		if err != nil {
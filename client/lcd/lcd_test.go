@@ -2,6 +2,7 @@ package lcd
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -26,9 +27,11 @@ import (
 	tmrpc "github.com/tendermint/tendermint/rpc/lib/server"
 	tmtypes "github.com/tendermint/tendermint/types"
 	"github.com/tendermint/tmlibs/cli"
+	cmn "github.com/tendermint/tmlibs/common"
 	dbm "github.com/tendermint/tmlibs/db"
 	"github.com/tendermint/tmlibs/log"
 
+	"github.com/cosmos/cosmos-sdk/baseapp"
 	client "github.com/cosmos/cosmos-sdk/client"
 	keys "github.com/cosmos/cosmos-sdk/client/keys"
 	bapp "github.com/cosmos/cosmos-sdk/examples/basecoin/app"
@@ -36,6 +39,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire"
 	"github.com/cosmos/cosmos-sdk/x/auth"
+	authcmd "github.com/cosmos/cosmos-sdk/x/auth/commands"
 )
 
 var (
@@ -214,6 +218,16 @@ func TestValidators(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, res.StatusCode)
 }
 
+func TestRoutes(t *testing.T) {
+	res, body := request(t, port, "GET", "/routes", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	var routes baseapp.AppRoutes
+	require.Nil(t, json.Unmarshal([]byte(body), &routes))
+	assert.Contains(t, routes.Msgs, "bank")
+	assert.Contains(t, routes.Msgs, "feegrant")
+	assert.Contains(t, routes.Queries, "bank")
+}
+
 func TestCoinSend(t *testing.T) {
 
 	// query empty
@@ -240,6 +254,18 @@ func TestCoinSend(t *testing.T) {
 	assert.Equal(t, coinDenom, mycoins.Denom)
 	assert.Equal(t, coinAmount-1, mycoins.Amount)
 
+	// query sender before the tx
+	res, body = request(t, port, "GET", fmt.Sprintf("/accounts/%s?height=%d", sendAddr, resultTx.Height-1), nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	err = json.Unmarshal([]byte(body), &m)
+	require.Nil(t, err)
+	assert.Equal(t, coinAmount, m.Coins[0].Amount)
+
+	res, body = request(t, port, "GET", "/accounts/"+sendAddr+"?height=abc", nil)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+	res, body = request(t, port, "GET", "/accounts/"+sendAddr+"?height=1000000000", nil)
+	require.Equal(t, http.StatusInternalServerError, res.StatusCode, body)
+
 	// query receiver
 	res, body = request(t, port, "GET", "/accounts/"+receiveAddr, nil)
 	require.Equal(t, http.StatusOK, res.StatusCode, body)
@@ -252,6 +278,70 @@ func TestCoinSend(t *testing.T) {
 	assert.Equal(t, int64(1), mycoins.Amount)
 }
 
+func TestAccountQueries(t *testing.T) {
+	// the accounts are looked up under their key in the main store
+	res, body := request(t, port, "GET", "/accounts/"+sendAddr, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	var m auth.BaseAccount
+	require.Nil(t, json.Unmarshal([]byte(body), &m))
+	assert.Equal(t, sendAddr, m.Address.String())
+	assert.Equal(t, coinDenom, m.Coins[0].Denom)
+
+	// so is the account of basecli
+	cdc := bapp.MakeCodec()
+	cmd := authcmd.GetAccountCmd("main", cdc, btypes.GetAccountDecoder(cdc))
+	output := captureStdout(t, func() {
+		require.Nil(t, cmd.RunE(cmd, []string{sendAddr}))
+	})
+	var acc btypes.AppAccount
+	require.Nil(t, json.Unmarshal([]byte(output), &acc), output)
+	assert.Equal(t, sendAddr, acc.Address.String())
+	assert.Equal(t, "tester", acc.Name)
+	assert.Equal(t, m.Coins, acc.Coins)
+}
+
+func TestStoreSubspace(t *testing.T) {
+	type rangeOutput struct {
+		Pairs []struct {
+			Key   cmn.HexBytes `json:"key"`
+			Value cmn.HexBytes `json:"value"`
+		} `json:"pairs"`
+		NextKey cmn.HexBytes `json:"next_key"`
+	}
+
+	// the accounts are under their prefix in the main store
+	addr, err := hex.DecodeString(sendAddr)
+	require.Nil(t, err)
+	prefix := hex.EncodeToString([]byte("account:"))
+	res, body := request(t, port, "GET", "/stores/main/subspace/"+prefix, nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	var output rangeOutput
+	require.Nil(t, json.Unmarshal([]byte(body), &output))
+	require.NotEmpty(t, output.Pairs)
+	assert.Empty(t, output.NextKey)
+	found := false
+	for _, pair := range output.Pairs {
+		found = found || bytes.Equal(auth.AddressStoreKey(addr), pair.Key)
+	}
+	assert.True(t, found, "no account %s in %s", sendAddr, body)
+
+	// in pages
+	res, body = request(t, port, "GET", "/stores/main/subspace/"+prefix+"?limit=1", nil)
+	require.Equal(t, http.StatusOK, res.StatusCode, body)
+	var page rangeOutput
+	require.Nil(t, json.Unmarshal([]byte(body), &page))
+	require.Len(t, page.Pairs, 1)
+	assert.Equal(t, output.Pairs[0].Key, page.Pairs[0].Key)
+	if len(output.Pairs) > 1 {
+		assert.Equal(t, output.Pairs[1].Key, page.NextKey)
+	}
+
+	res, body = request(t, port, "GET", "/stores/main/range?start="+prefix+"&limit=abc", nil)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+	res, body = request(t, port, "GET", "/stores/main/subspace/xyz", nil)
+	require.Equal(t, http.StatusBadRequest, res.StatusCode, body)
+}
+
 func TestIBCTransfer(t *testing.T) {
 
 	// create TX
@@ -343,10 +433,12 @@ func startTMAndLCD() (*nm.Node, net.Listener, error) {
 	privValidatorFile := config.PrivValidatorFile()
 	privVal := tmtypes.LoadOrGenPrivValidatorFS(privValidatorFile)
 	dbs := map[string]dbm.DB{
-		"main":    dbm.NewMemDB(),
-		"acc":     dbm.NewMemDB(),
-		"ibc":     dbm.NewMemDB(),
-		"staking": dbm.NewMemDB(),
+		"main":     dbm.NewMemDB(),
+		"acc":      dbm.NewMemDB(),
+		"ibc":      dbm.NewMemDB(),
+		"staking":  dbm.NewMemDB(),
+		"feegrant": dbm.NewMemDB(),
+		"authz":    dbm.NewMemDB(),
 	}
 	app := bapp.NewBasecoinApp(logger, dbs)
 
@@ -447,6 +539,20 @@ func request(t *testing.T, port, method, path string, payload []byte) (*http.Res
 	return res, string(output)
 }
 
+// captureStdout returns what fn prints, eg. a command.
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	require.Nil(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	output, err := ioutil.ReadAll(r)
+	require.Nil(t, err)
+	return string(output)
+}
+
 func doSend(t *testing.T, port, seed string) (receiveAddr string, resultTx ctypes.ResultBroadcastTxCommit) {
 
 	// create receive address
@@ -0,0 +1,39 @@
+package rpc
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/context"
+)
+
+func setOptionCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set-option <key> <value>",
+		Short: "Change a node-local option of the application, eg. log_level",
+		Long: `Change a node-local option of the application, eg. log_level.
+The node must be started with --unsafe_set_option_queries.`,
+		RunE: setOption,
+	}
+	cmd.Flags().StringP(client.FlagNode, "n", "tcp://localhost:46657", "Node to connect to")
+	return cmd
+}
+
+// CMD
+
+func setOption(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return errors.New("You must provide an option key and value")
+	}
+
+	log, err := context.NewCoreContextFromViper().SetOption(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(log)
+	return nil
+}
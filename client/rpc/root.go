@@ -27,6 +27,8 @@ func AddCommands(cmd *cobra.Command) {
 		statusCommand(),
 		blockCommand(),
 		validatorCommand(),
+		setOptionCommand(),
+		routesCommand(),
 	)
 }
 
@@ -46,9 +48,12 @@ func initClientCommand() *cobra.Command {
 
 func RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/node_info", NodeInfoRequestHandler).Methods("GET")
+	r.HandleFunc("/routes", RoutesRequestHandler).Methods("GET")
 	r.HandleFunc("/syncing", NodeSyncingRequestHandler).Methods("GET")
 	r.HandleFunc("/blocks/latest", LatestBlockRequestHandler).Methods("GET")
 	r.HandleFunc("/blocks/{height}", BlockRequestHandler).Methods("GET")
 	r.HandleFunc("/validatorsets/latest", LatestValidatorsetRequestHandler).Methods("GET")
 	r.HandleFunc("/validatorsets/{height}", ValidatorsetRequestHandler).Methods("GET")
+	r.HandleFunc("/stores/{storeName}/range", StoreRangeRequestHandler).Methods("GET")
+	r.HandleFunc("/stores/{storeName}/subspace/{prefix}", StoreSubspaceRequestHandler).Methods("GET")
 }
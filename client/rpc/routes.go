@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/context"
+)
+
+func routesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "routes",
+		Short: "List the routes of the Msgs and custom queries of the application",
+		RunE:  printRoutes,
+	}
+	cmd.Flags().StringP(client.FlagNode, "n", "tcp://localhost:46657", "Node to connect to")
+	return cmd
+}
+
+// CMD
+
+func printRoutes(cmd *cobra.Command, args []string) error {
+	routes, err := context.NewCoreContextFromViper().QueryRoutes()
+	if err != nil {
+		return err
+	}
+
+	output, err := json.MarshalIndent(routes, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
+
+// REST
+
+// RoutesRequestHandler lists the routes of the Msgs and custom queries
+// of the application
+func RoutesRequestHandler(w http.ResponseWriter, r *http.Request) {
+	routes, err := context.NewCoreContextFromViper().QueryRoutes()
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	output, err := json.MarshalIndent(routes, "", "  ")
+	if err != nil {
+		w.WriteHeader(500)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Write(output)
+}
@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/client/core"
+	"github.com/cosmos/cosmos-sdk/store"
+)
+
+// REST
+
+// rangeOutput is the JSON of a store.RangeResult, with hex keys and values
+type rangeOutput struct {
+	Pairs   []pairOutput `json:"pairs"`
+	NextKey cmn.HexBytes `json:"next_key,omitempty"`
+}
+
+type pairOutput struct {
+	Key   cmn.HexBytes `json:"key"`
+	Value cmn.HexBytes `json:"value"`
+}
+
+// StoreRangeRequestHandler lists the pairs of a store with keys in a
+// range, eg. /stores/main/range?start=<hex>&end=<hex>&limit=<n>&height=<h>.
+// All the parameters are optional: the range is the whole store by default.
+func StoreRangeRequestHandler(w http.ResponseWriter, r *http.Request) {
+	storeName := mux.Vars(r)["storeName"]
+	var query store.RangeQuery
+	var err error
+	query.Start, query.End, query.Limit, err = parseRangeParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	ctx, err := storeQueryContext(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	result, err := ctx.QueryRange(storeName, query)
+	writeRangeResult(w, result, err)
+}
+
+// StoreSubspaceRequestHandler lists the pairs of a store with keys with
+// a prefix, eg. /stores/main/subspace/<hex prefix>?start=<hex>&limit=<n>,
+// from the start if set.
+func StoreSubspaceRequestHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	storeName := vars["storeName"]
+	var query store.SubspaceQuery
+	var err error
+	query.Prefix, err = hex.DecodeString(vars["prefix"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(fmt.Sprintf("Invalid prefix %s", vars["prefix"])))
+		return
+	}
+	query.Start, _, query.Limit, err = parseRangeParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	ctx, err := storeQueryContext(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	result, err := ctx.QuerySubspace(storeName, query)
+	writeRangeResult(w, result, err)
+}
+
+// parseRangeParams parses the start, end and limit URL parameters
+func parseRangeParams(r *http.Request) (start, end []byte, limit int, err error) {
+	params := r.URL.Query()
+	start, err = hex.DecodeString(params.Get("start"))
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("Invalid start %s", params.Get("start"))
+	}
+	end, err = hex.DecodeString(params.Get("end"))
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("Invalid end %s", params.Get("end"))
+	}
+	if l := params.Get("limit"); l != "" {
+		limit, err = strconv.Atoi(l)
+		if err != nil || limit < 0 {
+			return nil, nil, 0, fmt.Errorf("Invalid limit %s", l)
+		}
+	}
+	return start, end, limit, nil
+}
+
+// storeQueryContext returns the context of a query of the latest state,
+// or of the state at the height URL parameter
+func storeQueryContext(r *http.Request) (core.CoreContext, error) {
+	ctx := context.NewCoreContextFromViper()
+	if height := r.URL.Query().Get("height"); height != "" {
+		h, err := strconv.ParseInt(height, 10, 64)
+		if err != nil || h < 0 {
+			return ctx, fmt.Errorf("Invalid height %s", height)
+		}
+		ctx = ctx.WithHeight(h)
+	}
+	return ctx, nil
+}
+
+func writeRangeResult(w http.ResponseWriter, result store.RangeResult, err error) {
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(fmt.Sprintf("Couldn't query the store. Error: %s", err.Error())))
+		return
+	}
+	output := rangeOutput{
+		Pairs:   make([]pairOutput, len(result.Pairs)),
+		NextKey: result.NextKey,
+	}
+	for i, pair := range result.Pairs {
+		output.Pairs[i] = pairOutput{pair.Key, pair.Value}
+	}
+	bz, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Write(bz)
+}
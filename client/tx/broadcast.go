@@ -1,12 +1,60 @@
 package tx
 
 import (
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"strings"
 
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/context"
 )
 
+// BroadcastTxCmd broadcasts a signed tx from a file of its hex bytes,
+// eg. a tx signed offline with the multisign command
+func BroadcastTxCmd(cmdr commander) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "broadcast <tx file>",
+		Short: "Broadcast a signed tx from a file",
+		RunE:  cmdr.broadcastTxCmd,
+	}
+	cmd.Flags().StringP(client.FlagNode, "n", "tcp://localhost:46657", "Node to connect to")
+	return cmd
+}
+
+func (c commander) broadcastTxCmd(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 || len(args[0]) == 0 {
+		return errors.New("You must provide a tx file")
+	}
+	txBytes, err := ReadHexFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	res, err := context.NewCoreContextFromViper().BroadcastTx(txBytes)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Committed at block %d. Hash: %s\n", res.Height, res.Hash.String())
+	return nil
+}
+
+// ReadHexFile returns the bytes of a file of hex, such as an unsigned
+// tx printed with --generate-only.
+func ReadHexFile(path string) ([]byte, error) {
+	bz, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(string(bz)))
+}
+
 type BroadcastTxBody struct {
 	TxBytes string `json="tx"`
 }
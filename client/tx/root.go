@@ -18,6 +18,7 @@ func AddCommands(cmd *cobra.Command, cdc *wire.Codec) {
 	cmd.AddCommand(
 		SearchTxCmd(cmdr),
 		QueryTxCmd(cmdr),
+		BroadcastTxCmd(cmdr),
 	)
 }
 
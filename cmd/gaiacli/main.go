@@ -59,6 +59,20 @@ func main() {
 		client.PostCommands(
 			bankcmd.SendTxCmd(cdc),
 		)...)
+	queryCmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query the state of the app",
+	}
+	queryCmd.AddCommand(
+		client.GetCommands(
+			bankcmd.SupplyCmd(cdc),
+		)...)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(
+		authcmd.MultiSigAddressCmd(),
+		authcmd.SignTxCmd(cdc),
+		authcmd.MultiSignTxCmd(cdc),
+	)
 	rootCmd.AddCommand(
 		client.PostCommands(
 			ibccmd.IBCTransferCmd(cdc),
@@ -3,7 +3,6 @@ package app
 import (
 	"encoding/json"
 
-	abci "github.com/tendermint/abci/types"
 	oldwire "github.com/tendermint/go-wire"
 	cmn "github.com/tendermint/tmlibs/common"
 	dbm "github.com/tendermint/tmlibs/db"
@@ -13,7 +12,9 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire"
 	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/authz"
 	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/cosmos/cosmos-sdk/x/feegrant"
 	"github.com/cosmos/cosmos-sdk/x/ibc"
 	"github.com/cosmos/cosmos-sdk/x/simplestake"
 
@@ -30,24 +31,31 @@ type BasecoinApp struct {
 	cdc *wire.Codec
 
 	// keys to access the substores
-	capKeyMainStore    *sdk.KVStoreKey
-	capKeyAccountStore *sdk.KVStoreKey
-	capKeyIBCStore     *sdk.KVStoreKey
-	capKeyStakingStore *sdk.KVStoreKey
+	capKeyMainStore     *sdk.KVStoreKey
+	capKeyAccountStore  *sdk.KVStoreKey
+	capKeyIBCStore      *sdk.KVStoreKey
+	capKeyStakingStore  *sdk.KVStoreKey
+	capKeyFeeGrantStore *sdk.KVStoreKey
+	capKeyAuthzStore    *sdk.KVStoreKey
 
 	// Manage getting and setting accounts
 	accountMapper sdk.AccountMapper
+
+	// Collect the fees paid by txs
+	feeCollectionKeeper auth.FeeCollectionKeeper
 }
 
-func NewBasecoinApp(logger log.Logger, dbs map[string]dbm.DB) *BasecoinApp {
+func NewBasecoinApp(logger log.Logger, dbs map[string]dbm.DB, baseAppOptions ...func(*bam.BaseApp)) *BasecoinApp {
 	// create your application object
 	var app = &BasecoinApp{
-		BaseApp:            bam.NewBaseApp(appName, logger, dbs["main"]),
-		cdc:                MakeCodec(),
-		capKeyMainStore:    sdk.NewKVStoreKey("main"),
-		capKeyAccountStore: sdk.NewKVStoreKey("acc"),
-		capKeyIBCStore:     sdk.NewKVStoreKey("ibc"),
-		capKeyStakingStore: sdk.NewKVStoreKey("stake"),
+		BaseApp:             bam.NewBaseApp(appName, logger, dbs["main"], baseAppOptions...),
+		cdc:                 MakeCodec(),
+		capKeyMainStore:     sdk.NewKVStoreKey("main"),
+		capKeyAccountStore:  sdk.NewKVStoreKey("acc"),
+		capKeyIBCStore:      sdk.NewKVStoreKey("ibc"),
+		capKeyStakingStore:  sdk.NewKVStoreKey("stake"),
+		capKeyFeeGrantStore: sdk.NewKVStoreKey("feegrant"),
+		capKeyAuthzStore:    sdk.NewKVStoreKey("authz"),
 	}
 
 	// define the accountMapper
@@ -55,26 +63,51 @@ func NewBasecoinApp(logger log.Logger, dbs map[string]dbm.DB) *BasecoinApp {
 		app.capKeyMainStore, // target store
 		&types.AppAccount{}, // prototype
 	)
+	// NOTE: only x/stake pays out the fee pool, and the app doesn't run it,
+	// so the fees accumulate in the pool
+	app.feeCollectionKeeper = auth.NewFeeCollectionKeeper(app.cdc, app.capKeyMainStore)
 
 	// add handlers
-	coinKeeper := bank.NewCoinKeeper(app.accountMapper)
+	coinKeeper := bank.NewCoinKeeper(app.accountMapper, app.capKeyMainStore)
 	ibcMapper := ibc.NewIBCMapper(app.cdc, app.capKeyIBCStore)
 	stakeKeeper := simplestake.NewKeeper(app.capKeyStakingStore, coinKeeper)
+	feeGrantKeeper := feegrant.NewKeeper(app.cdc, app.capKeyFeeGrantStore)
+	authzKeeper := authz.NewKeeper(app.cdc, app.capKeyAuthzStore)
 	app.Router().
 		AddRoute("bank", bank.NewHandler(coinKeeper)).
 		AddRoute("ibc", ibc.NewHandler(ibcMapper, coinKeeper)).
-		AddRoute("simplestake", simplestake.NewHandler(stakeKeeper))
+		AddRoute("simplestake", simplestake.NewHandler(stakeKeeper)).
+		AddRoute("feegrant", feegrant.NewHandler(feeGrantKeeper)).
+		AddRoute("authz", authz.NewHandler(authzKeeper, app.Router()))
+	app.QueryRouter().
+		AddRoute("bank", bank.NewQuerier(coinKeeper))
 
 	// initialize BaseApp
 	app.SetTxDecoder(app.txDecoder)
-	app.SetInitChainer(app.initChainer)
+	app.SetBeginBlocker(feegrant.NewBeginBlocker(feeGrantKeeper))
+	app.RegisterInitGenesis("accounts", app.initAccounts)
+	app.RegisterInitGenesis("fees", app.feeCollectionKeeper.InitGenesis)
+	app.RegisterInitGenesis("supply", bank.NewInitGenesis(coinKeeper, app.feeCollectionKeeper))
+	app.RegisterInitGenesis("ibc", ibcMapper.InitGenesis)
+	app.RegisterInitGenesis("simplestake", stakeKeeper.InitGenesis)
+	app.RegisterInitGenesis("feegrant", feeGrantKeeper.InitGenesis)
+	app.RegisterInitGenesis("authz", authzKeeper.InitGenesis)
+	app.RegisterExportGenesis("accounts", app.exportAccounts)
+	app.RegisterExportGenesis("fees", app.feeCollectionKeeper.ExportGenesis)
+	app.RegisterExportGenesis("supply", coinKeeper.ExportGenesis)
+	app.RegisterExportGenesis("ibc", ibcMapper.ExportGenesis)
+	app.RegisterExportGenesis("simplestake", stakeKeeper.ExportGenesis)
+	app.RegisterExportGenesis("feegrant", feeGrantKeeper.ExportGenesis)
+	app.RegisterExportGenesis("authz", authzKeeper.ExportGenesis)
 	app.MountStoreWithDB(app.capKeyMainStore, sdk.StoreTypeIAVL, dbs["main"])
 	app.MountStoreWithDB(app.capKeyAccountStore, sdk.StoreTypeIAVL, dbs["acc"])
 	app.MountStoreWithDB(app.capKeyIBCStore, sdk.StoreTypeIAVL, dbs["ibc"])
 	app.MountStoreWithDB(app.capKeyStakingStore, sdk.StoreTypeIAVL, dbs["staking"])
+	app.MountStoreWithDB(app.capKeyFeeGrantStore, sdk.StoreTypeIAVL, dbs["feegrant"])
+	app.MountStoreWithDB(app.capKeyAuthzStore, sdk.StoreTypeIAVL, dbs["authz"])
 	// NOTE: Broken until #532 lands
 	//app.MountStoresIAVL(app.capKeyMainStore, app.capKeyIBCStore, app.capKeyStakingStore)
-	app.SetAnteHandler(auth.NewAnteHandler(app.accountMapper))
+	app.SetAnteHandler(auth.NewAnteHandlerWithFeeGrants(app.accountMapper, app.feeCollectionKeeper, feeGrantKeeper))
 	err := app.LoadLatestVersion(app.capKeyMainStore)
 	if err != nil {
 		cmn.Exit(err.Error())
@@ -94,6 +127,11 @@ func MakeCodec() *wire.Codec {
 	const msgTypeIBCReceiveMsg = 0x6
 	const msgTypeBondMsg = 0x7
 	const msgTypeUnbondMsg = 0x8
+	const msgTypeGrantFeeAllowance = 0x9
+	const msgTypeRevokeFeeAllowance = 0xa
+	const msgTypeGrant = 0xb
+	const msgTypeRevoke = 0xc
+	const msgTypeExec = 0xd
 	var _ = oldwire.RegisterInterface(
 		struct{ sdk.Msg }{},
 		oldwire.ConcreteType{bank.SendMsg{}, msgTypeSend},
@@ -102,12 +140,21 @@ func MakeCodec() *wire.Codec {
 		oldwire.ConcreteType{ibc.IBCReceiveMsg{}, msgTypeIBCReceiveMsg},
 		oldwire.ConcreteType{simplestake.BondMsg{}, msgTypeBondMsg},
 		oldwire.ConcreteType{simplestake.UnbondMsg{}, msgTypeUnbondMsg},
+		oldwire.ConcreteType{feegrant.MsgGrantFeeAllowance{}, msgTypeGrantFeeAllowance},
+		oldwire.ConcreteType{feegrant.MsgRevokeFeeAllowance{}, msgTypeRevokeFeeAllowance},
+		oldwire.ConcreteType{authz.MsgGrant{}, msgTypeGrant},
+		oldwire.ConcreteType{authz.MsgRevoke{}, msgTypeRevoke},
+		oldwire.ConcreteType{authz.MsgExec{}, msgTypeExec},
 	)
 
 	const accTypeApp = 0x1
+	const accTypeContinuousVesting = 0x2
+	const accTypeDelayedVesting = 0x3
 	var _ = oldwire.RegisterInterface(
 		struct{ sdk.Account }{},
 		oldwire.ConcreteType{&types.AppAccount{}, accTypeApp},
+		oldwire.ConcreteType{&auth.ContinuousVestingAccount{}, accTypeContinuousVesting},
+		oldwire.ConcreteType{&auth.DelayedVestingAccount{}, accTypeDelayedVesting},
 	)
 	cdc := wire.NewCodec()
 
@@ -126,7 +173,7 @@ func (app *BasecoinApp) txDecoder(txBytes []byte) (sdk.Tx, sdk.Error) {
 		return nil, sdk.ErrTxDecode("txBytes are empty")
 	}
 
-	// StdTx.Msg is an interface. The concrete types
+	// StdTx.Msgs is a list of interfaces. The concrete types
 	// are registered by MakeTxCodec in bank.RegisterWire.
 	err := app.cdc.UnmarshalBinary(txBytes, &tx)
 	if err != nil {
@@ -135,24 +182,50 @@ func (app *BasecoinApp) txDecoder(txBytes []byte) (sdk.Tx, sdk.Error) {
 	return tx, nil
 }
 
-// custom logic for basecoin initialization
-func (app *BasecoinApp) initChainer(ctx sdk.Context, req abci.RequestInitChain) abci.ResponseInitChain {
-	stateJSON := req.AppStateBytes
-
-	genesisState := new(types.GenesisState)
-	err := json.Unmarshal(stateJSON, genesisState)
+// initialize the genesis accounts
+func (app *BasecoinApp) initAccounts(ctx sdk.Context, data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var genesisAccounts []*types.GenesisAccount
+	err := json.Unmarshal(data, &genesisAccounts)
 	if err != nil {
-		panic(err) // TODO https://github.com/cosmos/cosmos-sdk/issues/468
-		// return sdk.ErrGenesisParse("").TraceCause(err, "")
+		return err
 	}
 
-	for _, gacc := range genesisState.Accounts {
-		acc, err := gacc.ToAppAccount()
+	for _, gacc := range genesisAccounts {
+		acc, err := gacc.ToAccount()
 		if err != nil {
-			panic(err) // TODO https://github.com/cosmos/cosmos-sdk/issues/468
-			//	return sdk.ErrGenesisParse("").TraceCause(err, "")
+			return err
 		}
 		app.accountMapper.SetAccount(ctx, acc)
 	}
-	return abci.ResponseInitChain{}
+	return nil
+}
+
+// export the accounts as genesis accounts
+func (app *BasecoinApp) exportAccounts(ctx sdk.Context) json.RawMessage {
+	genesisAccounts := []*types.GenesisAccount{}
+	app.accountMapper.IterateAccounts(ctx, func(acc sdk.Account) bool {
+		genesisAccounts = append(genesisAccounts, types.NewGenesisAccountI(acc))
+		return false
+	})
+	bz, err := json.Marshal(genesisAccounts)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
+// ExportAppState loads the state committed at height,
+// or the latest state if height is 0, and exports it
+// as the app state of a genesis file.
+func (app *BasecoinApp) ExportAppState(height int64) (json.RawMessage, error) {
+	if height != 0 {
+		err := app.LoadVersion(height, app.capKeyMainStore)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return app.ExportGenesis()
 }
@@ -10,10 +10,14 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/cosmos/cosmos-sdk/examples/basecoin/types"
+	"github.com/cosmos/cosmos-sdk/server"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/authz"
 	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/cosmos/cosmos-sdk/x/feegrant"
 	"github.com/cosmos/cosmos-sdk/x/ibc"
+	"github.com/cosmos/cosmos-sdk/x/simplestake"
 
 	abci "github.com/tendermint/abci/types"
 	crypto "github.com/tendermint/go-crypto"
@@ -38,8 +42,8 @@ var (
 	halfCoins = sdk.Coins{{"foocoin", 5}}
 	manyCoins = sdk.Coins{{"foocoin", 1}, {"barcoin", 1}}
 	fee       = sdk.StdFee{
-		sdk.Coins{{"foocoin", 0}},
-		0,
+		Amount: sdk.Coins{{"foocoin", 0}},
+		Gas:    100000,
 	}
 
 	sendMsg1 = bank.SendMsg{
@@ -88,10 +92,12 @@ var (
 func loggerAndDBs() (log.Logger, map[string]dbm.DB) {
 	logger := log.NewTMLogger(log.NewSyncWriter(os.Stdout)).With("module", "sdk/app")
 	dbs := map[string]dbm.DB{
-		"main":    dbm.NewMemDB(),
-		"acc":     dbm.NewMemDB(),
-		"ibc":     dbm.NewMemDB(),
-		"staking": dbm.NewMemDB(),
+		"main":     dbm.NewMemDB(),
+		"acc":      dbm.NewMemDB(),
+		"ibc":      dbm.NewMemDB(),
+		"staking":  dbm.NewMemDB(),
+		"feegrant": dbm.NewMemDB(),
+		"authz":    dbm.NewMemDB(),
 	}
 	return logger, dbs
 }
@@ -119,6 +125,7 @@ func setGenesisAccounts(bapp *BasecoinApp, accs ...auth.BaseAccount) error {
 	// Initialize the chain
 	vals := []abci.Validator{}
 	bapp.InitChain(abci.RequestInitChain{vals, stateBytes})
+	bapp.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
 	bapp.Commit()
 
 	return nil
@@ -213,6 +220,177 @@ func TestGenesis(t *testing.T) {
 	assert.Equal(t, acc, res1)
 }
 
+func TestExportGenesis(t *testing.T) {
+	bapp := newBasecoinApp()
+
+	coins, err := sdk.ParseCoins("77foocoin")
+	require.Nil(t, err)
+	baseAcc := auth.BaseAccount{
+		Address: addr1,
+		Coins:   coins,
+	}
+	err = setGenesisAccounts(bapp, baseAcc)
+	require.Nil(t, err)
+
+	// a chain started from the export has the same app hash
+	exported, err := bapp.ExportAppState(0)
+	require.Nil(t, err)
+	bapp2 := newBasecoinApp()
+	bapp2.InitChain(abci.RequestInitChain{AppStateBytes: exported})
+	bapp2.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	bapp2.Commit()
+	assert.Equal(t, bapp.LastCommitID(), bapp2.LastCommitID())
+
+	// accounts keep their pubkey and sequence
+	SignCheckDeliver(t, bapp, sendMsg1, []int64{0}, true, priv1)
+	bapp.Commit()
+	exported2, err := bapp.ExportAppState(0)
+	require.Nil(t, err)
+	bapp3 := newBasecoinApp()
+	bapp3.InitChain(abci.RequestInitChain{AppStateBytes: exported2})
+	bapp3.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	bapp3.Commit()
+	ctx := bapp3.BaseApp.NewContext(true, abci.Header{})
+	acc := bapp3.accountMapper.GetAccount(ctx, addr1)
+	assert.Equal(t, priv1.PubKey(), acc.GetPubKey())
+	assert.Equal(t, int64(1), acc.GetSequence())
+	assert.Equal(t, "10foocoin", bapp3.accountMapper.GetAccount(ctx, addr2).GetCoins().String())
+	reexported, err := bapp3.ExportAppState(0)
+	require.Nil(t, err)
+	assert.Equal(t, string(exported2), string(reexported))
+
+	// earlier heights can still be exported
+	exported1, err := bapp.ExportAppState(1)
+	require.Nil(t, err)
+	assert.Equal(t, string(exported), string(exported1))
+}
+
+func TestVestingGenesis(t *testing.T) {
+	bapp := newBasecoinApp()
+
+	// the coins of addr1 vest from height 5 to 10
+	genAppState := server.VestingGenAppState(auth.GenesisVesting{
+		Type:      auth.VestingContinuous,
+		StartTime: 5,
+		EndTime:   10,
+		ByHeight:  true,
+	})
+	appState, err := genAppState(nil, addr1, "foocoin")
+	require.Nil(t, err)
+	bapp.InitChain(abci.RequestInitChain{AppStateBytes: appState})
+
+	// deliver sendMsg1 in a block at height
+	deliver := func(height int64, seq int64) sdk.Result {
+		bapp.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: height}})
+		res := bapp.Deliver(genTx(sendMsg1, []int64{seq}, priv1))
+		bapp.EndBlock(abci.RequestEndBlock{})
+		bapp.Commit()
+		return res
+	}
+	res := deliver(1, 0)
+	assert.Equal(t, sdk.CodeInsufficientCoins, res.Code, res.Log)
+	// the failed tx still used the sequence
+	res = deliver(6, 1)
+	assert.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	ctx := bapp.BaseApp.NewContext(true, abci.Header{})
+	assert.Equal(t, "10foocoin", bapp.accountMapper.GetAccount(ctx, addr2).GetCoins().String())
+
+	// the vesting account is exported
+	exported, err := bapp.ExportAppState(0)
+	require.Nil(t, err)
+	bapp2 := newBasecoinApp()
+	bapp2.InitChain(abci.RequestInitChain{AppStateBytes: exported})
+	bapp2.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	bapp2.Commit()
+	ctx = bapp2.BaseApp.NewContext(true, abci.Header{})
+	acc := bapp2.accountMapper.GetAccount(ctx, addr1)
+	require.IsType(t, &auth.ContinuousVestingAccount{}, acc)
+	assert.Equal(t, int64(10), acc.(*auth.ContinuousVestingAccount).EndTime)
+	reexported, err := bapp2.ExportAppState(0)
+	require.Nil(t, err)
+	assert.Equal(t, string(exported), string(reexported))
+}
+
+func TestVestingBond(t *testing.T) {
+	bapp := newBasecoinApp()
+
+	// the steak of addr1 vests from height 5 to 10
+	genAppState := server.VestingGenAppState(auth.GenesisVesting{
+		Type:      auth.VestingContinuous,
+		StartTime: 5,
+		EndTime:   10,
+		ByHeight:  true,
+	})
+	appState, err := genAppState(nil, addr1, "steak")
+	require.Nil(t, err)
+	bapp.InitChain(abci.RequestInitChain{AppStateBytes: appState})
+
+	deliver := func(height int64, msg sdk.Msg, seq int64) sdk.Result {
+		bapp.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: height}})
+		res := bapp.Deliver(genTx(msg, []int64{seq}, priv1))
+		bapp.EndBlock(abci.RequestEndBlock{})
+		bapp.Commit()
+		return res
+	}
+	vestingAcc := func(bapp *BasecoinApp) *auth.ContinuousVestingAccount {
+		ctx := bapp.BaseApp.NewContext(true, abci.Header{})
+		return bapp.accountMapper.GetAccount(ctx, addr1).(*auth.ContinuousVestingAccount)
+	}
+
+	// the locked steak can be bonded
+	bondMsg := simplestake.NewBondMsg(addr1, sdk.Coin{"steak", 10}, priv1.PubKey())
+	res := deliver(1, bondMsg, 0)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	acc := vestingAcc(bapp)
+	assert.Equal(t, sdk.Coins{{"steak", 9007199254740982}}, acc.GetCoins())
+	assert.Equal(t, sdk.Coins{{"steak", 10}}, acc.DelegatedVesting)
+
+	// the bond and the delegated steak are exported
+	exported, err := bapp.ExportAppState(0)
+	require.Nil(t, err)
+	bapp2 := newBasecoinApp()
+	bapp2.InitChain(abci.RequestInitChain{AppStateBytes: exported})
+	bapp2.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	bapp2.Commit()
+	reexported, err := bapp2.ExportAppState(0)
+	require.Nil(t, err)
+	assert.Equal(t, string(exported), string(reexported))
+
+	// unbonding returns the locked steak
+	res = deliver(2, simplestake.NewUnbondMsg(addr1), 1)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	acc = vestingAcc(bapp)
+	assert.Equal(t, sdk.Coins{{"steak", 9007199254740992}}, acc.GetCoins())
+	assert.True(t, acc.DelegatedVesting.IsZero())
+}
+
+func TestBondValidatorUpdates(t *testing.T) {
+	bapp := newBasecoinApp()
+	appState, err := server.DefaultGenAppState(nil, addr1, "steak")
+	require.Nil(t, err)
+	bapp.InitChain(abci.RequestInitChain{AppStateBytes: appState})
+
+	// the validator update of a bond is returned by EndBlock
+	bapp.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	bondMsg := simplestake.NewBondMsg(addr1, sdk.Coin{"steak", 10}, priv1.PubKey())
+	txBytes, err := bapp.cdc.MarshalBinary(genTx(bondMsg, []int64{0}, priv1))
+	require.Nil(t, err)
+	res := bapp.DeliverTx(txBytes)
+	require.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+	endRes := bapp.EndBlock(abci.RequestEndBlock{})
+	bapp.Commit()
+	require.Equal(t, 1, len(endRes.ValidatorUpdates))
+	assert.Equal(t, priv1.PubKey().Bytes(), endRes.ValidatorUpdates[0].PubKey)
+	assert.Equal(t, int64(10), endRes.ValidatorUpdates[0].Power)
+
+	// and the bonded validator is exported
+	validators, err := bapp.ExportValidators()
+	require.Nil(t, err)
+	require.Equal(t, 1, len(validators))
+	assert.Equal(t, priv1.PubKey(), validators[0].PubKey)
+	assert.Equal(t, int64(10), validators[0].Power)
+}
+
 func TestSendMsgWithAccounts(t *testing.T) {
 	bapp := newBasecoinApp()
 
@@ -254,6 +432,146 @@ func TestSendMsgWithAccounts(t *testing.T) {
 	SignCheckDeliver(t, bapp, sendMsg1, []int64{1}, true, priv1)
 }
 
+func TestFeeCollection(t *testing.T) {
+	bapp := newBasecoinApp()
+
+	coins, err := sdk.ParseCoins("77foocoin")
+	require.Nil(t, err)
+	baseAcc := auth.BaseAccount{
+		Address: addr1,
+		Coins:   coins,
+	}
+	err = setGenesisAccounts(bapp, baseAcc)
+	require.Nil(t, err)
+
+	// send with a fee
+	fee := sdk.NewStdFee(100000, sdk.Coin{"foocoin", 5})
+	msgs := []sdk.Msg{sendMsg1}
+	sig := priv1.Sign(sdk.StdSignBytes(chainID, []int64{0}, fee, msgs))
+	tx := sdk.NewStdTx(msgs, fee, []sdk.StdSignature{{
+		PubKey:    priv1.PubKey(),
+		Signature: sig,
+		Sequence:  0,
+	}})
+	bapp.BeginBlock(abci.RequestBeginBlock{})
+	res := bapp.Deliver(tx)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	bapp.EndBlock(abci.RequestEndBlock{})
+	bapp.Commit()
+
+	// the fee is collected
+	ctx := bapp.BaseApp.NewContext(true, abci.Header{})
+	assert.Equal(t, "62foocoin", bapp.accountMapper.GetAccount(ctx, addr1).GetCoins().String())
+	assert.Equal(t, "5foocoin", bapp.feeCollectionKeeper.GetCollectedFees(ctx).String())
+	CheckSupply(t, bapp, "77foocoin")
+
+	// and exported with the genesis
+	exported, err := bapp.ExportAppState(0)
+	require.Nil(t, err)
+	bapp2 := newBasecoinApp()
+	bapp2.InitChain(abci.RequestInitChain{AppStateBytes: exported})
+	bapp2.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	bapp2.Commit()
+	ctx = bapp2.BaseApp.NewContext(true, abci.Header{})
+	assert.Equal(t, "5foocoin", bapp2.feeCollectionKeeper.GetCollectedFees(ctx).String())
+	CheckSupply(t, bapp2, "77foocoin")
+}
+
+func TestFeeGrant(t *testing.T) {
+	bapp := newBasecoinApp()
+
+	// addr2 has no foocoin to pay fees
+	acc1 := auth.BaseAccount{Address: addr1, Coins: sdk.Coins{{"foocoin", 77}}}
+	acc2 := auth.BaseAccount{Address: addr2, Coins: sdk.Coins{{"barcoin", 10}}}
+	err := setGenesisAccounts(bapp, acc1, acc2)
+	require.Nil(t, err)
+
+	// deliver msg with a fee of amount, paid by granter
+	deliver := func(msg sdk.Msg, amount int64, granter sdk.Address, seq int64, priv crypto.PrivKeyEd25519) sdk.Result {
+		fee := sdk.NewStdFee(100000, sdk.Coin{"foocoin", amount})
+		fee.Granter = granter
+		msgs := []sdk.Msg{msg}
+		tx := sdk.NewStdTx(msgs, fee, []sdk.StdSignature{{
+			PubKey:    priv.PubKey(),
+			Signature: priv.Sign(sdk.StdSignBytes(chainID, []int64{seq}, fee, msgs)),
+			Sequence:  seq,
+		}})
+		bapp.BeginBlock(abci.RequestBeginBlock{})
+		res := bapp.Deliver(tx)
+		bapp.EndBlock(abci.RequestEndBlock{})
+		bapp.Commit()
+		return res
+	}
+	sendBar := bank.SendMsg{
+		Inputs:  []bank.Input{bank.NewInput(addr2, sdk.Coins{{"barcoin", 1}})},
+		Outputs: []bank.Output{bank.NewOutput(addr1, sdk.Coins{{"barcoin", 1}})},
+	}
+
+	// addr1 grants addr2 up to 10foocoin of fees for sends
+	res := deliver(sendBar, 5, addr1, 0, priv2)
+	assert.Equal(t, feegrant.CodeNoAllowance, res.Code, res.Log)
+	allowance := feegrant.FeeAllowance{
+		SpendLimit:      sdk.Coins{{"foocoin", 10}},
+		AllowedMsgTypes: []string{sendBar.Type()},
+	}
+	res = deliver(feegrant.NewMsgGrantFeeAllowance(addr1, addr2, allowance), 0, nil, 0, priv1)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+
+	// which pays the fees of addr2
+	res = deliver(sendBar, 6, addr1, 0, priv2)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	res = deliver(sendBar, 5, addr1, 1, priv2)
+	assert.Equal(t, feegrant.CodeFeeLimitExceeded, res.Code, res.Log)
+	ctx := bapp.BaseApp.NewContext(true, abci.Header{})
+	assert.Equal(t, "1barcoin,71foocoin", bapp.accountMapper.GetAccount(ctx, addr1).GetCoins().String())
+	assert.Equal(t, "9barcoin", bapp.accountMapper.GetAccount(ctx, addr2).GetCoins().String())
+	CheckSupply(t, bapp, "10barcoin,77foocoin")
+
+	// the rest of the allowance is exported
+	exported, err := bapp.ExportAppState(0)
+	require.Nil(t, err)
+	bapp2 := newBasecoinApp()
+	bapp2.InitChain(abci.RequestInitChain{AppStateBytes: exported})
+	bapp2.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
+	bapp2.Commit()
+	bapp = bapp2
+	res = deliver(sendBar, 3, addr1, 1, priv2)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+
+	// until it is revoked
+	res = deliver(feegrant.NewMsgRevokeFeeAllowance(addr1, addr2), 0, nil, 1, priv1)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	res = deliver(sendBar, 0, addr1, 2, priv2)
+	assert.Equal(t, feegrant.CodeNoAllowance, res.Code, res.Log)
+}
+
+func TestAuthz(t *testing.T) {
+	bapp := newBasecoinApp()
+
+	acc1 := auth.BaseAccount{Address: addr1, Coins: sdk.Coins{{"foocoin", 77}}}
+	acc2 := auth.BaseAccount{Address: addr2}
+	err := setGenesisAccounts(bapp, acc1, acc2)
+	require.Nil(t, err)
+
+	// addr2 sends the coins of addr1 once addr1 grants it sends
+	exec := authz.NewMsgExec(addr2, []sdk.Msg{sendMsg1})
+	SignCheckDeliver(t, bapp, exec, []int64{0}, false, priv2)
+	SignCheckDeliver(t, bapp, authz.NewMsgGrant(addr1, addr2, sendMsg1.Type(), 0), []int64{0}, true, priv1)
+	SignCheckDeliver(t, bapp, exec, []int64{1}, true, priv2)
+	CheckBalance(t, bapp, addr1, "67foocoin")
+	CheckBalance(t, bapp, addr2, "10foocoin")
+
+	// the authorization is exported
+	bapp.Commit()
+	exported, err := bapp.ExportAppState(0)
+	require.Nil(t, err)
+	bapp2 := newBasecoinApp()
+	bapp2.InitChain(abci.RequestInitChain{AppStateBytes: exported})
+	bapp2.Commit()
+	SignCheckDeliver(t, bapp2, exec, []int64{2}, true, priv2)
+	CheckBalance(t, bapp2, addr1, "57foocoin")
+}
+
 func TestSendMsgMultipleOut(t *testing.T) {
 	bapp := newBasecoinApp()
 
@@ -427,12 +745,12 @@ func genTx(msg sdk.Msg, seq []int64, priv ...crypto.PrivKeyEd25519) sdk.StdTx {
 	for i, p := range priv {
 		sigs[i] = sdk.StdSignature{
 			PubKey:    p.PubKey(),
-			Signature: p.Sign(sdk.StdSignBytes(chainID, seq, fee, msg)),
+			Signature: p.Sign(sdk.StdSignBytes(chainID, seq, fee, []sdk.Msg{msg})),
 			Sequence:  seq[i],
 		}
 	}
 
-	return sdk.NewStdTx(msg, fee, sigs)
+	return sdk.NewStdTx([]sdk.Msg{msg}, fee, sigs)
 
 }
 
@@ -460,6 +778,15 @@ func SignCheckDeliver(t *testing.T, bapp *BasecoinApp, msg sdk.Msg, seq []int64,
 	//bapp.Commit()
 }
 
+// check the supply of coins with a query
+func CheckSupply(t *testing.T, bapp *BasecoinApp, supplyExpected string) {
+	res := bapp.Query(abci.RequestQuery{Path: "/custom/bank/supply"})
+	require.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+	var supply sdk.Coins
+	require.Nil(t, bapp.cdc.UnmarshalJSON(res.Value, &supply))
+	assert.Equal(t, supplyExpected, supply.String())
+}
+
 func CheckBalance(t *testing.T, bapp *BasecoinApp, addr sdk.Address, balExpected string) {
 	ctxDeliver := bapp.BaseApp.NewContext(false, abci.Header{})
 	res2 := bapp.accountMapper.GetAccount(ctxDeliver, addr)
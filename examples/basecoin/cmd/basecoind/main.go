@@ -1,16 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 
 	"github.com/spf13/cobra"
 
 	abci "github.com/tendermint/abci/types"
+	tmtypes "github.com/tendermint/tendermint/types"
 	"github.com/tendermint/tmlibs/cli"
 	dbm "github.com/tendermint/tmlibs/db"
 	"github.com/tendermint/tmlibs/log"
 
+	bam "github.com/cosmos/cosmos-sdk/baseapp"
 	"github.com/cosmos/cosmos-sdk/examples/basecoin/app"
 	"github.com/cosmos/cosmos-sdk/server"
 )
@@ -26,6 +29,10 @@ var (
 )
 
 func generateApp(rootDir string, logger log.Logger) (abci.Application, error) {
+	pruning, err := server.GetPruningStrategy()
+	if err != nil {
+		return nil, err
+	}
 	dataDir := filepath.Join(rootDir, "data")
 	dbMain, err := dbm.NewGoLevelDB("basecoin", dataDir)
 	if err != nil {
@@ -43,18 +50,45 @@ func generateApp(rootDir string, logger log.Logger) (abci.Application, error) {
 	if err != nil {
 		return nil, err
 	}
+	dbFeeGrant, err := dbm.NewGoLevelDB("basecoin-feegrant", dataDir)
+	if err != nil {
+		return nil, err
+	}
+	dbAuthz, err := dbm.NewGoLevelDB("basecoin-authz", dataDir)
+	if err != nil {
+		return nil, err
+	}
 	dbs := map[string]dbm.DB{
-		"main":    dbMain,
-		"acc":     dbAcc,
-		"ibc":     dbIBC,
-		"staking": dbStaking,
+		"main":     dbMain,
+		"acc":      dbAcc,
+		"ibc":      dbIBC,
+		"staking":  dbStaking,
+		"feegrant": dbFeeGrant,
+		"authz":    dbAuthz,
 	}
-	bapp := app.NewBasecoinApp(logger, dbs)
+	bapp := app.NewBasecoinApp(logger, dbs, bam.SetPruning(pruning))
 	return bapp, nil
 }
 
+func exportApp(rootDir string, logger log.Logger, height int64) (json.RawMessage, []tmtypes.GenesisValidator, error) {
+	bapp, err := generateApp(rootDir, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	basecoinApp := bapp.(*app.BasecoinApp)
+	appState, err := basecoinApp.ExportAppState(height)
+	if err != nil {
+		return nil, nil, err
+	}
+	validators, err := basecoinApp.ExportValidators()
+	if err != nil {
+		return nil, nil, err
+	}
+	return appState, validators, nil
+}
+
 func main() {
-	server.AddCommands(rootCmd, server.DefaultGenAppState, generateApp, context)
+	server.AddCommands(rootCmd, server.DefaultGenAppState, generateApp, exportApp, context)
 
 	// prepare and add flags
 	rootDir := os.ExpandEnv("$HOME/.basecoind")
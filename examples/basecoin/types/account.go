@@ -1,6 +1,12 @@
 package types
 
 import (
+	"bytes"
+	"fmt"
+
+	crypto "github.com/tendermint/go-crypto"
+	oldwire "github.com/tendermint/go-wire"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire"
 	"github.com/cosmos/cosmos-sdk/x/auth"
@@ -28,12 +34,13 @@ func GetAccountDecoder(cdc *wire.Codec) sdk.AccountDecoder {
 		if len(accBytes) == 0 {
 			return nil, sdk.ErrTxDecode("accBytes are empty")
 		}
-		acct := new(AppAccount)
-		err = cdc.UnmarshalBinary(accBytes, &acct)
+		// the account may be an AppAccount or a vesting account
+		r, n := bytes.NewBuffer(accBytes), new(int)
+		accI := oldwire.ReadBinary(struct{ sdk.Account }{}, r, len(accBytes), n, &err)
 		if err != nil {
 			panic(err)
 		}
-		return acct, err
+		return accI.(struct{ sdk.Account }).Account, err
 	}
 }
 
@@ -44,26 +51,69 @@ type GenesisState struct {
 	Accounts []*GenesisAccount `json:"accounts"`
 }
 
-// GenesisAccount doesn't need pubkey or sequence
+// GenesisAccount doesn't need pubkey or sequence,
+// but exported accounts keep them so the chain can be restarted
 type GenesisAccount struct {
-	Name    string      `json:"name"`
-	Address sdk.Address `json:"address"`
-	Coins   sdk.Coins   `json:"coins"`
+	Name     string        `json:"name"`
+	Address  sdk.Address   `json:"address"`
+	Coins    sdk.Coins     `json:"coins"`
+	PubKey   crypto.PubKey `json:"public_key"`
+	Sequence int64         `json:"sequence"`
+
+	// the schedule of a vesting account, which has no name
+	Vesting *auth.GenesisVesting `json:"vesting,omitempty"`
 }
 
 func NewGenesisAccount(aa *AppAccount) *GenesisAccount {
 	return &GenesisAccount{
-		Name:    aa.Name,
-		Address: aa.Address,
-		Coins:   aa.Coins.Sort(),
+		Name:     aa.Name,
+		Address:  aa.Address,
+		Coins:    aa.Coins.Sort(),
+		PubKey:   aa.PubKey,
+		Sequence: aa.Sequence,
+	}
+}
+
+// NewGenesisAccountI returns the genesis account of an AppAccount
+// or of a vesting account
+func NewGenesisAccountI(acc sdk.Account) *GenesisAccount {
+	switch acc := acc.(type) {
+	case *AppAccount:
+		return NewGenesisAccount(acc)
+	case auth.VestingAccount:
+		return &GenesisAccount{
+			Address:  acc.GetAddress(),
+			Coins:    acc.GetCoins().Sort(),
+			PubKey:   acc.GetPubKey(),
+			Sequence: acc.GetSequence(),
+			Vesting:  auth.NewGenesisVesting(acc),
+		}
+	}
+	panic(fmt.Sprintf("unknown account type %T", acc))
+}
+
+// convert GenesisAccount to an AppAccount, or to a vesting account
+// if it has a vesting schedule
+func (ga *GenesisAccount) ToAccount() (acc sdk.Account, err error) {
+	if ga.Vesting == nil {
+		return ga.ToAppAccount()
+	}
+	baseAcc := auth.BaseAccount{
+		Address:  ga.Address,
+		Coins:    ga.Coins.Sort(),
+		PubKey:   ga.PubKey,
+		Sequence: ga.Sequence,
 	}
+	return ga.Vesting.ToVestingAccount(baseAcc)
 }
 
 // convert GenesisAccount to AppAccount
 func (ga *GenesisAccount) ToAppAccount() (acc *AppAccount, err error) {
 	baseAcc := auth.BaseAccount{
-		Address: ga.Address,
-		Coins:   ga.Coins.Sort(),
+		Address:  ga.Address,
+		Coins:    ga.Coins.Sort(),
+		PubKey:   ga.PubKey,
+		Sequence: ga.Sequence,
 	}
 	return &AppAccount{
 		BaseAccount: baseAcc,
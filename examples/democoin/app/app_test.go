@@ -14,7 +14,9 @@ import (
 	"github.com/cosmos/cosmos-sdk/examples/democoin/x/pow"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/auth"
+	"github.com/cosmos/cosmos-sdk/x/authz"
 	"github.com/cosmos/cosmos-sdk/x/bank"
+	"github.com/cosmos/cosmos-sdk/x/feegrant"
 	"github.com/cosmos/cosmos-sdk/x/ibc"
 
 	abci "github.com/tendermint/abci/types"
@@ -32,8 +34,8 @@ var (
 	addr2 = crypto.GenPrivKeyEd25519().PubKey().Address()
 	coins = sdk.Coins{{"foocoin", 10}}
 	fee   = sdk.StdFee{
-		sdk.Coins{{"foocoin", 0}},
-		0,
+		Amount: sdk.Coins{{"foocoin", 0}},
+		Gas:    100000,
 	}
 
 	sendMsg = bank.SendMsg{
@@ -70,11 +72,13 @@ var (
 func loggerAndDBs() (log.Logger, map[string]dbm.DB) {
 	logger := log.NewTMLogger(log.NewSyncWriter(os.Stdout)).With("module", "sdk/app")
 	dbs := map[string]dbm.DB{
-		"main":    dbm.NewMemDB(),
-		"acc":     dbm.NewMemDB(),
-		"pow":     dbm.NewMemDB(),
-		"ibc":     dbm.NewMemDB(),
-		"staking": dbm.NewMemDB(),
+		"main":     dbm.NewMemDB(),
+		"acc":      dbm.NewMemDB(),
+		"pow":      dbm.NewMemDB(),
+		"ibc":      dbm.NewMemDB(),
+		"staking":  dbm.NewMemDB(),
+		"feegrant": dbm.NewMemDB(),
+		"authz":    dbm.NewMemDB(),
 	}
 	return logger, dbs
 }
@@ -95,12 +99,13 @@ func TestMsgs(t *testing.T) {
 		{sendMsg},
 		{quizMsg1},
 		{setTrendMsg1},
+		{authz.NewMsgExec(addr1, []sdk.Msg{setTrendMsg1})},
 	}
 
 	sequences := []int64{0}
 	for i, m := range msgs {
-		sig := priv1.Sign(sdk.StdSignBytes(chainID, sequences, fee, m.msg))
-		tx := sdk.NewStdTx(m.msg, fee, []sdk.StdSignature{{
+		sig := priv1.Sign(sdk.StdSignBytes(chainID, sequences, fee, []sdk.Msg{m.msg}))
+		tx := sdk.NewStdTx([]sdk.Msg{m.msg}, fee, []sdk.StdSignature{{
 			PubKey:    priv1.PubKey(),
 			Signature: sig,
 		}})
@@ -150,6 +155,7 @@ func TestGenesis(t *testing.T) {
 
 	vals := []abci.Validator{}
 	bapp.InitChain(abci.RequestInitChain{vals, stateBytes})
+	bapp.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: 1}})
 	bapp.Commit()
 
 	// A checkTx context
@@ -201,8 +207,8 @@ func TestSendMsgWithAccounts(t *testing.T) {
 
 	// Sign the tx
 	sequences := []int64{0}
-	sig := priv1.Sign(sdk.StdSignBytes(chainID, sequences, fee, sendMsg))
-	tx := sdk.NewStdTx(sendMsg, fee, []sdk.StdSignature{{
+	sig := priv1.Sign(sdk.StdSignBytes(chainID, sequences, fee, []sdk.Msg{sendMsg}))
+	tx := sdk.NewStdTx([]sdk.Msg{sendMsg}, fee, []sdk.StdSignature{{
 		PubKey:    priv1.PubKey(),
 		Signature: sig,
 	}})
@@ -234,7 +240,7 @@ func TestSendMsgWithAccounts(t *testing.T) {
 
 	// resigning the tx with the bumped sequence should work
 	sequences = []int64{1}
-	sig = priv1.Sign(sdk.StdSignBytes(chainID, sequences, fee, tx.Msg))
+	sig = priv1.Sign(sdk.StdSignBytes(chainID, sequences, fee, tx.Msgs))
 	tx.Signatures[0].Signature = sig
 	res = bapp.Deliver(tx)
 	assert.Equal(t, sdk.CodeOK, res.Code, res.Log)
@@ -399,13 +405,128 @@ func TestHandler(t *testing.T) {
 	SignCheckDeliver(t, bapp, receiveMsg, 3, false)
 }
 
+func TestAuthz(t *testing.T) {
+	bapp := newDemocoinApp()
+
+	// the grantee signs for addr1
+	priv2 := crypto.GenPrivKeyEd25519()
+	addr2 := priv2.PubKey().Address()
+	acc1 := &types.AppAccount{auth.BaseAccount{Address: addr1}, "foobart"}
+	acc2 := &types.AppAccount{auth.BaseAccount{Address: addr2}, "grantee"}
+	genesisState := map[string]interface{}{
+		"accounts": []*types.GenesisAccount{
+			types.NewGenesisAccount(acc1),
+			types.NewGenesisAccount(acc2),
+		},
+		"cool": map[string]string{
+			"trend": "ice-cold",
+		},
+	}
+	stateBytes, err := json.MarshalIndent(genesisState, "", "\t")
+	require.Nil(t, err)
+	bapp.InitChain(abci.RequestInitChain{[]abci.Validator{}, stateBytes})
+	bapp.Commit()
+
+	exec := func(msg sdk.Msg, seq int64) sdk.Result {
+		execMsg := authz.NewMsgExec(addr2, []sdk.Msg{msg})
+		tx := sdk.NewStdTx([]sdk.Msg{execMsg}, fee, []sdk.StdSignature{{
+			PubKey:    priv2.PubKey(),
+			Signature: priv2.Sign(sdk.StdSignBytes(chainID, []int64{seq}, fee, []sdk.Msg{execMsg})),
+			Sequence:  seq,
+		}})
+		bapp.BeginBlock(abci.RequestBeginBlock{})
+		res := bapp.Deliver(tx)
+		bapp.EndBlock(abci.RequestEndBlock{})
+		return res
+	}
+
+	// not until addr1 grants each msg
+	res := exec(setTrendMsg1, 0)
+	assert.Equal(t, authz.CodeNoAuthorization, res.Code, res.Log)
+	SignCheckDeliver(t, bapp, authz.NewMsgGrant(addr1, addr2, setTrendMsg1.Type(), 0), 0, true)
+	res = exec(setTrendMsg1, 1)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	res = exec(quizMsg1, 2)
+	assert.Equal(t, authz.CodeNoAuthorization, res.Code, res.Log)
+	CheckBalance(t, bapp, "")
+	SignCheckDeliver(t, bapp, authz.NewMsgGrant(addr1, addr2, quizMsg1.Type(), 0), 1, true)
+	res = exec(quizMsg1, 3)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	CheckBalance(t, bapp, "69icecold")
+
+	// the inner msgs must be valid too, which is checked before the sequence
+	res = exec(setTrendMsg3, 4)
+	assert.NotEqual(t, sdk.CodeOK, res.Code, res.Log)
+
+	// nor once revoked
+	SignCheckDeliver(t, bapp, authz.NewMsgRevoke(addr1, addr2, quizMsg1.Type()), 2, true)
+	res = exec(quizMsg1, 4)
+	assert.Equal(t, authz.CodeNoAuthorization, res.Code, res.Log)
+	CheckBalance(t, bapp, "69icecold")
+	res = exec(setTrendMsg2, 5)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+}
+
+func TestFeeGrant(t *testing.T) {
+	bapp := newDemocoinApp()
+
+	// the grantee has no foocoin to pay fees
+	priv2 := crypto.GenPrivKeyEd25519()
+	addr2 := priv2.PubKey().Address()
+	acc1 := &types.AppAccount{auth.BaseAccount{Address: addr1, Coins: coins}, "foobart"}
+	acc2 := &types.AppAccount{auth.BaseAccount{Address: addr2}, "grantee"}
+	genesisState := map[string]interface{}{
+		"accounts": []*types.GenesisAccount{
+			types.NewGenesisAccount(acc1),
+			types.NewGenesisAccount(acc2),
+		},
+		"cool": map[string]string{
+			"trend": "ice-cold",
+		},
+	}
+	stateBytes, err := json.MarshalIndent(genesisState, "", "\t")
+	require.Nil(t, err)
+	bapp.InitChain(abci.RequestInitChain{[]abci.Validator{}, stateBytes})
+	bapp.Commit()
+
+	// deliver msg of addr2 with a fee paid by addr1
+	deliver := func(msg sdk.Msg, seq int64) sdk.Result {
+		fee := sdk.NewStdFee(100000, sdk.Coin{"foocoin", 3})
+		fee.Granter = addr1
+		msgs := []sdk.Msg{msg}
+		tx := sdk.NewStdTx(msgs, fee, []sdk.StdSignature{{
+			PubKey:    priv2.PubKey(),
+			Signature: priv2.Sign(sdk.StdSignBytes(chainID, []int64{seq}, fee, msgs)),
+			Sequence:  seq,
+		}})
+		bapp.BeginBlock(abci.RequestBeginBlock{})
+		res := bapp.Deliver(tx)
+		bapp.EndBlock(abci.RequestEndBlock{})
+		return res
+	}
+	setTrend := cool.SetTrendMsg{Sender: addr2, Cool: "icecold"}
+	quiz := cool.QuizMsg{Sender: addr2, CoolAnswer: "icecold"}
+
+	// not until addr1 grants an allowance for the msg
+	res := deliver(setTrend, 0)
+	assert.Equal(t, feegrant.CodeNoAllowance, res.Code, res.Log)
+	allowance := feegrant.FeeAllowance{AllowedMsgTypes: []string{setTrend.Type()}}
+	SignCheckDeliver(t, bapp, feegrant.NewMsgGrantFeeAllowance(addr1, addr2, allowance), 0, true)
+	res = deliver(setTrend, 0)
+	require.Equal(t, sdk.CodeOK, res.Code, res.Log)
+	CheckBalance(t, bapp, "7foocoin")
+	res = deliver(quiz, 1)
+	assert.Equal(t, feegrant.CodeMsgNotAllowed, res.Code, res.Log)
+	CheckBalance(t, bapp, "7foocoin")
+}
+
 // TODO describe the use of this function
 func SignCheckDeliver(t *testing.T, bapp *DemocoinApp, msg sdk.Msg, seq int64, expPass bool) {
 
 	// Sign the tx
-	tx := sdk.NewStdTx(msg, fee, []sdk.StdSignature{{
+	tx := sdk.NewStdTx([]sdk.Msg{msg}, fee, []sdk.StdSignature{{
 		PubKey:    priv1.PubKey(),
-		Signature: priv1.Sign(sdk.StdSignBytes(chainID, []int64{seq}, fee, msg)),
+		Signature: priv1.Sign(sdk.StdSignBytes(chainID, []int64{seq}, fee, []sdk.Msg{msg})),
 		Sequence:  seq,
 	}})
 
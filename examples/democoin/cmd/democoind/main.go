@@ -8,10 +8,12 @@ import (
 	"github.com/spf13/cobra"
 
 	abci "github.com/tendermint/abci/types"
+	tmtypes "github.com/tendermint/tendermint/types"
 	"github.com/tendermint/tmlibs/cli"
 	dbm "github.com/tendermint/tmlibs/db"
 	"github.com/tendermint/tmlibs/log"
 
+	bam "github.com/cosmos/cosmos-sdk/baseapp"
 	"github.com/cosmos/cosmos-sdk/examples/democoin/app"
 	"github.com/cosmos/cosmos-sdk/server"
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -47,6 +49,10 @@ func defaultAppState(args []string, addr sdk.Address, coinDenom string) (json.Ra
 }
 
 func generateApp(rootDir string, logger log.Logger) (abci.Application, error) {
+	pruning, err := server.GetPruningStrategy()
+	if err != nil {
+		return nil, err
+	}
 	dbMain, err := dbm.NewGoLevelDB("democoin", filepath.Join(rootDir, "data"))
 	if err != nil {
 		return nil, err
@@ -67,19 +73,46 @@ func generateApp(rootDir string, logger log.Logger) (abci.Application, error) {
 	if err != nil {
 		return nil, err
 	}
+	dbFeeGrant, err := dbm.NewGoLevelDB("democoin-feegrant", filepath.Join(rootDir, "data"))
+	if err != nil {
+		return nil, err
+	}
+	dbAuthz, err := dbm.NewGoLevelDB("democoin-authz", filepath.Join(rootDir, "data"))
+	if err != nil {
+		return nil, err
+	}
 	dbs := map[string]dbm.DB{
-		"main":    dbMain,
-		"acc":     dbAcc,
-		"pow":     dbPow,
-		"ibc":     dbIBC,
-		"staking": dbStaking,
+		"main":     dbMain,
+		"acc":      dbAcc,
+		"pow":      dbPow,
+		"ibc":      dbIBC,
+		"staking":  dbStaking,
+		"feegrant": dbFeeGrant,
+		"authz":    dbAuthz,
 	}
-	bapp := app.NewDemocoinApp(logger, dbs)
+	bapp := app.NewDemocoinApp(logger, dbs, bam.SetPruning(pruning))
 	return bapp, nil
 }
 
+func exportApp(rootDir string, logger log.Logger, height int64) (json.RawMessage, []tmtypes.GenesisValidator, error) {
+	bapp, err := generateApp(rootDir, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	democoinApp := bapp.(*app.DemocoinApp)
+	appState, err := democoinApp.ExportAppState(height)
+	if err != nil {
+		return nil, nil, err
+	}
+	validators, err := democoinApp.ExportValidators()
+	if err != nil {
+		return nil, nil, err
+	}
+	return appState, validators, nil
+}
+
 func main() {
-	server.AddCommands(rootCmd, defaultAppState, generateApp, context)
+	server.AddCommands(rootCmd, defaultAppState, generateApp, exportApp, context)
 
 	// prepare and add flags
 	rootDir := os.ExpandEnv("$HOME/.democoind")
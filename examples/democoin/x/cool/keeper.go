@@ -1,6 +1,8 @@
 package cool
 
 import (
+	"encoding/json"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/x/bank"
 )
@@ -46,3 +48,33 @@ func (k Keeper) InitGenesis(ctx sdk.Context, data CoolGenesis) error {
 	k.setTrend(ctx, data.Trend)
 	return nil
 }
+
+// NewInitGenesis - returns an sdk.InitGenesis which parses the CoolGenesis
+func NewInitGenesis(k Keeper) sdk.InitGenesis {
+	return func(ctx sdk.Context, data json.RawMessage) error {
+		// a missing genesis leaves the defaults
+		var genesis CoolGenesis
+		if len(data) != 0 {
+			if err := json.Unmarshal(data, &genesis); err != nil {
+				return err
+			}
+		}
+		return k.InitGenesis(ctx, genesis)
+	}
+}
+
+// ExportGenesis - returns the current trend as a CoolGenesis
+func (k Keeper) ExportGenesis(ctx sdk.Context) CoolGenesis {
+	return CoolGenesis{Trend: k.GetTrend(ctx)}
+}
+
+// NewExportGenesis - returns an sdk.ExportGenesis which serializes the CoolGenesis
+func NewExportGenesis(k Keeper) sdk.ExportGenesis {
+	return func(ctx sdk.Context) json.RawMessage {
+		bz, err := json.Marshal(k.ExportGenesis(ctx))
+		if err != nil {
+			panic(err)
+		}
+		return bz
+	}
+}
@@ -32,7 +32,7 @@ func NewSetTrendMsg(sender sdk.Address, cool string) SetTrendMsg {
 var _ sdk.Msg = SetTrendMsg{}
 
 // nolint
-func (msg SetTrendMsg) Type() string                            { return "cool" }
+func (msg SetTrendMsg) Type() string                            { return "cool/settrend" }
 func (msg SetTrendMsg) Get(key interface{}) (value interface{}) { return nil }
 func (msg SetTrendMsg) GetSigners() []sdk.Address               { return []sdk.Address{msg.Sender} }
 func (msg SetTrendMsg) String() string {
@@ -83,7 +83,7 @@ func NewQuizMsg(sender sdk.Address, coolerthancool string) QuizMsg {
 var _ sdk.Msg = QuizMsg{}
 
 // nolint
-func (msg QuizMsg) Type() string                            { return "cool" }
+func (msg QuizMsg) Type() string                            { return "cool/quiz" }
 func (msg QuizMsg) Get(key interface{}) (value interface{}) { return nil }
 func (msg QuizMsg) GetSigners() []sdk.Address               { return []sdk.Address{msg.Sender} }
 func (msg QuizMsg) String() string {
@@ -18,7 +18,7 @@ func TestPowHandler(t *testing.T) {
 	am := auth.NewAccountMapper(capKey, &auth.BaseAccount{})
 	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
 	config := NewPowConfig("pow", int64(1))
-	ck := bank.NewCoinKeeper(am)
+	ck := bank.NewCoinKeeper(am, capKey)
 	keeper := NewKeeper(capKey, config, ck)
 
 	handler := keeper.Handler
@@ -1,6 +1,7 @@
 package pow
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 
@@ -40,6 +41,45 @@ func (pk Keeper) InitGenesis(ctx sdk.Context, genesis PowGenesis) error {
 	return nil
 }
 
+// returns an sdk.InitGenesis which parses the PowGenesis
+func NewInitGenesis(pk Keeper) sdk.InitGenesis {
+	return func(ctx sdk.Context, data json.RawMessage) error {
+		// a missing genesis leaves the defaults
+		var genesis PowGenesis
+		if len(data) != 0 {
+			if err := json.Unmarshal(data, &genesis); err != nil {
+				return err
+			}
+		}
+		return pk.InitGenesis(ctx, genesis)
+	}
+}
+
+// ExportGenesis returns the current difficulty and count as a PowGenesis
+func (pk Keeper) ExportGenesis(ctx sdk.Context) (genesis PowGenesis, err error) {
+	genesis.Difficulty, err = pk.GetLastDifficulty(ctx)
+	if err != nil {
+		return genesis, err
+	}
+	genesis.Count, err = pk.GetLastCount(ctx)
+	return genesis, err
+}
+
+// returns an sdk.ExportGenesis which serializes the PowGenesis
+func NewExportGenesis(pk Keeper) sdk.ExportGenesis {
+	return func(ctx sdk.Context) json.RawMessage {
+		genesis, err := pk.ExportGenesis(ctx)
+		if err != nil {
+			panic(err)
+		}
+		bz, err := json.Marshal(genesis)
+		if err != nil {
+			panic(err)
+		}
+		return bz
+	}
+}
+
 var lastDifficultyKey = []byte("lastDifficultyKey")
 
 func (pk Keeper) GetLastDifficulty(ctx sdk.Context) (uint64, error) {
@@ -48,7 +88,7 @@ func (pk Keeper) GetLastDifficulty(ctx sdk.Context) (uint64, error) {
 	if stored == nil {
 		panic("no stored difficulty")
 	} else {
-		return strconv.ParseUint(string(stored), 0, 64)
+		return strconv.ParseUint(string(stored), 16, 64)
 	}
 }
 
@@ -65,7 +105,7 @@ func (pk Keeper) GetLastCount(ctx sdk.Context) (uint64, error) {
 	if stored == nil {
 		panic("no stored count")
 	} else {
-		return strconv.ParseUint(string(stored), 0, 64)
+		return strconv.ParseUint(string(stored), 16, 64)
 	}
 }
 
@@ -31,7 +31,7 @@ func TestPowKeeperGetSet(t *testing.T) {
 	am := auth.NewAccountMapper(capKey, &auth.BaseAccount{})
 	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
 	config := NewPowConfig("pow", int64(1))
-	ck := bank.NewCoinKeeper(am)
+	ck := bank.NewCoinKeeper(am, capKey)
 	keeper := NewKeeper(capKey, config, ck)
 
 	err := keeper.InitGenesis(ctx, PowGenesis{uint64(1), uint64(0)})
@@ -47,3 +47,22 @@ func TestPowKeeperGetSet(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, res, uint64(2))
 }
+
+func TestPowKeeperExportGenesis(t *testing.T) {
+	ms, capKey := setupMultiStore()
+
+	am := auth.NewAccountMapper(capKey, &auth.BaseAccount{})
+	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
+	config := NewPowConfig("pow", int64(1))
+	ck := bank.NewCoinKeeper(am, capKey)
+	keeper := NewKeeper(capKey, config, ck)
+
+	// values above 9 are stored as hex
+	genesis := PowGenesis{uint64(26), uint64(17)}
+	err := keeper.InitGenesis(ctx, genesis)
+	assert.Nil(t, err)
+
+	res, err := keeper.ExportGenesis(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, genesis, res)
+}
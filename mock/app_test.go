@@ -30,7 +30,7 @@ func TestInitApp(t *testing.T) {
 	// XXX test failing
 	// make sure we can query these values
 	query := abci.RequestQuery{
-		Path: "/main/key",
+		Path: "/store/main/key",
 		Data: []byte("foo"),
 	}
 	qres := app.Query(query)
@@ -66,7 +66,7 @@ func TestDeliverTx(t *testing.T) {
 
 	// make sure we can query these values
 	query := abci.RequestQuery{
-		Path: "/main/key",
+		Path: "/store/main/key",
 		Data: []byte(key),
 	}
 	qres := app.Query(query)
@@ -5,7 +5,11 @@ import (
 	"os"
 
 	abci "github.com/tendermint/abci/types"
+	dbm "github.com/tendermint/tmlibs/db"
 	"github.com/tendermint/tmlibs/log"
+
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
 // SetupApp returns an application as well as a clean-up function
@@ -25,3 +29,18 @@ func SetupApp() (abci.Application, func(), error) {
 	app, err := NewApp(rootDir, logger)
 	return app, cleanup, err
 }
+
+// SetupMultiStore returns a multistore in memory with an IAVL store
+// mounted for each key, to test the keepers of modules
+func SetupMultiStore(keys ...sdk.StoreKey) sdk.CommitMultiStore {
+	db := dbm.NewMemDB()
+	ms := store.NewCommitMultiStore(db)
+	for _, key := range keys {
+		ms.MountStoreWithDB(key, sdk.StoreTypeIAVL, db)
+	}
+	err := ms.LoadLatestVersion()
+	if err != nil {
+		panic(err)
+	}
+	return ms
+}
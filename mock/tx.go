@@ -43,8 +43,8 @@ func (tx kvstoreTx) Type() string {
 	return "kvstore"
 }
 
-func (tx kvstoreTx) GetMsg() sdk.Msg {
-	return tx
+func (tx kvstoreTx) GetMsgs() []sdk.Msg {
+	return []sdk.Msg{tx}
 }
 
 func (tx kvstoreTx) GetSignBytes() []byte {
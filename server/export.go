@@ -0,0 +1,85 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	tmtypes "github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tmlibs/log"
+)
+
+const (
+	flagHeight = "height"
+	flagOutput = "output"
+)
+
+// AppExporter loads the app from the home dir
+// and exports its state committed at height
+// (the latest state if height is 0) as genesis app_state,
+// with the validator set of the next height
+type AppExporter func(home string, logger log.Logger, height int64) (json.RawMessage, []tmtypes.GenesisValidator, error)
+
+// ExportCmd dumps the app state at a height into a genesis file,
+// which can be used to restart the chain from that state
+func ExportCmd(app AppExporter, ctx *Context) *cobra.Command {
+	export := exportCmd{
+		appExporter: app,
+		context:     ctx,
+	}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export state to a genesis file",
+		RunE:  export.run,
+	}
+	cmd.Flags().Int64(flagHeight, 0, "Height to export the state at, 0 for the latest height")
+	cmd.Flags().String(flagOutput, "", "File to write the genesis to, defaults to stdout")
+	return cmd
+}
+
+type exportCmd struct {
+	appExporter AppExporter
+	context     *Context
+}
+
+func (e exportCmd) run(cmd *cobra.Command, args []string) error {
+	home := viper.GetString("home")
+	height := viper.GetInt64(flagHeight)
+	appState, validators, err := e.appExporter(home, e.context.Logger, height)
+	if err != nil {
+		return err
+	}
+
+	// keep the chain of the current genesis
+	genFile := e.context.Config.GenesisFile()
+	out, err := exportedGenesis(genFile, appState, validators)
+	if err != nil {
+		return err
+	}
+
+	output := viper.GetString(flagOutput)
+	if output == "" {
+		fmt.Println(string(out))
+		return nil
+	}
+	return ioutil.WriteFile(output, out, 0600)
+}
+
+// exportedGenesis returns the genesis doc in filename
+// with its app_state and validators replaced
+func exportedGenesis(filename string, appState json.RawMessage, validators []tmtypes.GenesisValidator) ([]byte, error) {
+	doc, err := readGenesisDoc(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	doc["validators"], err = json.Marshal(validators)
+	if err != nil {
+		return nil, err
+	}
+	doc["app_state"] = appState
+	return json.MarshalIndent(doc, "", "  ")
+}
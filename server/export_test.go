@@ -0,0 +1,57 @@
+package server
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	crypto "github.com/tendermint/go-crypto"
+	tcmd "github.com/tendermint/tendermint/cmd/tendermint/commands"
+	tmtypes "github.com/tendermint/tendermint/types"
+	"github.com/tendermint/tmlibs/log"
+
+	"github.com/cosmos/cosmos-sdk/mock"
+)
+
+func TestExport(t *testing.T) {
+	defer setupViper(t)()
+
+	logger := log.NewNopLogger()
+	cfg, err := tcmd.ParseConfig()
+	require.Nil(t, err)
+	ctx := NewContext(cfg, logger)
+	err = InitCmd(mock.GenInitOptions, ctx).RunE(nil, nil)
+	require.NoError(t, err)
+	genDoc, err := tmtypes.GenesisDocFromFile(cfg.GenesisFile())
+	require.Nil(t, err)
+
+	var exportedHeight int64
+	appState := json.RawMessage(`{"key":"value"}`)
+	validators := []tmtypes.GenesisValidator{{
+		PubKey: crypto.GenPrivKeyEd25519().PubKey(),
+		Power:  10,
+	}}
+	exporter := func(home string, logger log.Logger, height int64) (json.RawMessage, []tmtypes.GenesisValidator, error) {
+		exportedHeight = height
+		return appState, validators, nil
+	}
+
+	output := filepath.Join(viper.GetString("home"), "exported.json")
+	viper.Set(flagHeight, 3)
+	viper.Set(flagOutput, output)
+	defer viper.Set(flagHeight, 0)
+	defer viper.Set(flagOutput, "")
+	err = ExportCmd(exporter, ctx).RunE(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), exportedHeight)
+
+	// the exported genesis keeps the chain, with the exported validators
+	exported, err := tmtypes.GenesisDocFromFile(output)
+	require.Nil(t, err)
+	require.Equal(t, genDoc.ChainID, exported.ChainID)
+	require.Equal(t, validators, exported.Validators)
+	require.JSONEq(t, string(appState), string(exported.AppState()))
+}
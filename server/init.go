@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
 	"github.com/spf13/cobra"
 
 	"github.com/tendermint/go-crypto/keys"
@@ -179,6 +180,34 @@ func DefaultGenAppState(args []string, addr sdk.Address, coinDenom string) (json
 	return json.RawMessage(opts), nil
 }
 
+// VestingGenAppState returns a GenAppState which, like DefaultGenAppState,
+// gives lots of coins to the address, in a vesting account which unlocks
+// them on the schedule of vesting.
+func VestingGenAppState(vesting auth.GenesisVesting) GenAppState {
+	return func(args []string, addr sdk.Address, coinDenom string) (json.RawMessage, error) {
+		if _, err := vesting.ToVestingAccount(auth.BaseAccount{}); err != nil {
+			return nil, err
+		}
+		bz, err := json.MarshalIndent(vesting, "        ", "  ")
+		if err != nil {
+			return nil, err
+		}
+		opts := fmt.Sprintf(`{
+      "accounts": [{
+        "address": "%s",
+        "coins": [
+          {
+            "denom": "%s",
+            "amount": 9007199254740992
+          }
+        ],
+        "vesting": %s
+      }]
+    }`, addr.String(), coinDenom, bz)
+		return json.RawMessage(opts), nil
+	}
+}
+
 //-------------------------------------------------------------------
 
 // GenesisDoc involves some tendermint-specific structures we don't
@@ -187,24 +216,35 @@ func DefaultGenAppState(args []string, addr sdk.Address, coinDenom string) (json
 type GenesisDoc map[string]json.RawMessage
 
 func addGenesisState(filename string, appState json.RawMessage) error {
-	bz, err := ioutil.ReadFile(filename)
+	out, err := genesisWithAppState(filename, appState)
 	if err != nil {
 		return err
 	}
 
-	var doc GenesisDoc
-	err = json.Unmarshal(bz, &doc)
+	return ioutil.WriteFile(filename, out, 0600)
+}
+
+// genesisWithAppState returns the genesis doc in filename
+// with its app_state replaced by appState
+func genesisWithAppState(filename string, appState json.RawMessage) ([]byte, error) {
+	doc, err := readGenesisDoc(filename)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	doc["app_state"] = appState
-	out, err := json.MarshalIndent(doc, "", "  ")
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func readGenesisDoc(filename string) (GenesisDoc, error) {
+	bz, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return ioutil.WriteFile(filename, out, 0600)
+	var doc GenesisDoc
+	err = json.Unmarshal(bz, &doc)
+	return doc, err
 }
 
 //-------------------------------------------------------------------
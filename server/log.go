@@ -0,0 +1,85 @@
+package server
+
+import (
+	"sync"
+
+	cfg "github.com/tendermint/tendermint/config"
+	tmflags "github.com/tendermint/tmlibs/cli/flags"
+	"github.com/tendermint/tmlibs/log"
+)
+
+// levelLogger is a log.Logger whose level filter, in the format of
+// the log_level config, can be changed with SetLevel while running.
+// Loggers derived from it with With follow the changes.
+type levelLogger struct {
+	filter  *levelFilter
+	keyvals []interface{}
+}
+
+var _ log.Logger = (*levelLogger)(nil)
+
+// levelFilter is shared by a levelLogger and the loggers derived from it
+type levelFilter struct {
+	mtx    sync.RWMutex
+	base   log.Logger
+	logger log.Logger // base, filtered at the current level
+}
+
+func newLevelLogger(base log.Logger, lvl string) (*levelLogger, error) {
+	filter := &levelFilter{base: base}
+	err := filter.setLevel(lvl)
+	if err != nil {
+		return nil, err
+	}
+	return &levelLogger{filter: filter}, nil
+}
+
+func (f *levelFilter) setLevel(lvl string) error {
+	logger, err := tmflags.ParseLogLevel(lvl, f.base, cfg.DefaultLogLevel())
+	if err != nil {
+		return err
+	}
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.logger = logger
+	return nil
+}
+
+// SetLevel changes the level filter of the logger and all loggers
+// sharing its filter, eg. "main:info,state:info,*:error".
+func (l *levelLogger) SetLevel(lvl string) error {
+	return l.filter.setLevel(lvl)
+}
+
+func (l *levelLogger) current() log.Logger {
+	l.filter.mtx.RLock()
+	logger := l.filter.logger
+	l.filter.mtx.RUnlock()
+	if len(l.keyvals) == 0 {
+		return logger
+	}
+	return logger.With(l.keyvals...)
+}
+
+// Implements log.Logger.
+func (l *levelLogger) Debug(msg string, keyvals ...interface{}) {
+	l.current().Debug(msg, keyvals...)
+}
+
+// Implements log.Logger.
+func (l *levelLogger) Info(msg string, keyvals ...interface{}) {
+	l.current().Info(msg, keyvals...)
+}
+
+// Implements log.Logger.
+func (l *levelLogger) Error(msg string, keyvals ...interface{}) {
+	l.current().Error(msg, keyvals...)
+}
+
+// Implements log.Logger.
+func (l *levelLogger) With(keyvals ...interface{}) log.Logger {
+	merged := make([]interface{}, 0, len(l.keyvals)+len(keyvals))
+	merged = append(merged, l.keyvals...)
+	merged = append(merged, keyvals...)
+	return &levelLogger{filter: l.filter, keyvals: merged}
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/tmlibs/log"
+)
+
+func TestLevelLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := newLevelLogger(log.NewTMLogger(&buf), "*:error")
+	require.Nil(t, err)
+	derived := logger.With("module", "app")
+
+	derived.Info("hidden")
+	require.Empty(t, buf.String())
+
+	// derived loggers follow the new level
+	err = logger.SetLevel("app:info,*:error")
+	require.Nil(t, err)
+	derived.Info("shown")
+	require.Contains(t, buf.String(), "shown")
+	require.Contains(t, buf.String(), "module=app")
+
+	buf.Reset()
+	logger.Info("hidden")
+	require.Empty(t, buf.String())
+
+	// bad levels leave the level unchanged
+	err = logger.SetLevel("app:loud")
+	require.NotNil(t, err)
+	derived.Info("still shown")
+	require.Contains(t, buf.String(), "still shown")
+}
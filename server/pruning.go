@@ -0,0 +1,56 @@
+package server
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	flagPruning           = "pruning"
+	flagPruningKeepRecent = "pruning_keep_recent"
+	flagPruningKeepEvery  = "pruning_keep_every"
+
+	// pruningCustom is the pruning strategy set by the keep flags
+	pruningCustom = "custom"
+)
+
+// addPruningFlags adds the flags of the pruning strategy,
+// which may also be set in config.toml
+func addPruningFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagPruning, sdk.PruningNothing,
+		"Pruning strategy of the stores: nothing, everything, syncable or custom")
+	cmd.Flags().Int64(flagPruningKeepRecent, 0,
+		"With custom pruning, the number of recent versions to keep (0 to keep all)")
+	cmd.Flags().Int64(flagPruningKeepEvery, 0,
+		"With custom pruning, also keep every version which is a multiple of this (0 for none)")
+}
+
+// GetPruningStrategy returns the pruning strategy set by the flags of the
+// start command, or by the pruning entries of config.toml. By default,
+// nothing is pruned. App creators pass it to their stores, eg. with
+// baseapp.SetPruning.
+func GetPruningStrategy() (sdk.PruningStrategy, error) {
+	name := viper.GetString(flagPruning)
+	switch name {
+	case "":
+		return sdk.PruneNothing, nil
+	case pruningCustom:
+		pruning := sdk.NewPruningStrategy(
+			viper.GetInt64(flagPruningKeepRecent),
+			viper.GetInt64(flagPruningKeepEvery),
+		)
+		if err := pruning.ValidateBasic(); err != nil {
+			return pruning, errors.Errorf("Invalid %s: %v", flagPruning, err)
+		}
+		return pruning, nil
+	default:
+		pruning, err := sdk.ParsePruningStrategy(name)
+		if err != nil {
+			return pruning, errors.Errorf("Invalid %s: %v", flagPruning, err)
+		}
+		return pruning, nil
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestGetPruningStrategy(t *testing.T) {
+	defer func() {
+		viper.Set(flagPruning, "")
+		viper.Set(flagPruningKeepRecent, 0)
+		viper.Set(flagPruningKeepEvery, 0)
+	}()
+
+	cases := []struct {
+		name                  string
+		keepRecent, keepEvery int64
+		expected              sdk.PruningStrategy
+		ok                    bool
+	}{
+		{"", 0, 0, sdk.PruneNothing, true},
+		{"nothing", 0, 0, sdk.PruneNothing, true},
+		{"everything", 0, 0, sdk.PruneEverything, true},
+		{"syncable", 0, 0, sdk.PruneSyncable, true},
+		{"custom", 10, 100, sdk.NewPruningStrategy(10, 100), true},
+		{"custom", -1, 0, sdk.PruningStrategy{}, false},
+		{"sometimes", 0, 0, sdk.PruningStrategy{}, false},
+	}
+	for _, tc := range cases {
+		viper.Set(flagPruning, tc.name)
+		viper.Set(flagPruningKeepRecent, tc.keepRecent)
+		viper.Set(flagPruningKeepEvery, tc.keepEvery)
+		pruning, err := GetPruningStrategy()
+		if !tc.ok {
+			assert.NotNil(t, err, tc.name)
+			continue
+		}
+		require.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, pruning, tc.name)
+	}
+}
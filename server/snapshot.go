@@ -0,0 +1,165 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/cosmos/cosmos-sdk/store"
+)
+
+const (
+	flagSnapshotDir      = "snapshot_dir"
+	flagSnapshotInterval = "snapshot_interval"
+)
+
+// snapshotApp is implemented by apps which can snapshot
+// their stores and restore from them, eg. BaseApp
+type snapshotApp interface {
+	SetSnapshots(dir string, interval int64)
+	CreateSnapshot(dir string, height int64) (store.Snapshot, error)
+	RestoreSnapshot(dir string, height int64) (store.Snapshot, error)
+}
+
+// SnapshotCmd manages the snapshots of the state of the app,
+// from which a node can resume at their height.
+// It works offline: the node must be stopped.
+func SnapshotCmd(appCreator AppCreator, ctx *Context) *cobra.Command {
+	snapshot := snapshotCmd{
+		appCreator: appCreator,
+		context:    ctx,
+	}
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Create, list and restore snapshots of the app state",
+	}
+	cmd.PersistentFlags().String(flagSnapshotDir, "", "Directory of the snapshots, defaults to <home>/data/snapshots")
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Snapshot the app state at a height",
+		Args:  cobra.NoArgs,
+		RunE:  snapshot.create,
+	}
+	createCmd.Flags().Int64(flagHeight, 0, "Height to snapshot the state at, 0 for the latest height")
+
+	cmd.AddCommand(
+		createCmd,
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the snapshots",
+			Args:  cobra.NoArgs,
+			RunE:  snapshot.list,
+		},
+		&cobra.Command{
+			Use:   "restore <height>",
+			Short: "Restore the app state of an empty node from the snapshot at a height",
+			Long: `Restore the app state of an empty node from the snapshot at a height.
+Only the state of the app is restored: Tendermint must be synced to the
+same height separately before the node is started.`,
+			Args: cobra.ExactArgs(1),
+			RunE: snapshot.restore,
+		},
+	)
+	return cmd
+}
+
+type snapshotCmd struct {
+	appCreator AppCreator
+	context    *Context
+}
+
+func (s snapshotCmd) create(cmd *cobra.Command, args []string) error {
+	app, err := s.loadApp()
+	if err != nil {
+		return err
+	}
+	snapshot, err := app.CreateSnapshot(snapshotDir(), viper.GetInt64(flagHeight))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created snapshot of height %d with %d chunks, hash %X\n",
+		snapshot.Version(), len(snapshot.ChunkHashes), snapshot.CommitID.Hash)
+	return nil
+}
+
+func (s snapshotCmd) list(cmd *cobra.Command, args []string) error {
+	snapshots, err := store.ListSnapshots(snapshotDir())
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		fmt.Printf("height %d\tchunks %d\thash %X\n",
+			snapshot.Version(), len(snapshot.ChunkHashes), snapshot.CommitID.Hash)
+	}
+	return nil
+}
+
+func (s snapshotCmd) restore(cmd *cobra.Command, args []string) error {
+	height, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || height <= 0 {
+		return errors.Errorf("Invalid height %s", args[0])
+	}
+	app, err := s.loadApp()
+	if err != nil {
+		return err
+	}
+	snapshot, err := app.RestoreSnapshot(snapshotDir(), height)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored snapshot of height %d, hash %X\n",
+		snapshot.Version(), snapshot.CommitID.Hash)
+	return nil
+}
+
+func (s snapshotCmd) loadApp() (snapshotApp, error) {
+	app, err := s.appCreator(viper.GetString("home"), s.context.Logger)
+	if err != nil {
+		return nil, err
+	}
+	sapp, ok := app.(snapshotApp)
+	if !ok {
+		return nil, errors.New("The app doesn't support snapshots")
+	}
+	return sapp, nil
+}
+
+// snapshotDir is the directory of the snapshots of the node,
+// from the flag or in the data directory of the node
+func snapshotDir() string {
+	dir := viper.GetString(flagSnapshotDir)
+	if dir == "" {
+		dir = filepath.Join(viper.GetString("home"), "data", "snapshots")
+	}
+	return dir
+}
+
+// addSnapshotFlags adds the flags of the periodic snapshots to the start command
+func addSnapshotFlags(cmd *cobra.Command) {
+	cmd.Flags().String(flagSnapshotDir, "", "Directory of the snapshots, defaults to <home>/data/snapshots")
+	cmd.Flags().Int64(flagSnapshotInterval, 0, "Snapshot the app state every this many blocks, 0 to disable")
+}
+
+// setSnapshots enables the periodic snapshots of the app from the flags
+func setSnapshots(app abci.Application) error {
+	interval := viper.GetInt64(flagSnapshotInterval)
+	if interval < 0 {
+		return errors.Errorf("Invalid %s: %d", flagSnapshotInterval, interval)
+	}
+	sapp, ok := app.(snapshotApp)
+	if !ok {
+		if interval > 0 {
+			return errors.New("The app doesn't support snapshots")
+		}
+		return nil
+	}
+	sapp.SetSnapshots(snapshotDir(), interval)
+	return nil
+}
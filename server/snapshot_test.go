@@ -0,0 +1,72 @@
+package server
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	abci "github.com/tendermint/abci/types"
+	dbm "github.com/tendermint/tmlibs/db"
+	"github.com/tendermint/tmlibs/log"
+
+	bam "github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestSnapshotCmd(t *testing.T) {
+	defer setupViper(t)()
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	viper.Set(flagSnapshotDir, dir)
+	defer viper.Set(flagSnapshotDir, "")
+
+	// an app on in-memory dbs per home
+	key := sdk.NewKVStoreKey("main")
+	dbs := make(map[string][2]dbm.DB)
+	appCreator := func(home string, logger log.Logger) (abci.Application, error) {
+		if _, ok := dbs[home]; !ok {
+			dbs[home] = [2]dbm.DB{dbm.NewMemDB(), dbm.NewMemDB()}
+		}
+		app := bam.NewBaseApp("test", logger, dbs[home][0])
+		app.MountStoreWithDB(key, sdk.StoreTypeIAVL, dbs[home][1])
+		return app, app.LoadLatestVersion(key)
+	}
+
+	logger := log.NewNopLogger()
+	home := viper.GetString("home")
+	app, err := appCreator(home, logger)
+	require.Nil(t, err)
+	for height := int64(1); height <= 2; height++ {
+		app.BeginBlock(abci.RequestBeginBlock{Header: abci.Header{Height: height}})
+		app.Commit()
+	}
+	commitID := app.(*bam.BaseApp).LastCommitID()
+
+	cmd := SnapshotCmd(appCreator, NewContext(nil, logger))
+	create, _, err := cmd.Find([]string{"create"})
+	require.Nil(t, err)
+	err = create.RunE(create, nil)
+	require.Nil(t, err)
+	snapshots, err := store.ListSnapshots(dir)
+	require.Nil(t, err)
+	require.Equal(t, 1, len(snapshots))
+	require.Equal(t, commitID, snapshots[0].CommitID)
+
+	// restore on a new node
+	viper.Set("home", home+"-restored")
+	defer viper.Set("home", home)
+	restore, _, err := cmd.Find([]string{"restore"})
+	require.Nil(t, err)
+	err = restore.RunE(restore, []string{"1"})
+	require.NotNil(t, err)
+	err = restore.RunE(restore, []string{"2"})
+	require.Nil(t, err)
+	app, err = appCreator(home+"-restored", logger)
+	require.Nil(t, err)
+	require.Equal(t, commitID, app.(*bam.BaseApp).LastCommitID())
+}
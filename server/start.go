@@ -14,17 +14,42 @@ import (
 	"github.com/tendermint/tendermint/types"
 	cmn "github.com/tendermint/tmlibs/common"
 	"github.com/tendermint/tmlibs/log"
+
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
 const (
-	flagWithTendermint = "with-tendermint"
-	flagAddress        = "address"
+	flagWithTendermint   = "with-tendermint"
+	flagAddress          = "address"
+	flagMinimumGasPrices = "minimum_gas_prices"
+	flagStateSinkFile    = "state_sink_file"
+	flagSetOptionQueries = "unsafe_set_option_queries"
 )
 
 // AppCreator lets us lazily initialize app, using home dir
 // and other flags (?) to start
 type AppCreator func(string, log.Logger) (abci.Application, error)
 
+// optionApp is implemented by apps with options
+// that can be set at runtime, eg. BaseApp
+type optionApp interface {
+	RegisterOption(key string, handler sdk.OptionHandler)
+	SetOptionQueries(enabled bool)
+}
+
+// gasPriceApp is implemented by apps which filter
+// CheckTx by minimum gas prices, eg. BaseApp
+type gasPriceApp interface {
+	SetMinimumGasPrices(prices sdk.Coins)
+}
+
+// stateSinkApp is implemented by apps which report
+// the state changes of each block, eg. BaseApp
+type stateSinkApp interface {
+	SetStateSink(sink store.StateSink)
+}
+
 // StartCmd runs the service passed in, either
 // stand-alone, or in-process with tendermint
 func StartCmd(app AppCreator, ctx *Context) *cobra.Command {
@@ -40,6 +65,14 @@ func StartCmd(app AppCreator, ctx *Context) *cobra.Command {
 	// basic flags for abci app
 	cmd.Flags().Bool(flagWithTendermint, true, "run abci app embedded in-process with tendermint")
 	cmd.Flags().String(flagAddress, "tcp://0.0.0.0:46658", "Listen address")
+	cmd.Flags().String(flagMinimumGasPrices, "",
+		"Minimum price per unit of gas of txs accepted into the mempool, eg. 1steak,2fermion (any one denom suffices)")
+	cmd.Flags().String(flagStateSinkFile, "",
+		"File to append the state changes of each block to, as lines of JSON")
+	cmd.Flags().Bool(flagSetOptionQueries, false,
+		"UNSAFE: let anyone who can query the node over RPC change its options, eg. minimum_gas_prices and log_level")
+	addPruningFlags(cmd)
+	addSnapshotFlags(cmd)
 
 	// AddNodeFlags adds support for all
 	// tendermint-specific command line options
@@ -69,6 +102,19 @@ func (s startCmd) startStandAlone() error {
 	if err != nil {
 		return err
 	}
+	err = s.setMinimumGasPrices(app)
+	if err != nil {
+		return err
+	}
+	err = setSnapshots(app)
+	if err != nil {
+		return err
+	}
+	err = setStateSink(app)
+	if err != nil {
+		return err
+	}
+	s.registerOptions(app)
 
 	svr, err := server.NewServer(addr, "socket", app)
 	if err != nil {
@@ -92,6 +138,19 @@ func (s startCmd) startInProcess() error {
 	if err != nil {
 		return err
 	}
+	err = s.setMinimumGasPrices(app)
+	if err != nil {
+		return err
+	}
+	err = setSnapshots(app)
+	if err != nil {
+		return err
+	}
+	err = setStateSink(app)
+	if err != nil {
+		return err
+	}
+	s.registerOptions(app)
 
 	// Create & start tendermint node
 	n, err := node.NewNode(cfg,
@@ -113,3 +172,55 @@ func (s startCmd) startInProcess() error {
 	n.RunForever()
 	return nil
 }
+
+// registerOptions registers the options of the server on the app,
+// and lets clients set options through RPC queries if the flag enables it.
+// abci_query is a public RPC route, so the flag is off by default.
+func (s startCmd) registerOptions(app abci.Application) {
+	oapp, ok := app.(optionApp)
+	if !ok {
+		return
+	}
+	if logger, ok := s.context.Logger.(*levelLogger); ok {
+		oapp.RegisterOption("log_level", logger.SetLevel)
+	}
+	oapp.SetOptionQueries(viper.GetBool(flagSetOptionQueries))
+}
+
+// setMinimumGasPrices sets the minimum gas prices from the flag,
+// or from the minimum_gas_prices entry of config.toml, on the app,
+// and registers the option to change them at runtime
+func (s startCmd) setMinimumGasPrices(app abci.Application) error {
+	gapp, ok := app.(gasPriceApp)
+	if !ok {
+		return nil
+	}
+	prices, err := sdk.ParseCoins(viper.GetString(flagMinimumGasPrices))
+	if err != nil {
+		return errors.Errorf("Invalid %s: %v", flagMinimumGasPrices, err)
+	}
+	gapp.SetMinimumGasPrices(prices)
+	if oapp, ok := app.(optionApp); ok {
+		oapp.RegisterOption(flagMinimumGasPrices, sdk.CoinsOption(nil, gapp.SetMinimumGasPrices))
+	}
+	return nil
+}
+
+// setStateSink makes the app append its state changes to the file of
+// the flag, if any
+func setStateSink(app abci.Application) error {
+	path := viper.GetString(flagStateSinkFile)
+	if path == "" {
+		return nil
+	}
+	sapp, ok := app.(stateSinkApp)
+	if !ok {
+		return errors.New("The app doesn't report its state changes")
+	}
+	sink, err := store.NewFileStateSink(path)
+	if err != nil {
+		return err
+	}
+	sapp.SetStateSink(sink)
+	return nil
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/cosmos/cosmos-sdk/mock"
+	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/tendermint/abci/server"
+	abci "github.com/tendermint/abci/types"
 	tcmd "github.com/tendermint/tendermint/cmd/tendermint/commands"
 	"github.com/tendermint/tmlibs/log"
 )
@@ -63,3 +65,59 @@ func TestStartWithTendermint(t *testing.T) {
 
 	close(RunOrTimeout(startCmd, timeout, t))
 }
+
+type gasPriceTestApp struct {
+	abci.BaseApplication
+
+	options       map[string]sdk.OptionHandler
+	prices        sdk.Coins
+	optionQueries bool
+}
+
+func (app *gasPriceTestApp) RegisterOption(key string, handler sdk.OptionHandler) {
+	app.options[key] = handler
+}
+func (app *gasPriceTestApp) SetOptionQueries(enabled bool) {
+	app.optionQueries = enabled
+}
+func (app *gasPriceTestApp) SetMinimumGasPrices(prices sdk.Coins) {
+	app.prices = prices
+}
+
+func TestStartMinimumGasPrices(t *testing.T) {
+	defer viper.Set(flagMinimumGasPrices, "")
+
+	start := startCmd{context: NewDefaultContext()}
+	app := &gasPriceTestApp{options: make(map[string]sdk.OptionHandler)}
+
+	viper.Set(flagMinimumGasPrices, "2fermion,1steak")
+	err := start.setMinimumGasPrices(app)
+	require.Nil(t, err)
+	require.Equal(t, sdk.Coins{{"fermion", 2}, {"steak", 1}}, app.prices)
+
+	// the prices can be changed with the option
+	handler, ok := app.options[flagMinimumGasPrices]
+	require.True(t, ok)
+	require.Nil(t, handler("5steak"))
+	require.Equal(t, sdk.Coins{{"steak", 5}}, app.prices)
+
+	viper.Set(flagMinimumGasPrices, "steak")
+	err = start.setMinimumGasPrices(app)
+	require.NotNil(t, err)
+}
+
+func TestStartSetOptionQueries(t *testing.T) {
+	defer viper.Set(flagSetOptionQueries, false)
+
+	start := startCmd{context: NewDefaultContext()}
+	app := &gasPriceTestApp{options: make(map[string]sdk.OptionHandler)}
+
+	// off by default, even with unsafe RPC
+	start.context.Config.RPC.Unsafe = true
+	start.registerOptions(app)
+	require.False(t, app.optionQueries)
+
+	viper.Set(flagSetOptionQueries, true)
+	start.registerOptions(app)
+	require.True(t, app.optionQueries)
+}
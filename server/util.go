@@ -10,7 +10,6 @@ import (
 	tcmd "github.com/tendermint/tendermint/cmd/tendermint/commands"
 	cfg "github.com/tendermint/tendermint/config"
 	"github.com/tendermint/tmlibs/cli"
-	tmflags "github.com/tendermint/tmlibs/cli/flags"
 	"github.com/tendermint/tmlibs/log"
 )
 
@@ -44,17 +43,17 @@ func PersistentPreRunEFn(context *Context) func(*cobra.Command, []string) error
 		if err != nil {
 			return err
 		}
-		logger := log.NewTMLogger(log.NewSyncWriter(os.Stdout))
-		logger, err = tmflags.ParseLogLevel(config.LogLevel, logger, cfg.DefaultLogLevel())
-		if err != nil {
-			return err
-		}
+		var logger log.Logger = log.NewTMLogger(log.NewSyncWriter(os.Stdout))
 		if viper.GetBool(cli.TraceFlag) {
 			logger = log.NewTracingLogger(logger)
 		}
-		logger = logger.With("module", "main")
+		// the log_level option can change the level of a running node
+		levelLogger, err := newLevelLogger(logger, config.LogLevel)
+		if err != nil {
+			return err
+		}
 		context.Config = config
-		context.Logger = logger
+		context.Logger = levelLogger.With("module", "main")
 		return nil
 	}
 }
@@ -62,13 +61,15 @@ func PersistentPreRunEFn(context *Context) func(*cobra.Command, []string) error
 func AddCommands(
 	rootCmd *cobra.Command,
 	appState GenAppState, appCreator AppCreator,
-	context *Context) {
+	appExporter AppExporter, context *Context) {
 
 	rootCmd.PersistentFlags().String("log_level", context.Config.LogLevel, "Log level")
 
 	rootCmd.AddCommand(
 		InitCmd(appState, context),
 		StartCmd(appCreator, context),
+		ExportCmd(appExporter, context),
+		SnapshotCmd(appCreator, context),
 		UnsafeResetAllCmd(context),
 		ShowNodeIDCmd(context),
 		ShowValidatorCmd(context),
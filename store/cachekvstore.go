@@ -1,11 +1,7 @@
 package store
 
 import (
-	"bytes"
-	"sort"
 	"sync"
-
-	cmn "github.com/tendermint/tmlibs/common"
 )
 
 // If value is nil but deleted is false, it means the parent doesn't have the
@@ -17,10 +13,17 @@ type cValue struct {
 }
 
 // cacheKVStore wraps an in-memory cache around an underlying KVStore.
+// The dirty keys are also kept sorted, to iterate over them in order
+// without sorting the cache each time.
 type cacheKVStore struct {
 	mtx    sync.Mutex
 	cache  map[string]cValue
+	dirty  *skipList // the dirty keys, with nil values for the deleted ones
 	parent KVStore
+
+	// notified of the writes to parent, may be nil
+	listener  WriteListener
+	storeName string
 }
 
 var _ CacheKVStore = (*cacheKVStore)(nil)
@@ -29,6 +32,7 @@ func NewCacheKVStore(parent KVStore) *cacheKVStore {
 
 	ci := &cacheKVStore{
 		cache:  make(map[string]cValue),
+		dirty:  newSkipList(),
 		parent: parent,
 	}
 
@@ -86,31 +90,36 @@ func (ci *cacheKVStore) Write() {
 	ci.mtx.Lock()
 	defer ci.mtx.Unlock()
 
-	// We need a copy of all of the keys.
-	// Not the best, but probably not a bottleneck depending.
-	keys := make([]string, 0, len(ci.cache))
-	for key, dbValue := range ci.cache {
-		if dbValue.dirty {
-			keys = append(keys, key)
-		}
-	}
-	sort.Strings(keys)
-
 	// TODO: Consider allowing usage of Batch, which would allow the write to
 	// at least happen atomically.
-	for _, key := range keys {
-		cacheValue := ci.cache[key]
+	for node := ci.dirty.first(); node != nil; node = node.next[0] {
+		key := node.key
+		cacheValue := ci.cache[string(key)]
 		if cacheValue.deleted {
-			ci.parent.Delete([]byte(key))
+			ci.parent.Delete(key)
 		} else if cacheValue.value == nil {
 			// Skip, it already doesn't exist in parent.
+			continue
 		} else {
-			ci.parent.Set([]byte(key), cacheValue.value)
+			ci.parent.Set(key, cacheValue.value)
+		}
+		if ci.listener != nil {
+			ci.listener.OnWrite(ci.storeName, key, cacheValue.value, cacheValue.deleted)
 		}
 	}
 
 	// Clear the cache
 	ci.cache = make(map[string]cValue)
+	ci.dirty = newSkipList()
+}
+
+// SetWriteListener sets the listener notified of the writes to the
+// parent store on Write, as the writes of the store named storeName.
+func (ci *cacheKVStore) SetWriteListener(storeName string, listener WriteListener) {
+	ci.mtx.Lock()
+	defer ci.mtx.Unlock()
+	ci.storeName = storeName
+	ci.listener = listener
 }
 
 //----------------------------------------
@@ -124,6 +133,11 @@ func (ci *cacheKVStore) CacheWrap() CacheWrap {
 //----------------------------------------
 // Iteration
 
+// Implements KVStore.
+func (ci *cacheKVStore) Prefix(prefix []byte) KVStore {
+	return NewPrefixStore(ci, prefix)
+}
+
 // Implements KVStore.
 func (ci *cacheKVStore) Iterator(start, end []byte) Iterator {
 	return ci.iterator(start, end, true)
@@ -141,31 +155,10 @@ func (ci *cacheKVStore) iterator(start, end []byte, ascending bool) Iterator {
 	} else {
 		parent = ci.parent.ReverseIterator(start, end)
 	}
-	items := ci.dirtyItems(ascending)
-	cache = newMemIterator(start, end, items)
+	cache = newSkipListIterator(ci.dirty, start, end, ascending)
 	return newCacheMergeIterator(parent, cache, ascending)
 }
 
-// Constructs a slice of dirty items, to use w/ memIterator.
-func (ci *cacheKVStore) dirtyItems(ascending bool) []cmn.KVPair {
-	items := make([]cmn.KVPair, 0, len(ci.cache))
-	for key, cacheValue := range ci.cache {
-		if !cacheValue.dirty {
-			continue
-		}
-		items = append(items,
-			cmn.KVPair{[]byte(key), cacheValue.value})
-	}
-	sort.Slice(items, func(i, j int) bool {
-		if ascending {
-			return bytes.Compare(items[i].Key, items[j].Key) < 0
-		} else {
-			return bytes.Compare(items[i].Key, items[j].Key) > 0
-		}
-	})
-	return items
-}
-
 //----------------------------------------
 // etc
 
@@ -183,4 +176,7 @@ func (ci *cacheKVStore) setCacheValue(key, value []byte, deleted bool, dirty boo
 		dirty:   dirty,
 	}
 	ci.cache[string(key)] = cacheValue
+	if dirty {
+		ci.dirty.set(key, value)
+	}
 }
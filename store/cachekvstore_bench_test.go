@@ -0,0 +1,205 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+// sortingCacheKVStore is a cache which keeps its keys in a map only, as
+// cacheKVStore did before it kept its dirty keys sorted: each iterator
+// and each write copies and sorts all the dirty keys. It is the baseline
+// of the benchmarks.
+type sortingCacheKVStore struct {
+	mtx    sync.Mutex
+	cache  map[string]cValue
+	parent KVStore
+}
+
+func newSortingCacheKVStore(parent KVStore) *sortingCacheKVStore {
+	return &sortingCacheKVStore{
+		cache:  make(map[string]cValue),
+		parent: parent,
+	}
+}
+
+func (ci *sortingCacheKVStore) Get(key []byte) []byte {
+	ci.mtx.Lock()
+	defer ci.mtx.Unlock()
+	cacheValue, ok := ci.cache[string(key)]
+	if !ok {
+		value := ci.parent.Get(key)
+		ci.cache[string(key)] = cValue{value: value}
+		return value
+	}
+	return cacheValue.value
+}
+
+func (ci *sortingCacheKVStore) Set(key, value []byte) {
+	ci.mtx.Lock()
+	defer ci.mtx.Unlock()
+	ci.cache[string(key)] = cValue{value: value, dirty: true}
+}
+
+func (ci *sortingCacheKVStore) Write() {
+	ci.mtx.Lock()
+	defer ci.mtx.Unlock()
+	for _, item := range ci.dirtyItems(true) {
+		cacheValue := ci.cache[string(item.key)]
+		if cacheValue.deleted {
+			ci.parent.Delete(item.key)
+		} else if cacheValue.value != nil {
+			ci.parent.Set(item.key, cacheValue.value)
+		}
+	}
+	ci.cache = make(map[string]cValue)
+}
+
+func (ci *sortingCacheKVStore) Iterator(start, end []byte) Iterator {
+	return ci.iterator(start, end, true)
+}
+
+func (ci *sortingCacheKVStore) ReverseIterator(start, end []byte) Iterator {
+	return ci.iterator(start, end, false)
+}
+
+func (ci *sortingCacheKVStore) iterator(start, end []byte, ascending bool) Iterator {
+	var parent Iterator
+	if ascending {
+		parent = ci.parent.Iterator(start, end)
+	} else {
+		parent = ci.parent.ReverseIterator(start, end)
+	}
+	// link the items of the domain in order for a skipListIterator
+	first := &skipListNode{next: []*skipListNode{nil}}
+	node := first
+	for _, item := range ci.dirtyItems(ascending) {
+		if (start == nil || bytes.Compare(item.key, start) >= 0) &&
+			(end == nil || bytes.Compare(item.key, end) < 0) {
+			item.next = []*skipListNode{nil}
+			node.next[0] = item
+			node = item
+		}
+	}
+	cache := &skipListIterator{node: first.next[0], ascending: true}
+	return newCacheMergeIterator(parent, cache, ascending)
+}
+
+func (ci *sortingCacheKVStore) dirtyItems(ascending bool) []*skipListNode {
+	items := make([]*skipListNode, 0, len(ci.cache))
+	for key, cacheValue := range ci.cache {
+		if cacheValue.dirty {
+			items = append(items, &skipListNode{key: []byte(key), value: cacheValue.value})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if ascending {
+			return bytes.Compare(items[i].key, items[j].key) < 0
+		}
+		return bytes.Compare(items[i].key, items[j].key) > 0
+	})
+	return items
+}
+
+// benchCacheKVStore is implemented by cacheKVStore and the baseline.
+type benchCacheKVStore interface {
+	Get(key []byte) []byte
+	Set(key, value []byte)
+	Iterator(start, end []byte) Iterator
+	ReverseIterator(start, end []byte) Iterator
+	Write()
+}
+
+var benchCacheKVStores = []struct {
+	name string
+	new  func(parent KVStore) benchCacheKVStore
+}{
+	{"sorted", func(parent KVStore) benchCacheKVStore {
+		return NewCacheKVStore(parent)
+	}},
+	{"sorting", func(parent KVStore) benchCacheKVStore {
+		return newSortingCacheKVStore(parent)
+	}},
+}
+
+var benchCacheSizes = []int{100, 1000, 10000}
+
+// newBenchCacheKVStore returns a cache of about size dirty keys, over
+// the cache of a block like the caches of txs.
+func newBenchCacheKVStore(r *rand.Rand, newStore func(KVStore) benchCacheKVStore, size int) benchCacheKVStore {
+	block := NewCacheKVStore(dbStoreAdapter{dbm.NewMemDB()})
+	st := newStore(block)
+	for i := 0; i < size; i++ {
+		k := r.Intn(size * 10)
+		st.Set(keyFmt(k), valFmt(k))
+	}
+	return st
+}
+
+// Benchmark a workload of handlers which set and get keys, and read the
+// first few items of a domain, such as the top validators.
+func BenchmarkCacheKVStoreMixed(b *testing.B) {
+	for _, impl := range benchCacheKVStores {
+		for _, size := range benchCacheSizes {
+			b.Run(fmt.Sprintf("%s/%d", impl.name, size), func(b *testing.B) {
+				r := rand.New(rand.NewSource(1))
+				st := newBenchCacheKVStore(r, impl.new, size)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					k := r.Intn(size * 10)
+					st.Set(keyFmt(k), valFmt(k))
+					st.Get(keyFmt(r.Intn(size * 10)))
+					var iter Iterator
+					if i%2 == 0 {
+						iter = st.Iterator(keyFmt(r.Intn(size*10)), nil)
+					} else {
+						iter = st.ReverseIterator(nil, keyFmt(r.Intn(size*10)))
+					}
+					for j := 0; j < 10 && iter.Valid(); j++ {
+						iter.Next()
+					}
+					iter.Close()
+				}
+			})
+		}
+	}
+}
+
+// Benchmark setting new and existing keys.
+func BenchmarkCacheKVStoreSet(b *testing.B) {
+	for _, impl := range benchCacheKVStores {
+		for _, size := range benchCacheSizes {
+			b.Run(fmt.Sprintf("%s/%d", impl.name, size), func(b *testing.B) {
+				r := rand.New(rand.NewSource(1))
+				st := newBenchCacheKVStore(r, impl.new, size)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					k := r.Intn(size * 10)
+					st.Set(keyFmt(k), valFmt(k))
+				}
+			})
+		}
+	}
+}
+
+// Benchmark writing a cache to its parent.
+func BenchmarkCacheKVStoreWrite(b *testing.B) {
+	for _, impl := range benchCacheKVStores {
+		for _, size := range benchCacheSizes {
+			b.Run(fmt.Sprintf("%s/%d", impl.name, size), func(b *testing.B) {
+				r := rand.New(rand.NewSource(1))
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					st := newBenchCacheKVStore(r, impl.new, size)
+					b.StartTimer()
+					st.Write()
+				}
+			})
+		}
+	}
+}
@@ -294,6 +294,32 @@ func TestCacheKVMergeIteratorRandom(t *testing.T) {
 	}
 }
 
+// Test reverse iteration over random domains of a cache over a cache,
+// whose items are in the skip lists of both.
+func TestCacheKVReverseIteratorRandom(t *testing.T) {
+	st := NewCacheKVStore(newCacheKVStore())
+	truth := dbm.NewMemDB()
+
+	max := 100
+	setRange(st, truth, 25, 75)
+	for i := 0; i < 500; i++ {
+		doRandomOp(st, truth, max)
+
+		start, end := randInt(max), randInt(max)
+		var expected []cmn.KVPair
+		itr := truth.Iterator(keyFmt(start), keyFmt(end))
+		for ; itr.Valid(); itr.Next() {
+			expected = append([]cmn.KVPair{{itr.Key(), itr.Value()}}, expected...)
+		}
+		var actual []cmn.KVPair
+		itr = st.ReverseIterator(keyFmt(start), keyFmt(end))
+		for ; itr.Valid(); itr.Next() {
+			actual = append(actual, cmn.KVPair{itr.Key(), itr.Value()})
+		}
+		require.Equal(t, expected, actual, "[%d, %d)", start, end)
+	}
+}
+
 //-------------------------------------------------------------------------------------------
 // do some random ops
 
@@ -1,6 +1,8 @@
 package store
 
 import (
+	"sort"
+
 	sdk "github.com/cosmos/cosmos-sdk/types"
 )
 
@@ -46,13 +48,50 @@ func (cms cacheMultiStore) GetStoreType() StoreType {
 }
 
 // Implements CacheMultiStore.
+// The stores are written in the order of their names.
 func (cms cacheMultiStore) Write() {
 	cms.db.Write()
-	for _, store := range cms.stores {
-		store.Write()
+	keys := make([]StoreKey, 0, len(cms.stores))
+	for key := range cms.stores {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Name() < keys[j].Name()
+	})
+	for _, key := range keys {
+		cms.stores[key].Write()
+	}
+}
+
+// Implements CacheMultiStore.
+// The writes of the stores of a nested multistore are named
+// <name of the multistore>/<name of the store>. The writes of
+// transient stores aren't committed, so they aren't listened to.
+func (cms cacheMultiStore) SetWriteListener(listener WriteListener) {
+	for key, store := range cms.stores {
+		switch store := store.(type) {
+		case *cacheKVStore:
+			if store.GetStoreType() == sdk.StoreTypeTransient {
+				continue
+			}
+			store.SetWriteListener(key.Name(), listener)
+		case cacheMultiStore:
+			store.SetWriteListener(prefixWriteListener{key.Name() + "/", listener})
+		}
 	}
 }
 
+// prefixWriteListener prefixes the names of the stores of its writes.
+type prefixWriteListener struct {
+	prefix   string
+	listener WriteListener
+}
+
+// Implements WriteListener.
+func (l prefixWriteListener) OnWrite(storeName string, key, value []byte, deleted bool) {
+	l.listener.OnWrite(l.prefix+storeName, key, value, deleted)
+}
+
 // Implements CacheWrapper.
 func (cms cacheMultiStore) CacheWrap() CacheWrap {
 	return cms.CacheMultiStore().(CacheWrap)
@@ -72,3 +111,8 @@ func (cms cacheMultiStore) GetStore(key StoreKey) Store {
 func (cms cacheMultiStore) GetKVStore(key StoreKey) KVStore {
 	return cms.stores[key].(KVStore)
 }
+
+// Implements MultiStore.
+func (cms cacheMultiStore) GetKVStoreWithGas(meter sdk.GasMeter, key StoreKey) KVStore {
+	return NewGasKVStore(meter, cms.GetKVStore(key))
+}
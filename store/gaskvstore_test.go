@@ -0,0 +1,68 @@
+package store
+
+import (
+	"testing"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+func TestGasKVStoreBasic(t *testing.T) {
+	mem := dbStoreAdapter{dbm.NewMemDB()}
+	meter := sdk.NewGasMeter(1000)
+	st := NewGasKVStore(meter, mem)
+	require.Empty(t, st.Get(keyFmt(1)), "Expected `key1` to be empty")
+	st.Set(keyFmt(1), valFmt(1))
+	require.Equal(t, valFmt(1), st.Get(keyFmt(1)))
+	st.Delete(keyFmt(1))
+	require.Empty(t, st.Get(keyFmt(1)), "Expected `key1` to be empty")
+
+	expected := sdk.Gas(3*ReadCostFlat + WriteCostFlat +
+		WriteCostPerByte*len(valFmt(1)) + ReadCostPerByte*len(valFmt(1)))
+	require.Equal(t, expected, meter.GasConsumed())
+}
+
+func TestGasKVStoreIterator(t *testing.T) {
+	mem := dbStoreAdapter{dbm.NewMemDB()}
+	meter := sdk.NewGasMeter(1000)
+	st := NewGasKVStore(meter, mem)
+	st.Set(keyFmt(1), valFmt(1))
+	st.Set(keyFmt(2), valFmt(2))
+	consumed := meter.GasConsumed()
+
+	iterator := st.Iterator(nil, nil)
+	ka := iterator.Key()
+	require.Equal(t, ka, keyFmt(1))
+	va := iterator.Value()
+	require.Equal(t, va, valFmt(1))
+	iterator.Next()
+	kb := iterator.Key()
+	require.Equal(t, kb, keyFmt(2))
+	vb := iterator.Value()
+	require.Equal(t, vb, valFmt(2))
+	iterator.Next()
+	require.False(t, iterator.Valid())
+	iterator.Close()
+
+	expected := sdk.Gas(2*KeyCostFlat + 2*ValueCostFlat +
+		ValueCostPerByte*(len(valFmt(1))+len(valFmt(2))) + 2*IterNextCostFlat)
+	require.Equal(t, consumed+expected, meter.GasConsumed())
+}
+
+func TestGasKVStoreOutOfGas(t *testing.T) {
+	mem := dbStoreAdapter{dbm.NewMemDB()}
+	meter := sdk.NewGasMeter(0)
+	st := NewGasKVStore(meter, mem)
+	require.Panics(t, func() { st.Set(keyFmt(1), valFmt(1)) }, "Expected out-of-gas")
+	require.Nil(t, mem.Get(keyFmt(1)), "Out-of-gas write must not reach the parent")
+}
+
+func TestGasKVStoreInfiniteMeter(t *testing.T) {
+	mem := dbStoreAdapter{dbm.NewMemDB()}
+	meter := sdk.NewInfiniteGasMeter()
+	st := NewGasKVStore(meter, mem)
+	st.Set(keyFmt(1), valFmt(1))
+	require.Equal(t, valFmt(1), st.Get(keyFmt(1)))
+	require.True(t, meter.GasConsumed() > 0)
+}
@@ -0,0 +1,157 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	wire "github.com/tendermint/go-wire"
+	dbm "github.com/tendermint/tmlibs/db"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// The iavl package has no API to export and import the nodes of a version
+// of a tree, which snapshots and rollbacks need to rebuild the same hashes.
+// This file is the only one which knows how the iavl nodeDB persists the
+// trees in their db: it matches iavl v0.7 as constrained in Gopkg.toml,
+// and TestIAVLNodeLayout fails if an upgrade of iavl changes it.
+
+// Keys of the IAVL trees in their db, as written by the iavl nodeDB.
+const (
+	iavlNodeKeyFmt   = "n/%x"  // n/<hash>
+	iavlRootKeyFmt   = "r/%d"  // r/<version>
+	iavlOrphanPrefix = "o/%d/" // o/<last-version>/
+	iavlRootPrefix   = "r/"    // r/
+)
+
+// iavlRootKey is the key of the hash of the root of version
+func iavlRootKey(version int64) []byte {
+	return []byte(fmt.Sprintf(iavlRootKeyFmt, version))
+}
+
+// iavlNodeKey is the key of the node of hash
+func iavlNodeKey(hash []byte) []byte {
+	return []byte(fmt.Sprintf(iavlNodeKeyFmt, hash))
+}
+
+// iavlVersions returns the versions of the IAVL tree in db, in order.
+func iavlVersions(db dbm.DB) []int64 {
+	var versions []int64
+	iter := dbm.IteratePrefix(db, []byte(iavlRootPrefix))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		var version int64
+		_, err := fmt.Sscanf(string(iter.Key()), iavlRootKeyFmt, &version)
+		if err == nil {
+			versions = append(versions, version)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i] < versions[j]
+	})
+	return versions
+}
+
+// iavlNode is a node of an IAVL tree as persisted in its db.
+type iavlNode struct {
+	Key      []byte   // the key of the node in the db
+	Value    []byte   // the encoded node
+	Version  int64    // the version which created the node
+	Children [][]byte // the hashes of the children of inner nodes
+}
+
+// walkIAVLNodes calls visit on the root key and hash of version in db,
+// then on its nodes from the top, left first. The children of the nodes
+// for which visit returns false are skipped.
+func walkIAVLNodes(db dbm.DB, version int64, visitRoot func(key, hash []byte) error, visit func(node iavlNode) (bool, error)) error {
+	rootKey := iavlRootKey(version)
+	root := db.Get(rootKey)
+	if root == nil {
+		return fmt.Errorf("version %d is not retained", version)
+	}
+	err := visitRoot(rootKey, root)
+	if err != nil {
+		return err
+	}
+	var hashes [][]byte
+	if len(root) > 0 {
+		hashes = append(hashes, root)
+	}
+	for len(hashes) > 0 {
+		hash := hashes[len(hashes)-1]
+		hashes = hashes[:len(hashes)-1]
+		nodeKey := iavlNodeKey(hash)
+		bz := db.Get(nodeKey)
+		if bz == nil {
+			return fmt.Errorf("missing node %X of version %d", hash, version)
+		}
+		_, nodeVersion, children, err := decodeIAVLNode(bz)
+		if err != nil {
+			return fmt.Errorf("invalid node %X of version %d: %v", hash, version, err)
+		}
+		descend, err := visit(iavlNode{nodeKey, bz, nodeVersion, children})
+		if err != nil {
+			return err
+		}
+		if !descend {
+			continue
+		}
+		for i := len(children) - 1; i >= 0; i-- {
+			hashes = append(hashes, children[i])
+		}
+	}
+	return nil
+}
+
+// decodeIAVLNode decodes a node of an IAVL tree as persisted by the iavl
+// nodeDB, and returns its hash, its version and the hashes of its children.
+func decodeIAVLNode(bz []byte) (hash []byte, version int64, children [][]byte, err error) {
+	if len(bz) < 17 {
+		return nil, 0, nil, fmt.Errorf("node too short")
+	}
+	height := int8(bz[0])
+	size := wire.GetInt64(bz[1:])
+	version = wire.GetInt64(bz[9:])
+	buf := bz[17:]
+	key, n, err := wire.GetByteSlice(buf)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	buf = buf[n:]
+
+	// hash the node like iavl, without the key of inner nodes
+	var hn int
+	hb := new(bytes.Buffer)
+	wire.WriteInt8(height, hb, &hn, &err)
+	wire.WriteInt64(size, hb, &hn, &err)
+	wire.WriteInt64(version, hb, &hn, &err)
+	if height == 0 {
+		value, _, err := wire.GetByteSlice(buf)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		wire.WriteByteSlice(key, hb, &hn, &err)
+		wire.WriteByteSlice(value, hb, &hn, &err)
+	} else {
+		left, n, err := wire.GetByteSlice(buf)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		right, _, err := wire.GetByteSlice(buf[n:])
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if len(left) == 0 || len(right) == 0 {
+			return nil, 0, nil, fmt.Errorf("inner node without children")
+		}
+		wire.WriteByteSlice(left, hb, &hn, &err)
+		wire.WriteByteSlice(right, hb, &hn, &err)
+		children = [][]byte{left, right}
+	}
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	hasher := ripemd160.New()
+	hasher.Write(hb.Bytes())
+	return hasher.Sum(nil), version, children, nil
+}
@@ -0,0 +1,93 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/iavl"
+	dbm "github.com/tendermint/tmlibs/db"
+)
+
+// Test that the nodes of the trees are where and how iavl persists them,
+// which an upgrade of iavl could change.
+func TestIAVLNodeLayout(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree := iavl.NewVersionedTree(db, cacheSize)
+	var hashes [][]byte
+	var sizes []int
+	for v := 0; v < 3; v++ {
+		for i := 0; i < 20; i++ {
+			tree.Set([]byte(fmt.Sprintf("key%d", (i*7+v)%25)), []byte(fmt.Sprintf("value%d", v)))
+		}
+		tree.Remove([]byte(fmt.Sprintf("key%d", v)))
+		hash, _, err := tree.SaveVersion()
+		require.Nil(t, err)
+		hashes = append(hashes, hash)
+		sizes = append(sizes, tree.Tree().Size())
+	}
+	require.Equal(t, []int64{1, 2, 3}, iavlVersions(db))
+
+	for i, hash := range hashes {
+		checkIAVLNodes(t, db, int64(i+1), hash, sizes[i])
+	}
+}
+
+// Test the layout against random trees, whose nodes must hash to the
+// root hash iavl computes.
+func TestIAVLNodeLayoutRandom(t *testing.T) {
+	for seed := int64(0); seed < 10; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		db := dbm.NewMemDB()
+		tree := iavl.NewVersionedTree(db, cacheSize)
+		for v := int64(1); v <= 5; v++ {
+			for i := r.Intn(100); i >= 0; i-- {
+				key := []byte(fmt.Sprintf("key%d", r.Intn(200)))
+				if r.Intn(4) == 0 {
+					tree.Remove(key)
+				} else {
+					tree.Set(key, []byte(fmt.Sprintf("value%d", r.Int63())))
+				}
+			}
+			if tree.Tree().Size() == 0 {
+				tree.Set([]byte("key"), []byte("value"))
+			}
+			hash, version, err := tree.SaveVersion()
+			require.Nil(t, err)
+			require.Equal(t, v, version)
+			require.Equal(t, tree.Hash(), hash)
+			checkIAVLNodes(t, db, version, tree.Hash(), tree.Tree().Size())
+		}
+	}
+}
+
+// checkIAVLNodes walks the nodes of a version and checks each hashes
+// to the hash its parent has of it, from the root hash down.
+func checkIAVLNodes(t *testing.T, db dbm.DB, version int64, hash []byte, size int) {
+	expected := make(map[string]bool)
+	leaves := 0
+	err := walkIAVLNodes(db, version, func(key, root []byte) error {
+		require.Equal(t, hash, root)
+		expected[string(root)] = true
+		return nil
+	}, func(node iavlNode) (bool, error) {
+		nodeHash, nodeVersion, children, err := decodeIAVLNode(node.Value)
+		require.Nil(t, err)
+		require.True(t, expected[string(nodeHash)], "unexpected node %X", nodeHash)
+		require.True(t, bytes.Equal(iavlNodeKey(nodeHash), node.Key))
+		require.True(t, nodeVersion <= version)
+		delete(expected, string(nodeHash))
+		for _, child := range children {
+			expected[string(child)] = true
+		}
+		if len(children) == 0 {
+			leaves++
+		}
+		return true, nil
+	})
+	require.Nil(t, err)
+	require.Empty(t, expected)
+	require.Equal(t, size, leaves)
+}
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"bytes"
 	"fmt"
 	"sync"
 
@@ -13,17 +14,21 @@ import (
 )
 
 const (
-	defaultIAVLCacheSize  = 10000
-	defaultIAVLNumHistory = 1<<53 - 1 // DEPRECATED
+	defaultIAVLCacheSize = 10000
 )
 
-func LoadIAVLStore(db dbm.DB, id CommitID) (CommitStore, error) {
+// LoadIAVLStore loads the version id.Version of the IAVL store in db,
+// which prunes its old versions with the given strategy.
+func LoadIAVLStore(db dbm.DB, id CommitID, pruning PruningStrategy) (CommitStore, error) {
 	tree := iavl.NewVersionedTree(db, defaultIAVLCacheSize)
-	_, err := tree.LoadVersion(id.Version)
+	ver, err := tree.LoadVersion(id.Version)
 	if err != nil {
+		if id.Version > 0 && ver < id.Version {
+			return nil, fmt.Errorf("version %d is not retained, it may have been pruned: %v", id.Version, err)
+		}
 		return nil, err
 	}
-	store := newIAVLStore(tree, defaultIAVLNumHistory)
+	store := newIAVLStore(tree, pruning)
 	return store, nil
 }
 
@@ -39,42 +44,66 @@ type iavlStore struct {
 	// The underlying tree.
 	tree *iavl.VersionedTree
 
-	// How many old versions we hold onto.
-	// A value of 0 means keep all history.
-	numHistory int64
+	// Which old versions we hold onto.
+	pruning PruningStrategy
+
+	// The versions below it are pruned as per the strategy.
+	prunedUntil int64
 }
 
 // CONTRACT: tree should be fully loaded.
-func newIAVLStore(tree *iavl.VersionedTree, numHistory int64) *iavlStore {
+func newIAVLStore(tree *iavl.VersionedTree, pruning PruningStrategy) *iavlStore {
 	st := &iavlStore{
-		tree:       tree,
-		numHistory: numHistory,
+		tree:        tree,
+		pruning:     pruning,
+		prunedUntil: 1,
 	}
 	return st
 }
 
 // Implements Committer.
 func (st *iavlStore) Commit() CommitID {
+	commitID := st.commit()
+	st.prune(commitID.Version)
+	return commitID
+}
 
-	// Save a new version.
+// commit saves a new version, without pruning the old ones.
+func (st *iavlStore) commit() CommitID {
 	hash, version, err := st.tree.SaveVersion()
 	if err != nil {
 		// TODO: Do we want to extend Commit to allow returning errors?
 		panic(err)
 	}
-
-	// Release an old version of history
-	if st.numHistory > 0 && (st.numHistory < st.tree.Version64()) {
-		toRelease := version - st.numHistory
-		st.tree.DeleteVersion(toRelease)
-	}
-
 	return CommitID{
 		Version: version,
 		Hash:    hash,
 	}
 }
 
+// prune releases the versions which are no longer recent once version
+// is committed, unless they are kept. The first commit checks all the
+// older versions, eg. which an earlier strategy kept, and the next ones
+// only the versions which became old since.
+// After loading an older version, the newer versions are still
+// in the tree and the release is relative to the recommitted version,
+// so no version at or above the loaded one is released.
+func (st *iavlStore) prune(version int64) {
+	last := version - st.pruning.KeepRecent
+	if st.pruning.KeepRecent == 0 || last < st.prunedUntil {
+		return
+	}
+	for toRelease := st.prunedUntil; toRelease <= last; toRelease++ {
+		if st.pruning.PruneVersion(toRelease, version) && st.tree.VersionExists(toRelease) {
+			err := st.tree.DeleteVersion(toRelease)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	st.prunedUntil = last + 1
+}
+
 // Implements Committer.
 func (st *iavlStore) LastCommitID() CommitID {
 	return CommitID{
@@ -114,6 +143,11 @@ func (st *iavlStore) Delete(key []byte) {
 	st.tree.Remove(key)
 }
 
+// Implements KVStore.
+func (st *iavlStore) Prefix(prefix []byte) KVStore {
+	return NewPrefixStore(st, prefix)
+}
+
 // Implements KVStore.
 func (st *iavlStore) Iterator(start, end []byte) Iterator {
 	return newIAVLIterator(st.tree.Tree(), start, end, true)
@@ -137,7 +171,8 @@ func (st *iavlStore) ReverseIterator(start, end []byte) Iterator {
 // as we will have merkle proofs immediately (header height = data height + 1)
 // If latest-1 is not present, use latest (which must be present)
 // if you care to have the latest data to see a tx results, you must
-// explicitly set the height you want to see
+// explicitly set the height you want to see.
+// Heights which are not committed yet or not retained are errors.
 func (st *iavlStore) Query(req abci.RequestQuery) (res abci.ResponseQuery) {
 	if len(req.Data) == 0 {
 		msg := "Query cannot be zero length"
@@ -145,14 +180,24 @@ func (st *iavlStore) Query(req abci.RequestQuery) (res abci.ResponseQuery) {
 	}
 
 	tree := st.tree
+	latest := tree.Version64()
 	height := req.Height
-	if height == 0 {
-		latest := tree.Version64()
+	switch {
+	case height == 0:
 		if tree.VersionExists(latest - 1) {
 			height = latest - 1
 		} else {
 			height = latest
 		}
+	case height < 0:
+		msg := fmt.Sprintf("invalid height %d", height)
+		return sdk.ErrUnknownRequest(msg).QueryResult()
+	case height > latest:
+		msg := fmt.Sprintf("height %d is not committed yet, the latest height is %d", height, latest)
+		return sdk.ErrUnknownRequest(msg).QueryResult()
+	case !tree.VersionExists(height):
+		msg := fmt.Sprintf("height %d is not retained, it may have been pruned", height)
+		return sdk.ErrUnknownRequest(msg).QueryResult()
 	}
 	// store the height we chose in the response
 	res.Height = height
@@ -173,6 +218,31 @@ func (st *iavlStore) Query(req abci.RequestQuery) (res abci.ResponseQuery) {
 			_, res.Value = tree.GetVersioned(key, height)
 		}
 
+	case "/range", "/subspace": // Get the pairs of a range of keys
+		var q RangeQuery
+		var err error
+		if req.Path == "/range" {
+			err = cdc.UnmarshalBinary(req.Data, &q)
+		} else {
+			var sq SubspaceQuery
+			err = cdc.UnmarshalBinary(req.Data, &sq)
+			if err == nil {
+				q, err = sq.Range()
+			}
+		}
+		if err != nil {
+			return sdk.ErrTxDecode(err.Error()).QueryResult()
+		}
+		result, proof, err := queryRange(tree, height, q, req.Prove)
+		if err != nil {
+			return sdk.ErrUnknownRequest(err.Error()).QueryResult()
+		}
+		res.Value, err = cdc.MarshalBinary(result)
+		if err != nil {
+			return sdk.ErrInternal(err.Error()).QueryResult()
+		}
+		res.Proof = proof
+
 	default:
 		msg := fmt.Sprintf("Unexpected Query path: %v", req.Path)
 		return sdk.ErrUnknownRequest(msg).QueryResult()
@@ -182,6 +252,172 @@ func (st *iavlStore) Query(req abci.RequestQuery) (res abci.ResponseQuery) {
 
 //----------------------------------------
 
+// versionStore returns a read-only KVStore of a retained version of the
+// store, which reads it from the tree in memory instead of reloading it
+// from the db.
+func (st *iavlStore) versionStore(version int64) (KVStore, error) {
+	if !st.tree.VersionExists(version) {
+		return nil, fmt.Errorf("version %d is not retained, it may have been pruned", version)
+	}
+	return &iavlVersionStore{st.tree, version}, nil
+}
+
+var _ KVStore = (*iavlVersionStore)(nil)
+
+// iavlVersionStore is a read-only KVStore of a version of a tree.
+type iavlVersionStore struct {
+	tree    *iavl.VersionedTree
+	version int64
+}
+
+// Implements Store.
+func (st *iavlVersionStore) GetStoreType() StoreType {
+	return sdk.StoreTypeIAVL
+}
+
+// Implements Store.
+func (st *iavlVersionStore) CacheWrap() CacheWrap {
+	return NewCacheKVStore(st)
+}
+
+// Implements KVStore.
+func (st *iavlVersionStore) Get(key []byte) []byte {
+	_, value := st.tree.GetVersioned(key, st.version)
+	return value
+}
+
+// Implements KVStore.
+func (st *iavlVersionStore) Has(key []byte) bool {
+	return st.Get(key) != nil
+}
+
+// Implements KVStore.
+func (st *iavlVersionStore) Set(key, value []byte) {
+	panic("cannot write to an old version of an IAVL store")
+}
+
+// Implements KVStore.
+func (st *iavlVersionStore) Delete(key []byte) {
+	panic("cannot write to an old version of an IAVL store")
+}
+
+// Implements KVStore.
+func (st *iavlVersionStore) Prefix(prefix []byte) KVStore {
+	return NewPrefixStore(st, prefix)
+}
+
+// Implements KVStore.
+func (st *iavlVersionStore) Iterator(start, end []byte) Iterator {
+	return st.iterator(start, end, true)
+}
+
+// Implements KVStore.
+func (st *iavlVersionStore) ReverseIterator(start, end []byte) Iterator {
+	return st.iterator(start, end, false)
+}
+
+// iterator reads the pairs of the domain up front, as the tree has no
+// iterator over its old versions.
+func (st *iavlVersionStore) iterator(start, end []byte, ascending bool) Iterator {
+	keys, values, err := getVersionedRange(st.tree, st.version, start, end, 0)
+	if err != nil {
+		panic(err)
+	}
+	if !ascending {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+	return &pairsIterator{start: cp(start), end: cp(end), keys: keys, values: values}
+}
+
+// getVersionedRange returns the pairs of a version of tree with keys in
+// [start, end), in order, at most limit of them if limit is positive.
+// A nil end is the end of the tree.
+func getVersionedRange(tree *iavl.VersionedTree, version int64, start, end []byte, limit int) (keys, values [][]byte, err error) {
+	if end != nil && bytes.Compare(start, end) >= 0 {
+		return nil, nil, nil
+	}
+	// the ranges of the tree include their end, and go backwards
+	// unless it is after their start
+	last := end
+	if end == nil {
+		last, _, _, err = tree.GetVersionedLastInRangeWithProof(start, nil, version)
+		if err == iavl.ErrNilRoot || (err == nil && last == nil) {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	fetch := limit
+	if limit > 0 && end != nil {
+		fetch++ // for the end, which is excluded
+	}
+	keys, values, _, err = tree.GetVersionedRangeWithProof(start, last, fetch, version)
+	if err == iavl.ErrNilRoot {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if n := len(keys); end != nil && n > 0 && bytes.Equal(keys[n-1], end) {
+		keys, values = keys[:n-1], values[:n-1]
+	}
+	if limit > 0 && len(keys) > limit {
+		keys, values = keys[:limit], values[:limit]
+	}
+	return keys, values, nil
+}
+
+// pairsIterator iterates over pairs read up front.
+type pairsIterator struct {
+	start, end   []byte
+	keys, values [][]byte
+}
+
+var _ Iterator = (*pairsIterator)(nil)
+
+// Implements Iterator.
+func (iter *pairsIterator) Domain() (start, end []byte) {
+	return iter.start, iter.end
+}
+
+// Implements Iterator.
+func (iter *pairsIterator) Valid() bool {
+	return len(iter.keys) > 0
+}
+
+// Implements Iterator.
+func (iter *pairsIterator) Next() {
+	iter.assertIsValid()
+	iter.keys, iter.values = iter.keys[1:], iter.values[1:]
+}
+
+// Implements Iterator.
+func (iter *pairsIterator) Key() []byte {
+	iter.assertIsValid()
+	return iter.keys[0]
+}
+
+// Implements Iterator.
+func (iter *pairsIterator) Value() []byte {
+	iter.assertIsValid()
+	return iter.values[0]
+}
+
+// Implements Iterator.
+func (iter *pairsIterator) Close() {}
+
+func (iter *pairsIterator) assertIsValid() {
+	if !iter.Valid() {
+		panic("invalid iterator")
+	}
+}
+
+//----------------------------------------
+
 // Implements Iterator.
 type iavlIterator struct {
 	// Underlying store
@@ -298,8 +534,12 @@ func (iter *iavlIterator) Value() []byte {
 }
 
 // Implements Iterator.
+// Close waits for the goroutine to stop reading the tree,
+// which may then be committed.
 func (iter *iavlIterator) Close() {
 	close(iter.quitCh)
+	for range iter.iterCh {
+	}
 }
 
 //----------------------------------------
@@ -339,6 +579,10 @@ func (iter *iavlIterator) assertIsValid() {
 //----------------------------------------
 
 func cp(bz []byte) (ret []byte) {
+	if bz == nil {
+		// nil is an open bound for iteration, keep it distinct from empty
+		return nil
+	}
 	ret = make([]byte, len(bz))
 	copy(ret, bz)
 	return ret
@@ -1,6 +1,7 @@
 package store
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -14,8 +15,8 @@ import (
 )
 
 var (
-	cacheSize        = 100
-	numHistory int64 = 5
+	cacheSize = 100
+	pruning   = sdk.NewPruningStrategy(5, 0)
 )
 
 var (
@@ -45,7 +46,7 @@ func newTree(t *testing.T, db dbm.DB) (*iavl.VersionedTree, CommitID) {
 func TestIAVLStoreGetSetHasDelete(t *testing.T) {
 	db := dbm.NewMemDB()
 	tree, _ := newTree(t, db)
-	iavlStore := newIAVLStore(tree, numHistory)
+	iavlStore := newIAVLStore(tree, pruning)
 
 	key := "hello"
 
@@ -70,7 +71,7 @@ func TestIAVLStoreGetSetHasDelete(t *testing.T) {
 func TestIAVLIterator(t *testing.T) {
 	db := dbm.NewMemDB()
 	tree, _ := newTree(t, db)
-	iavlStore := newIAVLStore(tree, numHistory)
+	iavlStore := newIAVLStore(tree, pruning)
 	iter := iavlStore.Iterator([]byte("aloha"), []byte("hellz"))
 	expected := []string{"aloha", "hello"}
 	for i := 0; iter.Valid(); iter.Next() {
@@ -82,10 +83,81 @@ func TestIAVLIterator(t *testing.T) {
 	}
 }
 
+func TestIAVLIteratorOpenBounds(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree, _ := newTree(t, db)
+	iavlStore := newIAVLStore(tree, pruning)
+
+	// nil bounds are open ends of the keyspace
+	iter := iavlStore.Iterator(nil, nil)
+	expected := []string{"aloha", "hello"}
+	i := 0
+	for ; iter.Valid(); iter.Next() {
+		assert.EqualValues(t, expected[i], iter.Key())
+		i++
+	}
+	assert.Equal(t, len(expected), i)
+
+	iter = iavlStore.ReverseIterator([]byte("b"), nil)
+	assert.True(t, iter.Valid())
+	assert.EqualValues(t, "hello", iter.Key())
+	iter.Next()
+	assert.False(t, iter.Valid())
+}
+
+func TestIAVLVersionStore(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree, cid := newTree(t, db)
+	iavlStore := newIAVLStore(tree, pruning)
+	iavlStore.Set([]byte("hello"), []byte("again"))
+	iavlStore.Set([]byte("hola"), []byte("adios"))
+	iavlStore.Delete([]byte("aloha"))
+	iavlStore.Commit()
+
+	// the old version is read from the tree
+	st, err := iavlStore.versionStore(cid.Version)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "goodbye", st.Get([]byte("hello")))
+	assert.True(t, st.Has([]byte("aloha")))
+	assert.False(t, st.Has([]byte("hola")))
+
+	expected := []string{"aloha", "hello"}
+	cases := []struct {
+		iter     Iterator
+		expected []string
+	}{
+		{st.Iterator(nil, nil), expected},
+		{st.Iterator([]byte("b"), nil), expected[1:]},
+		{st.Iterator(nil, []byte("hello")), expected[:1]},
+		{st.Iterator([]byte("hello"), []byte("hello")), nil},
+		{st.ReverseIterator(nil, nil), []string{"hello", "aloha"}},
+		{st.ReverseIterator([]byte("b"), nil), expected[1:]},
+		{st.Prefix([]byte("h")).Iterator(nil, nil), []string{"ello"}},
+	}
+	for i, tc := range cases {
+		var keys []string
+		for ; tc.iter.Valid(); tc.iter.Next() {
+			keys = append(keys, string(tc.iter.Key()))
+		}
+		tc.iter.Close()
+		assert.Equal(t, tc.expected, keys, "case %d", i)
+	}
+	assert.Panics(t, func() { st.Set([]byte("hello"), []byte("other")) })
+
+	// a page of the range
+	keys, values, err := getVersionedRange(tree, cid.Version+1, nil, []byte("hola"), 1)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]byte{[]byte("hello")}, keys)
+	assert.Equal(t, [][]byte{[]byte("again")}, values)
+
+	_, err = iavlStore.versionStore(cid.Version + 2)
+	assert.NotNil(t, err)
+}
+
 func TestIAVLSubspace(t *testing.T) {
 	db := dbm.NewMemDB()
 	tree, _ := newTree(t, db)
-	iavlStore := newIAVLStore(tree, numHistory)
+	iavlStore := newIAVLStore(tree, pruning)
 
 	iavlStore.Set([]byte("test1"), []byte("test1"))
 	iavlStore.Set([]byte("test2"), []byte("test2"))
@@ -105,7 +177,7 @@ func TestIAVLSubspace(t *testing.T) {
 func TestIAVLStoreQuery(t *testing.T) {
 	db := dbm.NewMemDB()
 	tree := iavl.NewVersionedTree(db, cacheSize)
-	iavlStore := newIAVLStore(tree, numHistory)
+	iavlStore := newIAVLStore(tree, pruning)
 
 	k, v := []byte("wind"), []byte("blows")
 	k2, v2 := []byte("water"), []byte("flows")
@@ -160,4 +232,94 @@ func TestIAVLStoreQuery(t *testing.T) {
 	qres = iavlStore.Query(query0)
 	assert.Equal(t, uint32(sdk.CodeOK), qres.Code)
 	assert.Equal(t, v, qres.Value)
+	assert.Equal(t, cid.Version-1, qres.Height)
+
+	// heights not committed yet or not retained are errors
+	query.Height = cid.Version + 1
+	qres = iavlStore.Query(query)
+	assert.Equal(t, uint32(sdk.CodeUnknownRequest), qres.Code)
+	iavlStore.tree.DeleteVersion(ver)
+	query.Height = ver
+	qres = iavlStore.Query(query)
+	assert.Equal(t, uint32(sdk.CodeUnknownRequest), qres.Code)
+	assert.Contains(t, qres.Log, "not retained")
+}
+
+// commit a version of st in which key holds the version number
+func commitVersion(st *iavlStore, key []byte) CommitID {
+	st.Set(key, []byte(fmt.Sprintf("%d", st.LastCommitID().Version+1)))
+	return st.Commit()
+}
+
+func TestIAVLPruning(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree := iavl.NewVersionedTree(db, cacheSize)
+	iavlStore := newIAVLStore(tree, sdk.NewPruningStrategy(3, 5))
+
+	for i := 0; i < 20; i++ {
+		commitVersion(iavlStore, []byte("key"))
+	}
+	for v := int64(1); v <= 20; v++ {
+		kept := v > 17 || v%5 == 0
+		assert.Equal(t, kept, tree.VersionExists(v), "version %d", v)
+	}
+}
+
+func TestIAVLPruningLoadVersion(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree := iavl.NewVersionedTree(db, cacheSize)
+	st := newIAVLStore(tree, sdk.NewPruningStrategy(3, 0))
+	key := []byte("key")
+
+	var cids []CommitID
+	for i := 0; i < 10; i++ {
+		cids = append(cids, commitVersion(st, key))
+	}
+
+	// the pruned versions can't be loaded
+	_, err := LoadIAVLStore(db, cids[4], sdk.NewPruningStrategy(3, 0))
+	assert.NotNil(t, err)
+
+	// load an older retained version and commit again
+	store, err := LoadIAVLStore(db, cids[8], sdk.NewPruningStrategy(3, 0))
+	assert.Nil(t, err)
+	st = store.(*iavlStore)
+	assert.Equal(t, cids[8], st.LastCommitID())
+	assert.Equal(t, cids[9], commitVersion(st, key))
+
+	// the newer version is recommitted and the retained versions are intact
+	for v := int64(8); v <= 10; v++ {
+		assert.True(t, st.tree.VersionExists(v), "version %d", v)
+	}
+	store, err = LoadIAVLStore(db, cids[7], sdk.NewPruningStrategy(3, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, cids[7], store.LastCommitID())
+
+	// committing on goes on pruning
+	cid := commitVersion(st, key)
+	assert.Equal(t, int64(11), cid.Version)
+	assert.False(t, st.tree.VersionExists(8))
+	assert.True(t, st.tree.VersionExists(9))
+}
+
+func TestIAVLPruningStrategyChange(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree := iavl.NewVersionedTree(db, cacheSize)
+	st := newIAVLStore(tree, sdk.PruneNothing)
+	key := []byte("key")
+
+	var cid CommitID
+	for i := 0; i < 10; i++ {
+		cid = commitVersion(st, key)
+	}
+
+	// the first commit with a strategy prunes all the old versions
+	store, err := LoadIAVLStore(db, cid, sdk.NewPruningStrategy(2, 4))
+	assert.Nil(t, err)
+	st = store.(*iavlStore)
+	commitVersion(st, key)
+	for v := int64(1); v <= 11; v++ {
+		kept := v > 9 || v%4 == 0
+		assert.Equal(t, kept, st.tree.VersionExists(v), "version %d", v)
+	}
 }
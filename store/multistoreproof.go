@@ -0,0 +1,158 @@
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/tendermint/iavl"
+	"github.com/tendermint/tmlibs/merkle"
+	"golang.org/x/crypto/ripemd160"
+)
+
+// MultiStoreProof proves a value in a substore of a rootMultiStore up to
+// the root hash of the multistore, ie. the AppHash of the block header.
+// It joins the proof of the substore query, which goes up to the root hash
+// of the substore, with the proof of the substore's CommitID in the
+// commitInfo of the multistore.
+type MultiStoreProof struct {
+	StoreProof  []byte          // proof of the substore query, eg. an iavl.KeyProof
+	CommitProof CommitInfoProof // proof of the substore root in the commitInfo
+}
+
+// CommitInfoProof is the simple merkle proof of the storeInfo
+// of a substore in the commitInfo of a rootMultiStore.
+type CommitInfoProof struct {
+	StoreName string
+	CommitID  CommitID
+	Index     int      // index of the store's leaf, sorted by the hash of the name
+	Total     int      // number of stores
+	Aunts     [][]byte // hashes from the leaf's sibling to the root's child
+}
+
+// Verify checks that the proof's CommitID is committed
+// under the store name in the commitInfo of root.
+func (proof CommitInfoProof) Verify(root []byte) error {
+	si := storeInfo{
+		Name: proof.StoreName,
+		Core: storeCore{CommitID: proof.CommitID},
+	}
+	sp := merkle.SimpleProof{Aunts: proof.Aunts}
+	if !sp.Verify(proof.Index, proof.Total, si.leafHash(), root) {
+		return fmt.Errorf("invalid commit proof of store %s for root %X", proof.StoreName, root)
+	}
+	return nil
+}
+
+// VerifyMultiStoreProof verifies the proof of a "/<storeName>/key" query
+// of a rootMultiStore against its root hash. value must be nil if the
+// query proved that the key is absent.
+func VerifyMultiStoreProof(proofBytes []byte, storeName string, key, value, root []byte) error {
+	proof, err := readMultiStoreProof(proofBytes, storeName, root)
+	if err != nil {
+		return err
+	}
+
+	// the substores are IAVL stores
+	keyProof, err := iavl.ReadKeyProof(proof.StoreProof)
+	if err != nil {
+		return fmt.Errorf("failed to decode proof of store %s: %v", storeName, err)
+	}
+	return keyProof.Verify(key, value, proof.CommitProof.CommitID.Hash)
+}
+
+// VerifyMultiStoreRangeProof verifies the proof of a "/<storeName>/range"
+// query of a rootMultiStore, or of a "/<storeName>/subspace" query with
+// its RangeQuery, against its root hash.
+func VerifyMultiStoreRangeProof(proofBytes []byte, storeName string, q RangeQuery, result RangeResult, root []byte) error {
+	proof, err := readMultiStoreProof(proofBytes, storeName, root)
+	if err != nil {
+		return err
+	}
+	var rangeProof RangeProof
+	err = cdc.UnmarshalBinary(proof.StoreProof, &rangeProof)
+	if err != nil {
+		return fmt.Errorf("failed to decode proof of store %s: %v", storeName, err)
+	}
+	return rangeProof.Verify(q, result, proof.CommitProof.CommitID.Hash)
+}
+
+// readMultiStoreProof decodes a MultiStoreProof of the store
+// storeName, and checks its commit proof against root.
+func readMultiStoreProof(proofBytes []byte, storeName string, root []byte) (proof MultiStoreProof, err error) {
+	err = cdc.UnmarshalBinary(proofBytes, &proof)
+	if err != nil {
+		return proof, fmt.Errorf("failed to decode proof: %v", err)
+	}
+	if proof.CommitProof.StoreName != storeName {
+		return proof, fmt.Errorf("proof is for store %s, not %s", proof.CommitProof.StoreName, storeName)
+	}
+	return proof, proof.CommitProof.Verify(root)
+}
+
+// proof returns the proof of the storeInfo of the store name,
+// or an error if there is no such store.
+func (ci commitInfo) proof(name string) (CommitInfoProof, error) {
+	// the leaves of the tree built by merkle.SimpleHashFromMap
+	sm := merkle.NewSimpleMap()
+	for _, si := range ci.StoreInfos {
+		sm.Set(si.Name, si)
+	}
+	kvs := sm.KVPairs()
+	leaves := make([]merkle.Hasher, len(kvs))
+	index := -1
+	nameHash := merkle.SimpleHashFromBytes([]byte(name))
+	for i, kv := range kvs {
+		leaves[i] = hashedLeaf(kvPairHash(kv.Key, kv.Value))
+		if bytes.Equal(kv.Key, nameHash) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return CommitInfoProof{}, fmt.Errorf("no store %s in commit %d", name, ci.Version)
+	}
+
+	var commitID CommitID
+	for _, si := range ci.StoreInfos {
+		if si.Name == name {
+			commitID = si.Core.CommitID
+		}
+	}
+	_, proofs := merkle.SimpleProofsFromHashers(leaves)
+	return CommitInfoProof{
+		StoreName: name,
+		CommitID:  commitID,
+		Index:     index,
+		Total:     len(leaves),
+		Aunts:     proofs[index].Aunts,
+	}, nil
+}
+
+// leafHash returns the hash of the storeInfo's leaf in the commitInfo,
+// as computed by merkle.SimpleHashFromMap.
+func (si storeInfo) leafHash() []byte {
+	return kvPairHash(merkle.SimpleHashFromBytes([]byte(si.Name)), si.Hash())
+}
+
+// kvPairHash hashes a key and value hash like
+// the leaves of a merkle.SimpleMap.
+func kvPairHash(key, value []byte) []byte {
+	hasher := ripemd160.New()
+	hasher.Write(encodeByteSlice(key))
+	hasher.Write(encodeByteSlice(value))
+	return hasher.Sum(nil)
+}
+
+// encodeByteSlice prefixes bz with its uvarint length
+func encodeByteSlice(bz []byte) []byte {
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(bz))
+	n := binary.PutUvarint(buf, uint64(len(bz)))
+	return append(buf[:n], bz...)
+}
+
+// hashedLeaf is a merkle.Hasher for an already computed hash
+type hashedLeaf []byte
+
+func (leaf hashedLeaf) Hash() []byte {
+	return leaf
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestCommitInfoProof(t *testing.T) {
+	for total := 1; total <= 5; total++ {
+		ci := commitInfo{Version: 3}
+		for i := 0; i < total; i++ {
+			ci.StoreInfos = append(ci.StoreInfos, storeInfo{
+				Name: fmt.Sprintf("store%d", i),
+				Core: storeCore{CommitID{3, []byte{byte(i)}}},
+			})
+		}
+		root := ci.Hash()
+
+		for _, si := range ci.StoreInfos {
+			proof, err := ci.proof(si.Name)
+			require.Nil(t, err)
+			assert.Equal(t, si.Core.CommitID, proof.CommitID)
+			assert.Nil(t, proof.Verify(root), "%d stores, %s", total, si.Name)
+
+			// the proof is only valid for the committed ID
+			proof.CommitID.Hash = []byte("bad")
+			assert.NotNil(t, proof.Verify(root))
+		}
+
+		_, err := ci.proof("nonexistent")
+		assert.NotNil(t, err)
+	}
+}
+
+func TestMultiStoreQueryProof(t *testing.T) {
+	db := dbm.NewMemDB()
+	multi := newMultiStoreWithMounts(db)
+	err := multi.LoadLatestVersion()
+	require.Nil(t, err)
+
+	k, v := []byte("wind"), []byte("blows")
+	store1 := multi.getStoreByName("store1").(KVStore)
+	store1.Set(k, v)
+	cid := multi.Commit()
+
+	// the value is proven up to the root of the multistore
+	query := abci.RequestQuery{Path: "/store1/key", Data: k, Height: cid.Version, Prove: true}
+	qres := multi.Query(query)
+	require.Equal(t, uint32(sdk.CodeOK), qres.Code, qres.Log)
+	assert.Equal(t, v, qres.Value)
+	err = VerifyMultiStoreProof(qres.Proof, "store1", k, v, cid.Hash)
+	assert.Nil(t, err)
+
+	// but not a different value, store or root
+	err = VerifyMultiStoreProof(qres.Proof, "store1", k, []byte("blew"), cid.Hash)
+	assert.NotNil(t, err)
+	err = VerifyMultiStoreProof(qres.Proof, "store2", k, v, cid.Hash)
+	assert.NotNil(t, err)
+	err = VerifyMultiStoreProof(qres.Proof, "store1", k, v, []byte("apphash"))
+	assert.NotNil(t, err)
+	err = VerifyMultiStoreProof([]byte("garbage"), "store1", k, v, cid.Hash)
+	assert.NotNil(t, err)
+
+	// absent keys are proven too
+	store1.Set([]byte("water"), []byte("flows"))
+	cid2 := multi.Commit()
+	query = abci.RequestQuery{Path: "/store1/key", Data: []byte("fire"), Height: cid2.Version, Prove: true}
+	qres = multi.Query(query)
+	require.Equal(t, uint32(sdk.CodeOK), qres.Code, qres.Log)
+	assert.Nil(t, qres.Value)
+	err = VerifyMultiStoreProof(qres.Proof, "store1", []byte("fire"), nil, cid2.Hash)
+	assert.Nil(t, err)
+
+	// queries at earlier heights are proven against their commit
+	query = abci.RequestQuery{Path: "/store1/key", Data: k, Height: cid.Version, Prove: true}
+	qres = multi.Query(query)
+	require.Equal(t, uint32(sdk.CodeOK), qres.Code, qres.Log)
+	assert.Nil(t, VerifyMultiStoreProof(qres.Proof, "store1", k, v, cid.Hash))
+	assert.NotNil(t, VerifyMultiStoreProof(qres.Proof, "store1", k, v, cid2.Hash))
+}
+
+func TestMultiStoreRangeQueryProof(t *testing.T) {
+	db := dbm.NewMemDB()
+	multi := newMultiStoreWithMounts(db)
+	err := multi.LoadLatestVersion()
+	require.Nil(t, err)
+
+	store1 := multi.getStoreByName("store1").(KVStore)
+	store1.Set([]byte("fire"), []byte("burns"))
+	store1.Set([]byte("water"), []byte("flows"))
+	store1.Set([]byte("wind"), []byte("blows"))
+	cid := multi.Commit()
+
+	q := SubspaceQuery{Prefix: []byte("w")}
+	data, err := cdc.MarshalBinary(q)
+	require.Nil(t, err)
+	query := abci.RequestQuery{Path: "/store1/subspace", Data: data, Height: cid.Version, Prove: true}
+	qres := multi.Query(query)
+	require.Equal(t, uint32(sdk.CodeOK), qres.Code, qres.Log)
+	var result RangeResult
+	require.Nil(t, cdc.UnmarshalBinary(qres.Value, &result))
+	require.Len(t, result.Pairs, 2)
+	assert.Equal(t, []byte("water"), result.Pairs[0].Key)
+	assert.Equal(t, []byte("blows"), result.Pairs[1].Value)
+
+	// the pairs are proven up to the root of the multistore
+	rq, err := q.Range()
+	require.Nil(t, err)
+	assert.Nil(t, VerifyMultiStoreRangeProof(qres.Proof, "store1", rq, result, cid.Hash))
+	assert.NotNil(t, VerifyMultiStoreRangeProof(qres.Proof, "store2", rq, result, cid.Hash))
+	assert.NotNil(t, VerifyMultiStoreRangeProof(qres.Proof, "store1", rq, result, []byte("apphash")))
+	result.Pairs = result.Pairs[1:]
+	assert.NotNil(t, VerifyMultiStoreRangeProof(qres.Proof, "store1", rq, result, cid.Hash))
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// prefixStore is a KVStore over the keys of its parent which begin with
+// a prefix, with the prefix stripped. Each prefix gives an isolated
+// keyspace in the parent store.
+type prefixStore struct {
+	parent KVStore
+	prefix []byte
+}
+
+var _ KVStore = prefixStore{}
+
+// NewPrefixStore returns the KVStore of the keys of parent
+// which begin with prefix.
+func NewPrefixStore(parent KVStore, prefix []byte) KVStore {
+	return prefixStore{
+		parent: parent,
+		prefix: cp(prefix),
+	}
+}
+
+func (ps prefixStore) key(key []byte) []byte {
+	if key == nil {
+		panic("key is nil")
+	}
+	res := make([]byte, len(ps.prefix), len(ps.prefix)+len(key))
+	copy(res, ps.prefix)
+	return append(res, key...)
+}
+
+// Implements Store.
+func (ps prefixStore) GetStoreType() StoreType {
+	return ps.parent.GetStoreType()
+}
+
+// Implements Store.
+func (ps prefixStore) CacheWrap() CacheWrap {
+	return NewCacheKVStore(ps)
+}
+
+// Implements KVStore.
+func (ps prefixStore) Get(key []byte) []byte {
+	return ps.parent.Get(ps.key(key))
+}
+
+// Implements KVStore.
+func (ps prefixStore) Has(key []byte) bool {
+	return ps.parent.Has(ps.key(key))
+}
+
+// Implements KVStore.
+func (ps prefixStore) Set(key, value []byte) {
+	ps.parent.Set(ps.key(key), value)
+}
+
+// Implements KVStore.
+func (ps prefixStore) Delete(key []byte) {
+	ps.parent.Delete(ps.key(key))
+}
+
+// Implements KVStore.
+// The prefix store of a prefix store prefixes the parent store directly.
+func (ps prefixStore) Prefix(prefix []byte) KVStore {
+	return NewPrefixStore(ps.parent, ps.key(prefix))
+}
+
+// Implements KVStore.
+func (ps prefixStore) Iterator(start, end []byte) Iterator {
+	pstart, pend := ps.domain(start, end)
+	return newPrefixIterator(ps.prefix, start, end, ps.parent.Iterator(pstart, pend))
+}
+
+// Implements KVStore.
+func (ps prefixStore) ReverseIterator(start, end []byte) Iterator {
+	pstart, pend := ps.domain(start, end)
+	return newPrefixIterator(ps.prefix, start, end, ps.parent.ReverseIterator(pstart, pend))
+}
+
+// domain returns the domain in the parent store of the domain from start
+// to end in the prefix store. A nil start or end is the beginning or the
+// end of the prefix store, which are bounded by the prefix in the parent.
+func (ps prefixStore) domain(start, end []byte) (pstart, pend []byte) {
+	if start == nil {
+		pstart = cp(ps.prefix)
+	} else {
+		pstart = ps.key(start)
+	}
+	if end == nil {
+		pend = sdk.PrefixEndBytes(ps.prefix)
+	} else {
+		pend = ps.key(end)
+	}
+	return
+}
+
+//----------------------------------------
+
+// prefixIterator strips the prefix from the keys of
+// an iterator over the domain of a prefix store.
+type prefixIterator struct {
+	prefix     []byte
+	start, end []byte
+	parent     Iterator
+}
+
+var _ Iterator = (*prefixIterator)(nil)
+
+func newPrefixIterator(prefix, start, end []byte, parent Iterator) *prefixIterator {
+	return &prefixIterator{
+		prefix: prefix,
+		start:  start,
+		end:    end,
+		parent: parent,
+	}
+}
+
+// Implements Iterator.
+func (pi *prefixIterator) Domain() (start, end []byte) {
+	return pi.start, pi.end
+}
+
+// Implements Iterator.
+// The domain of the parent iterator only holds keys with the prefix.
+func (pi *prefixIterator) Valid() bool {
+	return pi.parent.Valid()
+}
+
+// Implements Iterator.
+func (pi *prefixIterator) Next() {
+	if !pi.Valid() {
+		panic("prefixIterator invalid, cannot call Next()")
+	}
+	pi.parent.Next()
+}
+
+// Implements Iterator.
+func (pi *prefixIterator) Key() []byte {
+	if !pi.Valid() {
+		panic("prefixIterator invalid, cannot call Key()")
+	}
+	return pi.parent.Key()[len(pi.prefix):]
+}
+
+// Implements Iterator.
+func (pi *prefixIterator) Value() []byte {
+	if !pi.Valid() {
+		panic("prefixIterator invalid, cannot call Value()")
+	}
+	return pi.parent.Value()
+}
+
+// Implements Iterator.
+func (pi *prefixIterator) Close() {
+	pi.parent.Close()
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/tendermint/iavl"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func testPrefixStore(t *testing.T, parent KVStore, prefix []byte) {
+	// keys around the prefix in the parent
+	before := append(cp(prefix[:len(prefix)-1]), prefix[len(prefix)-1]-1, 0xFF)
+	after := sdk.PrefixEndBytes(prefix)
+	parent.Set(before, []byte("before"))
+	if after != nil {
+		parent.Set(after, []byte("after"))
+	}
+
+	store := parent.Prefix(prefix)
+	keys := [][]byte{{0x00}, {0x01}, {0x01, 0x00}, {0x02}, {0xFF}, {0xFF, 0xFF}}
+	for _, k := range keys {
+		assert.False(t, store.Has(k))
+		store.Set(k, k)
+	}
+	for _, k := range keys {
+		assert.True(t, store.Has(k))
+		assert.Equal(t, k, store.Get(k))
+		assert.Equal(t, k, parent.Get(append(cp(prefix), k...)))
+	}
+
+	checkIterator := func(iter Iterator, expected [][]byte) {
+		defer iter.Close()
+		var got [][]byte
+		for ; iter.Valid(); iter.Next() {
+			assert.Equal(t, iter.Key(), iter.Value())
+			got = append(got, iter.Key())
+		}
+		assert.Equal(t, expected, got)
+	}
+	reversed := func(keys [][]byte) [][]byte {
+		res := make([][]byte, len(keys))
+		for i, k := range keys {
+			res[len(keys)-1-i] = k
+		}
+		return res
+	}
+
+	// open bounds stay within the prefix
+	checkIterator(store.Iterator(nil, nil), keys)
+	checkIterator(store.ReverseIterator(nil, nil), reversed(keys))
+
+	// end is exclusive
+	checkIterator(store.Iterator([]byte{0x01}, []byte{0x02}), keys[1:3])
+	checkIterator(store.ReverseIterator([]byte{0x01}, []byte{0x02}), reversed(keys[1:3]))
+	checkIterator(store.Iterator([]byte{0x02}, nil), keys[3:])
+	checkIterator(store.ReverseIterator(nil, []byte{0x02}), reversed(keys[:3]))
+
+	// nested prefixes
+	sub := store.Prefix([]byte{0xFF})
+	iter := sub.Iterator(nil, nil)
+	for _, k := range [][]byte{{}, {0xFF}} {
+		require.True(t, iter.Valid())
+		assert.Equal(t, k, iter.Key())
+		assert.Equal(t, append([]byte{0xFF}, k...), iter.Value())
+		iter.Next()
+	}
+	assert.False(t, iter.Valid())
+	iter.Close()
+	assert.Equal(t, []byte{0xFF, 0xFF}, sub.Get([]byte{0xFF}))
+
+	for _, k := range keys {
+		store.Delete(k)
+		assert.False(t, store.Has(k))
+	}
+	checkIterator(store.Iterator(nil, nil), nil)
+	assert.Equal(t, []byte("before"), parent.Get(before))
+}
+
+func TestPrefixStore(t *testing.T) {
+	newIAVL := func() KVStore {
+		db := dbm.NewMemDB()
+		tree := iavl.NewVersionedTree(db, cacheSize)
+		return newIAVLStore(tree, pruning)
+	}
+	prefixes := [][]byte{{0x01}, {0x01, 0x02}, {0x01, 0xFF}, {0xFF}, {0xFF, 0xFF}}
+	for _, prefix := range prefixes {
+		testPrefixStore(t, newIAVL(), prefix)
+		testPrefixStore(t, NewCacheKVStore(newIAVL()), prefix)
+		testPrefixStore(t, NewGasKVStore(sdk.NewGasMeter(1000000), newIAVL()), prefix)
+	}
+}
+
+func TestPrefixStoreCacheWrap(t *testing.T) {
+	parent := dbStoreAdapter{dbm.NewMemDB()}
+	store := parent.Prefix([]byte("prefix"))
+
+	cache := store.CacheWrap().(CacheKVStore)
+	cache.Set([]byte("key"), []byte("value"))
+	require.Nil(t, parent.Get([]byte("prefixkey")))
+	cache.Write()
+	require.Equal(t, []byte("value"), parent.Get([]byte("prefixkey")))
+}
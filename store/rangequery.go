@@ -0,0 +1,193 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tendermint/iavl"
+	cmn "github.com/tendermint/tmlibs/common"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// MaxRangeQueryLimit is the maximum number of pairs in a page of the
+// result of a range or subspace query.
+const MaxRangeQueryLimit = 1000
+
+// RangeQuery is the data of a "/range" query of an IAVL store, which
+// returns the pairs with keys in [Start, End) in order, in pages of at
+// most Limit pairs.
+type RangeQuery struct {
+	Start []byte // the start of the store if empty
+	End   []byte // excluded, the end of the store if empty
+	Limit int    // MaxRangeQueryLimit if 0 or above
+}
+
+// SubspaceQuery is the data of a "/subspace" query of an IAVL store,
+// which returns the pairs whose keys have Prefix like a RangeQuery,
+// from Start if it is set, eg. to get the following pages.
+type SubspaceQuery struct {
+	Prefix []byte
+	Start  []byte
+	Limit  int
+}
+
+// Range returns the equivalent RangeQuery.
+func (q SubspaceQuery) Range() (RangeQuery, error) {
+	rq := RangeQuery{
+		Start: q.Prefix,
+		End:   sdk.PrefixEndBytes(q.Prefix),
+		Limit: q.Limit,
+	}
+	if len(q.Start) > 0 {
+		if !bytes.HasPrefix(q.Start, q.Prefix) {
+			return rq, fmt.Errorf("start %X is outside of the subspace %X", q.Start, q.Prefix)
+		}
+		rq.Start = q.Start
+	}
+	return rq, nil
+}
+
+// RangeResult is the value of the response to a range or subspace query.
+type RangeResult struct {
+	Pairs   []cmn.KVPair `json:"pairs"`
+	NextKey cmn.HexBytes `json:"next_key"` // start of the next page, empty on the last page
+}
+
+// RangeProof is the proof of the result of a range query of an IAVL
+// store. It is the iavl.KeyRangeProof of the pairs from the start of
+// the query to the end of the page, included: the next key if there is
+// a next page, or else the end of the query. The pair at the end of the
+// page, which isn't in the result, is proven with the others if it is in
+// the store.
+type RangeProof struct {
+	Proof   *iavl.KeyRangeProof // nil if the store is empty
+	EndPair *cmn.KVPair
+}
+
+// bounds returns the domain of q, and the limit of its pages.
+func (q RangeQuery) bounds() (start, end []byte, limit int, err error) {
+	if len(q.Start) > 0 {
+		start = q.Start
+	}
+	if len(q.End) > 0 {
+		end = q.End
+	}
+	if end != nil && bytes.Compare(start, end) >= 0 {
+		return nil, nil, 0, fmt.Errorf("empty range from %X to %X", start, end)
+	}
+	limit = q.Limit
+	if limit <= 0 || limit > MaxRangeQueryLimit {
+		limit = MaxRangeQueryLimit
+	}
+	return start, end, limit, nil
+}
+
+// rangeProofEnd returns the included end of the range proven by the
+// RangeProof of result: the next key of the result, or else the end of
+// the query, or else, with no end, the key right after the last pair of
+// the result, or after start if it is empty, as nothing follows them.
+func rangeProofEnd(start, end []byte, result RangeResult) []byte {
+	switch {
+	case len(result.NextKey) > 0:
+		return result.NextKey
+	case end != nil:
+		return end
+	case len(result.Pairs) > 0:
+		return append(cp(result.Pairs[len(result.Pairs)-1].Key), 0)
+	default:
+		return append(cp(start), 0)
+	}
+}
+
+// queryRange returns the page of q in a version of tree, with its encoded
+// RangeProof if prove is set. It reads the version from the tree in memory.
+func queryRange(tree *iavl.VersionedTree, version int64, q RangeQuery, prove bool) (result RangeResult, proofBytes []byte, err error) {
+	start, end, limit, err := q.bounds()
+	if err != nil {
+		return
+	}
+	keys, values, err := getVersionedRange(tree, version, start, end, limit+1)
+	if err != nil {
+		return
+	}
+	for i, key := range keys {
+		if i == limit {
+			result.NextKey = key
+			break
+		}
+		result.Pairs = append(result.Pairs, cmn.KVPair{Key: key, Value: values[i]})
+	}
+	if !prove {
+		return
+	}
+
+	var proof RangeProof
+	proofEnd := rangeProofEnd(start, end, result)
+	keys, values, rangeProof, err := tree.GetVersionedRangeWithProof(start, proofEnd, 0, version)
+	switch {
+	case err == iavl.ErrNilRoot: // the store is empty
+	case err != nil:
+		return result, nil, err
+	default:
+		switch len(keys) {
+		case len(result.Pairs):
+		case len(result.Pairs) + 1:
+			last := len(keys) - 1
+			proof.EndPair = &cmn.KVPair{Key: keys[last], Value: values[last]}
+		default:
+			return result, nil, fmt.Errorf("proof of %d keys for %d pairs", len(keys), len(result.Pairs))
+		}
+		proof.Proof = rangeProof
+	}
+	proofBytes, err = cdc.MarshalBinary(proof)
+	return
+}
+
+// Verify checks that result is a page of the result of q in the IAVL
+// store whose root hash is root.
+func (proof RangeProof) Verify(q RangeQuery, result RangeResult, root []byte) error {
+	start, end, limit, err := q.bounds()
+	if err != nil {
+		return err
+	}
+	n := len(result.Pairs)
+	if n > limit || (len(result.NextKey) > 0 && n < limit) {
+		return fmt.Errorf("page of %d pairs for a limit of %d", n, limit)
+	}
+	if len(result.NextKey) > 0 && end != nil && bytes.Compare(result.NextKey, end) >= 0 {
+		return fmt.Errorf("next key %X is after the end %X", result.NextKey, end)
+	}
+
+	// an empty store has no proof
+	if len(root) == 0 {
+		if n > 0 || len(result.NextKey) > 0 {
+			return fmt.Errorf("pairs of an empty store")
+		}
+		return nil
+	}
+	if proof.Proof == nil {
+		return fmt.Errorf("missing range proof")
+	}
+
+	proofEnd := rangeProofEnd(start, end, result)
+	keys := make([][]byte, 0, n+1)
+	values := make([][]byte, 0, n+1)
+	for _, pair := range result.Pairs {
+		keys = append(keys, pair.Key)
+		values = append(values, pair.Value)
+	}
+	if n > 0 && bytes.Compare(keys[n-1], proofEnd) >= 0 {
+		return fmt.Errorf("key %X is after the end of the page %X", keys[n-1], proofEnd)
+	}
+	if proof.EndPair != nil {
+		if !bytes.Equal(proof.EndPair.Key, proofEnd) {
+			return fmt.Errorf("proof ends at %X instead of %X", proof.EndPair.Key, proofEnd)
+		}
+		keys = append(keys, proof.EndPair.Key)
+		values = append(values, proof.EndPair.Value)
+	} else if len(result.NextKey) > 0 {
+		return fmt.Errorf("missing proof of the next key %X", result.NextKey)
+	}
+	return proof.Proof.Verify(start, proofEnd, 0, keys, values, root)
+}
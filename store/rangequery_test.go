@@ -0,0 +1,216 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	"github.com/tendermint/iavl"
+	cmn "github.com/tendermint/tmlibs/common"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// queryPages queries all the pages of q with path, checking their
+// proofs against root, and returns their pairs.
+func queryPages(t *testing.T, st *iavlStore, path string, q interface{}, height int64, root []byte) []cmn.KVPair {
+	var pairs []cmn.KVPair
+	for pages := 0; ; pages++ {
+		require.True(t, pages <= 1000, "too many pages")
+		data, err := cdc.MarshalBinary(q)
+		require.Nil(t, err)
+		res := st.Query(abci.RequestQuery{Path: path, Data: data, Height: height, Prove: true})
+		require.Equal(t, uint32(sdk.CodeOK), res.Code, res.Log)
+		require.Equal(t, height, res.Height)
+		var result RangeResult
+		require.Nil(t, cdc.UnmarshalBinary(res.Value, &result))
+		var proof RangeProof
+		require.Nil(t, cdc.UnmarshalBinary(res.Proof, &proof))
+
+		var rq RangeQuery
+		switch q := q.(type) {
+		case RangeQuery:
+			rq = q
+		case SubspaceQuery:
+			rq, err = q.Range()
+			require.Nil(t, err)
+		}
+		require.Nil(t, proof.Verify(rq, result, root), "page %d of %v", pages, q)
+
+		pairs = append(pairs, result.Pairs...)
+		if len(result.NextKey) == 0 {
+			return pairs
+		}
+		switch qq := q.(type) {
+		case RangeQuery:
+			qq.Start = result.NextKey
+			q = qq
+		case SubspaceQuery:
+			qq.Start = result.NextKey
+			q = qq
+		}
+	}
+}
+
+// expectedPairs returns the pairs of data with keys in [start, end).
+func expectedPairs(data map[string]string, start, end []byte) []cmn.KVPair {
+	var pairs cmn.KVPairs
+	for k, v := range data {
+		key := []byte(k)
+		if bytes.Compare(key, start) >= 0 && (end == nil || bytes.Compare(key, end) < 0) {
+			pairs = append(pairs, cmn.KVPair{Key: key, Value: []byte(v)})
+		}
+	}
+	pairs.Sort()
+	return pairs
+}
+
+func TestIAVLStoreRangeQuery(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree := iavl.NewVersionedTree(db, cacheSize)
+	st := newIAVLStore(tree, sdk.PruneNothing)
+
+	// the ranges of an empty store are empty
+	cid0 := st.Commit()
+	require.Empty(t, queryPages(t, st, "/range", RangeQuery{}, cid0.Version, cid0.Hash))
+
+	data := make(map[string]string)
+	for i := 0; i < 50; i++ {
+		data[fmt.Sprintf("a/%02d", i)] = fmt.Sprintf("a%d", i)
+		data[fmt.Sprintf("b/%02d", i)] = fmt.Sprintf("b%d", i)
+	}
+	data["b"] = "b"
+	data["c"] = "c"
+	for k, v := range data {
+		st.Set([]byte(k), []byte(v))
+	}
+	cid1 := st.Commit()
+	old := make(map[string]string, len(data))
+	for k, v := range data {
+		old[k] = v
+	}
+
+	// the next version changes some keys
+	for i := 0; i < 50; i += 3 {
+		key := fmt.Sprintf("a/%02d", i)
+		st.Delete([]byte(key))
+		delete(data, key)
+		key = fmt.Sprintf("b/%02d", i)
+		st.Set([]byte(key), []byte("new"))
+		data[key] = "new"
+	}
+	cid2 := st.Commit()
+
+	cases := []struct {
+		start, end string
+	}{
+		{"", ""},
+		{"a/", "b"},
+		{"a/10", "a/20"},
+		{"a/105", "a/201"},
+		{"a/", "b/"},
+		{"b", ""},
+		{"b/49", ""},
+		{"b/490", "c"},
+		{"c", ""},
+		{"d", ""},
+		{"", "a"},
+		{"0", "1"},
+	}
+	for _, version := range []struct {
+		cid  CommitID
+		data map[string]string
+	}{{cid1, old}, {cid2, data}} {
+		for _, tc := range cases {
+			var start, end []byte
+			if tc.start != "" {
+				start = []byte(tc.start)
+			}
+			if tc.end != "" {
+				end = []byte(tc.end)
+			}
+			expected := expectedPairs(version.data, start, end)
+			for _, limit := range []int{0, 1, 7, 100} {
+				q := RangeQuery{Start: start, End: end, Limit: limit}
+				pairs := queryPages(t, st, "/range", q, version.cid.Version, version.cid.Hash)
+				assert.Equal(t, expected, pairs, "version %d, %+v", version.cid.Version, q)
+			}
+		}
+
+		q := SubspaceQuery{Prefix: []byte("b/"), Limit: 10}
+		pairs := queryPages(t, st, "/subspace", q, version.cid.Version, version.cid.Hash)
+		assert.Equal(t, expectedPairs(version.data, []byte("b/"), []byte("b0")), pairs)
+	}
+
+	// bad queries are rejected
+	bad := []struct {
+		path string
+		q    interface{}
+	}{
+		{"/range", RangeQuery{Start: []byte("b"), End: []byte("a")}},
+		{"/range", RangeQuery{Start: []byte("b"), End: []byte("b")}},
+		{"/subspace", SubspaceQuery{Prefix: []byte("b/"), Start: []byte("a/")}},
+		{"/range", []byte("garbage")},
+	}
+	for _, tc := range bad {
+		data, err := cdc.MarshalBinary(tc.q)
+		require.Nil(t, err)
+		res := st.Query(abci.RequestQuery{Path: tc.path, Data: data, Height: cid2.Version})
+		assert.NotEqual(t, uint32(sdk.CodeOK), res.Code, "%s %v", tc.path, tc.q)
+	}
+}
+
+// Test that the proof of a range query rejects altered results.
+func TestRangeProofAltered(t *testing.T) {
+	db := dbm.NewMemDB()
+	tree := iavl.NewVersionedTree(db, cacheSize)
+	st := newIAVLStore(tree, sdk.PruneNothing)
+	for i := 0; i < 10; i++ {
+		st.Set([]byte(fmt.Sprintf("key%d", i)), []byte(fmt.Sprintf("value%d", i)))
+	}
+	cid := st.Commit()
+
+	q := RangeQuery{Start: []byte("key2"), End: []byte("key8"), Limit: 3}
+	result, proofBytes, err := queryRange(st.tree, cid.Version, q, true)
+	require.Nil(t, err)
+	var proof RangeProof
+	require.Nil(t, cdc.UnmarshalBinary(proofBytes, &proof))
+	require.Nil(t, proof.Verify(q, result, cid.Hash))
+	require.Equal(t, []byte("key5"), []byte(result.NextKey))
+
+	alter := []func(r *RangeResult){
+		// a missing pair
+		func(r *RangeResult) { r.Pairs = r.Pairs[1:] },
+		func(r *RangeResult) { r.Pairs = r.Pairs[:2] },
+		// a different value
+		func(r *RangeResult) { r.Pairs[1].Value = []byte("other") },
+		// another next key, or none
+		func(r *RangeResult) { r.NextKey = []byte("key6") },
+		func(r *RangeResult) { r.NextKey = nil },
+	}
+	for i, f := range alter {
+		altered := RangeResult{NextKey: result.NextKey}
+		for _, pair := range result.Pairs {
+			altered.Pairs = append(altered.Pairs, cmn.KVPair{Key: pair.Key, Value: pair.Value})
+		}
+		f(&altered)
+		assert.NotNil(t, proof.Verify(q, altered, cid.Hash), "alteration %d", i)
+	}
+
+	// the last page can't hide the pairs after it
+	q = RangeQuery{Start: []byte("key7"), End: []byte("key9")}
+	result, proofBytes, err = queryRange(st.tree, cid.Version, q, true)
+	require.Nil(t, err)
+	require.Nil(t, cdc.UnmarshalBinary(proofBytes, &proof))
+	require.Len(t, result.Pairs, 2)
+	require.Nil(t, proof.Verify(q, result, cid.Hash))
+	result.Pairs = result.Pairs[:1]
+	assert.NotNil(t, proof.Verify(q, result, cid.Hash))
+
+	// nor can a proof of another store
+	assert.NotNil(t, proof.Verify(q, result, []byte("other root")))
+}
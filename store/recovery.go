@@ -0,0 +1,106 @@
+package store
+
+import (
+	"fmt"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// rollback removes from the stores the versions after version, and the
+// commits of the rootMultiStore after version.
+//
+// The stores of a rootMultiStore may be on different dbs, which are
+// written one by one when committing, before the commitInfo. If the
+// commit is interrupted, some stores have the new version but the
+// commitInfo, which defines the committed versions, doesn't: those
+// versions are rolled back so that every store is at the version of the
+// last commitInfo, and the block can be committed again.
+func (rs *rootMultiStore) rollback(version int64) error {
+	rolledBack := make(map[dbm.DB]bool)
+	for key, params := range rs.storesParams {
+		switch params.typ {
+		case sdk.StoreTypeIAVL:
+			db := rs.db
+			if params.db != nil {
+				db = params.db
+			}
+			if rolledBack[db] {
+				continue
+			}
+			rolledBack[db] = true
+			err := rollbackIAVLStore(db, version)
+			if err != nil {
+				return fmt.Errorf("Failed to roll back store %s to version %d: %v", key.Name(), version, err)
+			}
+		case sdk.StoreTypeMulti:
+			err := params.multi.rollback(version)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// The commits after version are incomplete in a nested multistore,
+	// or stale after loading an older version.
+	batch := rs.db.NewBatch()
+	for ver := version + 1; ; ver++ {
+		cInfoKey := []byte(fmt.Sprintf(commitInfoKeyFmt, ver))
+		if !rs.db.Has(cInfoKey) {
+			break
+		}
+		batch.Delete(cInfoKey)
+	}
+	if getLatestVersion(rs.db) != version {
+		setLatestVersion(batch, version)
+	}
+	batch.WriteSync()
+	return nil
+}
+
+// rollbackIAVLStore removes the versions after version from the IAVL
+// tree in db, from the latest one. The tree must not be loaded.
+//
+// Each version is removed like iavl would delete it if it wasn't the
+// latest: its root, the nodes it created, which are only in that
+// version, and the orphans it recorded, which are in the tree again.
+// The versions before version must not have been pruned since.
+func rollbackIAVLStore(db dbm.DB, version int64) error {
+	versions := iavlVersions(db)
+	for i := len(versions) - 1; i >= 0 && versions[i] > version; i-- {
+		ver := versions[i]
+		previous := int64(0)
+		if i > 0 {
+			previous = versions[i-1]
+		}
+		batch := db.NewBatch()
+
+		var rootKey []byte
+		err := walkIAVLNodes(db, ver, func(key, hash []byte) error {
+			rootKey = key
+			return nil
+		}, func(node iavlNode) (bool, error) {
+			// the nodes of the previous versions are in place
+			if node.Version < ver {
+				return false, nil
+			}
+			batch.Delete(node.Key)
+			return true, nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// orphaned by ver, their last version is the previous one
+		iter := dbm.IteratePrefix(db, []byte(fmt.Sprintf(iavlOrphanPrefix, previous)))
+		for ; iter.Valid(); iter.Next() {
+			batch.Delete(iter.Key())
+		}
+		iter.Close()
+
+		batch.Delete(rootKey)
+		batch.WriteSync()
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// errCrash is the panic of a crashDB which crashes.
+var errCrash = fmt.Errorf("crash")
+
+// crasher counts the batches written to its dbs, and makes them crash
+// instead of writing the batch after a given number of writes.
+type crasher struct {
+	writesLeft int // -1 for no crash
+}
+
+// crashDB is a db whose batch writes crash when its crasher says so,
+// to simulate a crash in the middle of a commit across dbs.
+type crashDB struct {
+	dbm.DB
+	crasher *crasher
+}
+
+func (db crashDB) NewBatch() dbm.Batch {
+	return crashBatch{db.DB.NewBatch(), db.crasher}
+}
+
+type crashBatch struct {
+	dbm.Batch
+	crasher *crasher
+}
+
+func (b crashBatch) Write() {
+	b.crash()
+	b.Batch.Write()
+}
+
+func (b crashBatch) WriteSync() {
+	b.crash()
+	b.Batch.WriteSync()
+}
+
+func (b crashBatch) crash() {
+	if b.crasher.writesLeft == 0 {
+		panic(errCrash)
+	}
+	if b.crasher.writesLeft > 0 {
+		b.crasher.writesLeft--
+	}
+}
+
+var crashStoreNames = []string{"store1", "store2", "store3"}
+
+// newCrashMultiStore mounts each store on its own db, all crashing with c.
+func newCrashMultiStore(c *crasher, db dbm.DB, dbs map[string]dbm.DB, pruning PruningStrategy) *rootMultiStore {
+	store := NewCommitMultiStore(crashDB{db, c})
+	store.SetPruning(pruning)
+	for _, name := range crashStoreNames {
+		store.MountStoreWithDB(sdk.NewKVStoreKey(name), sdk.StoreTypeIAVL, crashDB{dbs[name], c})
+	}
+	return store
+}
+
+func writeVersion(store *rootMultiStore, version int64, value string) {
+	for _, name := range crashStoreNames {
+		kv := store.getStoreByName(name).(KVStore)
+		kv.Set([]byte(fmt.Sprintf("key%d", version)), []byte(value))
+		kv.Set([]byte("last"), []byte(value))
+		kv.Delete([]byte(fmt.Sprintf("key%d", version-2)))
+	}
+}
+
+// Test that a commit interrupted after any number of stores is rolled
+// back to the previous version, and that another block can be committed.
+func TestMultistoreCommitCrash(t *testing.T) {
+	for _, pruning := range []PruningStrategy{sdk.PruneNothing, sdk.PruneEverything} {
+		// store1, store2 and store3 are written, then the commitInfo
+		for crashAt := 0; crashAt <= len(crashStoreNames); crashAt++ {
+			c := &crasher{writesLeft: -1}
+			db := dbm.NewMemDB()
+			dbs := make(map[string]dbm.DB)
+			for _, name := range crashStoreNames {
+				dbs[name] = dbm.NewMemDB()
+			}
+
+			store := newCrashMultiStore(c, db, dbs, pruning)
+			require.Nil(t, store.LoadLatestVersion())
+			for version := int64(1); version <= 3; version++ {
+				writeVersion(store, version, "value")
+				store.Commit()
+			}
+			committed := store.LastCommitID()
+
+			// crash in the commit of version 4
+			writeVersion(store, 4, "value")
+			c.writesLeft = crashAt
+			require.Panics(t, func() { store.Commit() }, "crash at %d", crashAt)
+			c.writesLeft = -1
+
+			// the stores are back at version 3
+			store = newCrashMultiStore(c, db, dbs, pruning)
+			require.Nil(t, store.LoadLatestVersion(), "crash at %d", crashAt)
+			require.Equal(t, committed, store.LastCommitID(), "crash at %d", crashAt)
+			for _, name := range crashStoreNames {
+				kv := store.getStoreByName(name).(KVStore)
+				require.Nil(t, kv.Get([]byte("key4")))
+				require.Equal(t, []byte("value"), kv.Get([]byte("key2")))
+			}
+
+			// another version 4 can be committed, like on a new db
+			writeVersion(store, 4, "other")
+			commitID := store.Commit()
+			writeVersion(store, 5, "other")
+			store.Commit()
+
+			expected := newCrashMultiStore(c, dbm.NewMemDB(), map[string]dbm.DB{
+				"store1": dbm.NewMemDB(), "store2": dbm.NewMemDB(), "store3": dbm.NewMemDB(),
+			}, pruning)
+			require.Nil(t, expected.LoadLatestVersion())
+			for version := int64(1); version <= 3; version++ {
+				writeVersion(expected, version, "value")
+				expected.Commit()
+			}
+			writeVersion(expected, 4, "other")
+			require.Equal(t, expected.Commit(), commitID, "crash at %d", crashAt)
+			writeVersion(expected, 5, "other")
+			require.Equal(t, expected.Commit(), store.LastCommitID(), "crash at %d", crashAt)
+
+			// and the rolled back stores load at the latest version
+			store = newCrashMultiStore(c, db, dbs, pruning)
+			require.Nil(t, store.LoadLatestVersion())
+			require.Equal(t, expected.LastCommitID(), store.LastCommitID())
+			if pruning.KeepRecent == 0 {
+				require.Nil(t, store.LoadVersion(3))
+				require.Equal(t, committed, store.LastCommitID())
+			}
+		}
+	}
+}
+
+// Test that a nested multistore committed before the crash is rolled
+// back with its parent.
+func TestNestedMultistoreCommitCrash(t *testing.T) {
+	c := &crasher{writesLeft: -1}
+	db, nestedDB := dbm.NewMemDB(), dbm.NewMemDB()
+	newStore := func() (*rootMultiStore, StoreKey) {
+		store := NewCommitMultiStore(crashDB{db, c})
+		key := sdk.NewKVStoreKey("nested")
+		nested := store.MountMultiStore(key, crashDB{nestedDB, c})
+		nested.MountStoreWithDB(sdk.NewKVStoreKey("inner"), sdk.StoreTypeIAVL, crashDB{dbm.NewPrefixDB(nestedDB, []byte("inner/")), c})
+		store.MountStoreWithDB(sdk.NewKVStoreKey("main"), sdk.StoreTypeIAVL, crashDB{dbm.NewPrefixDB(db, []byte("main/")), c})
+		return store, key
+	}
+	write := func(store *rootMultiStore, key StoreKey, value string) {
+		inner := store.GetCommitStore(key).(*rootMultiStore).getStoreByName("inner").(KVStore)
+		inner.Set([]byte("key"), []byte(value))
+		store.getStoreByName("main").(KVStore).Set([]byte("key"), []byte(value))
+	}
+
+	store, key := newStore()
+	require.Nil(t, store.LoadLatestVersion())
+	write(store, key, "value")
+	committed := store.Commit()
+
+	// the nested store and its commitInfo are written, not the parent's
+	write(store, key, "new")
+	c.writesLeft = 2
+	require.Panics(t, func() { store.Commit() })
+	c.writesLeft = -1
+
+	store, key = newStore()
+	require.Nil(t, store.LoadLatestVersion())
+	require.Equal(t, committed, store.LastCommitID())
+	nested := store.GetCommitStore(key).(*rootMultiStore)
+	require.Equal(t, int64(1), nested.LastCommitID().Version)
+	require.Equal(t, []byte("value"), nested.getStoreByName("inner").(KVStore).Get([]byte("key")))
+
+	write(store, key, "other")
+	require.Equal(t, int64(2), store.Commit().Version)
+}
@@ -15,7 +15,8 @@ import (
 
 const (
 	latestVersionKey = "s/latest"
-	commitInfoKeyFmt = "s/%d" // s/<version>
+	commitInfoKeyFmt = "s/%d"  // s/<version>
+	multiStorePrefix = "m/%s/" // m/<name>/, prefix of the db of a nested multistore
 )
 
 // rootMultiStore is composed of many CommitStores.
@@ -25,6 +26,7 @@ const (
 type rootMultiStore struct {
 	db           dbm.DB
 	lastCommitID CommitID
+	pruning      PruningStrategy
 	storesParams map[StoreKey]storeParams
 	stores       map[StoreKey]CommitStore
 	keysByName   map[string]StoreKey
@@ -48,20 +50,52 @@ func (rs *rootMultiStore) GetStoreType() StoreType {
 }
 
 // Implements CommitMultiStore.
-func (rs *rootMultiStore) MountStoreWithDB(key StoreKey, typ StoreType, db dbm.DB) {
+func (rs *rootMultiStore) MountStoreWithDB(key StoreKey, typ StoreType, db dbm.DB, opts ...StoreOption) {
 	if key == nil {
 		panic("MountIAVLStore() key cannot be nil")
 	}
 	if _, ok := rs.storesParams[key]; ok {
 		panic(fmt.Sprintf("rootMultiStore duplicate store key %v", key))
 	}
-	rs.storesParams[key] = storeParams{
-		db:  db,
-		typ: typ,
+	var options sdk.StoreOptions
+	for _, opt := range opts {
+		opt(&options)
 	}
+	if options.Pruning != nil {
+		if err := options.Pruning.ValidateBasic(); err != nil {
+			panic(err)
+		}
+	}
+	params := storeParams{
+		db:      db,
+		typ:     typ,
+		pruning: options.Pruning,
+	}
+	if typ == sdk.StoreTypeMulti {
+		if db == nil {
+			db = rs.db
+		}
+		prefix := []byte(fmt.Sprintf(multiStorePrefix, key.Name()))
+		params.multi = NewCommitMultiStore(dbm.NewPrefixDB(db, prefix))
+	}
+	rs.storesParams[key] = params
 	rs.keysByName[key.Name()] = key
 }
 
+// Implements CommitMultiStore.
+func (rs *rootMultiStore) MountMultiStore(key StoreKey, db dbm.DB, opts ...StoreOption) CommitMultiStore {
+	rs.MountStoreWithDB(key, sdk.StoreTypeMulti, db, opts...)
+	return rs.storesParams[key].multi
+}
+
+// Implements CommitMultiStore.
+func (rs *rootMultiStore) SetPruning(pruning PruningStrategy) {
+	if err := pruning.ValidateBasic(); err != nil {
+		panic(err)
+	}
+	rs.pruning = pruning
+}
+
 // Implements CommitMultiStore.
 func (rs *rootMultiStore) GetCommitStore(key StoreKey) CommitStore {
 	return rs.stores[key]
@@ -73,8 +107,14 @@ func (rs *rootMultiStore) GetCommitKVStore(key StoreKey) CommitKVStore {
 }
 
 // Implements CommitMultiStore.
+// The versions of the stores after the latest commitInfo, which were
+// partially committed, are rolled back before loading.
 func (rs *rootMultiStore) LoadLatestVersion() error {
 	ver := getLatestVersion(rs.db)
+	err := rs.rollback(ver)
+	if err != nil {
+		return err
+	}
 	return rs.LoadVersion(ver)
 }
 
@@ -116,10 +156,19 @@ func (rs *rootMultiStore) LoadVersion(ver int64) error {
 	}
 
 	// If any CommitStoreLoaders were not used, return error.
-	for key := range rs.storesParams {
-		if _, ok := newStores[key]; !ok {
+	// Transient stores aren't committed, they start empty.
+	for key, storeParams := range rs.storesParams {
+		if _, ok := newStores[key]; ok {
+			continue
+		}
+		if storeParams.typ != sdk.StoreTypeTransient {
 			return fmt.Errorf("Unused CommitStoreLoader: %v", key)
 		}
+		store, err := rs.loadCommitStoreFromParams(CommitID{}, storeParams)
+		if err != nil {
+			return fmt.Errorf("Failed to load rootMultiStore: %v", err)
+		}
+		newStores[key] = store
 	}
 
 	// Success.
@@ -138,6 +187,15 @@ func (rs *rootMultiStore) LastCommitID() CommitID {
 
 // Implements Committer/CommitStore.
 func (rs *rootMultiStore) Commit() CommitID {
+	commitID := rs.commit()
+	rs.prune(commitID.Version)
+	return commitID
+}
+
+// commit commits the stores and then the commitInfo, without pruning.
+// The stores may be on different dbs: if the commit is interrupted, the
+// stores committed so far are rolled back when loading the latest version.
+func (rs *rootMultiStore) commit() CommitID {
 
 	// Commit stores.
 	version := rs.lastCommitID.Version + 1
@@ -147,7 +205,7 @@ func (rs *rootMultiStore) Commit() CommitID {
 	batch := rs.db.NewBatch()
 	setCommitInfo(batch, version, commitInfo)
 	setLatestVersion(batch, version)
-	batch.Write()
+	batch.WriteSync()
 
 	// Prepare for next version.
 	commitID := CommitID{
@@ -158,6 +216,15 @@ func (rs *rootMultiStore) Commit() CommitID {
 	return commitID
 }
 
+// prune prunes the stores once version is committed in all of them.
+func (rs *rootMultiStore) prune(version int64) {
+	for _, store := range rs.stores {
+		if store, ok := store.(prunableStore); ok {
+			store.prune(version)
+		}
+	}
+}
+
 // Implements CacheWrapper/Store/CommitStore.
 func (rs *rootMultiStore) CacheWrap() CacheWrap {
 	return rs.CacheMultiStore().(CacheWrap)
@@ -171,6 +238,62 @@ func (rs *rootMultiStore) CacheMultiStore() CacheMultiStore {
 	return newCacheMultiStoreFromRMS(rs)
 }
 
+// Implements CommitMultiStore.
+// The IAVL stores read the version from their trees in memory, and the
+// other stores are loaded at the version from their dbs. They are
+// cache-wrapped with an in-memory db, so that writes to the returned
+// CacheMultiStore never reach the dbs. Stores mounted after the version
+// are empty.
+func (rs *rootMultiStore) CacheMultiStoreWithVersion(version int64) (CacheMultiStore, error) {
+	latest := rs.lastCommitID.Version
+	if version <= 0 || version > latest {
+		return nil, fmt.Errorf("version %d is not committed, the latest version is %d", version, latest)
+	}
+	cInfo, err := getCommitInfo(rs.db, version)
+	if err != nil {
+		return nil, fmt.Errorf("version %d is not retained: %v", version, err)
+	}
+	cms := cacheMultiStore{
+		db:         NewCacheKVStore(dbStoreAdapter{dbm.NewMemDB()}),
+		stores:     make(map[StoreKey]CacheWrap, len(rs.storesParams)),
+		keysByName: rs.keysByName,
+	}
+	for _, si := range cInfo.StoreInfos {
+		key, ok := rs.keysByName[si.Name]
+		if !ok {
+			continue // no longer mounted
+		}
+		params := rs.storesParams[key]
+		switch params.typ {
+		case sdk.StoreTypeMulti:
+			store, err := params.multi.CacheMultiStoreWithVersion(si.Core.CommitID.Version)
+			if err != nil {
+				return nil, err
+			}
+			cms.stores[key] = store.(CacheWrap)
+		case sdk.StoreTypeIAVL:
+			store, err := rs.stores[key].(*iavlStore).versionStore(si.Core.CommitID.Version)
+			if err != nil {
+				return nil, fmt.Errorf("store %s at version %d: %v", si.Name, version, err)
+			}
+			// the version is read-only: writes stop at the inner cache
+			cms.stores[key] = NewCacheKVStore(store).CacheWrap()
+		default:
+			store, err := rs.loadCommitStoreFromParams(si.Core.CommitID, params)
+			if err != nil {
+				return nil, fmt.Errorf("store %s at version %d: %v", si.Name, version, err)
+			}
+			cms.stores[key] = store.CacheWrap()
+		}
+	}
+	for key := range rs.storesParams {
+		if _, ok := cms.stores[key]; !ok {
+			cms.stores[key] = newTransientStore().CacheWrap()
+		}
+	}
+	return cms, nil
+}
+
 // Implements MultiStore.
 func (rs *rootMultiStore) GetStore(key StoreKey) Store {
 	return rs.stores[key]
@@ -181,6 +304,11 @@ func (rs *rootMultiStore) GetKVStore(key StoreKey) KVStore {
 	return rs.stores[key].(KVStore)
 }
 
+// Implements MultiStore.
+func (rs *rootMultiStore) GetKVStoreWithGas(meter sdk.GasMeter, key StoreKey) KVStore {
+	return NewGasKVStore(meter, rs.GetKVStore(key))
+}
+
 // getStoreByName will first convert the original name to
 // a special key, before looking up the CommitStore.
 // This is not exposed to the extensions (which will need the
@@ -199,7 +327,8 @@ func (rs *rootMultiStore) getStoreByName(name string) Store {
 // Query calls substore.Query with the same `req` where `req.Path` is
 // modified to remove the substore prefix.
 // Ie. `req.Path` here is `/<substore>/<path>`, and trimmed to `/<path>` for the substore.
-// TODO: add proof for `multistore -> substore`.
+// If req.Prove is set, the proof of the substore is extended to the root
+// hash of the multistore: the proof of the response is a MultiStoreProof.
 func (rs *rootMultiStore) Query(req abci.RequestQuery) abci.ResponseQuery {
 	// Query just routes this to a substore.
 	path := req.Path
@@ -222,9 +351,36 @@ func (rs *rootMultiStore) Query(req abci.RequestQuery) abci.ResponseQuery {
 	// trim the path and make the query
 	req.Path = subpath
 	res := queryable.Query(req)
+	if !req.Prove || res.Code != uint32(sdk.CodeOK) || len(res.Proof) == 0 {
+		return res
+	}
+
+	proof, perr := rs.multiStoreProof(storeName, res.Height, res.Proof)
+	if perr != nil {
+		return sdk.ErrInternal(perr.Error()).QueryResult()
+	}
+	res.Proof = proof
 	return res
 }
 
+// multiStoreProof extends the proof of a query of a substore at height
+// with the proof of the substore root in the commit of that height,
+// and returns the encoded MultiStoreProof.
+func (rs *rootMultiStore) multiStoreProof(storeName string, height int64, storeProof []byte) ([]byte, error) {
+	cInfo, err := getCommitInfo(rs.db, height)
+	if err != nil {
+		return nil, err
+	}
+	commitProof, err := cInfo.proof(storeName)
+	if err != nil {
+		return nil, err
+	}
+	return cdc.MarshalBinary(MultiStoreProof{
+		StoreProof:  storeProof,
+		CommitProof: commitProof,
+	})
+}
+
 // parsePath expects a format like /<storeName>[/<subpath>]
 // Must start with /, subpath may be empty
 // Returns error if it doesn't start with /
@@ -248,16 +404,24 @@ func (rs *rootMultiStore) loadCommitStoreFromParams(id CommitID, params storePar
 	if params.db != nil {
 		db = params.db
 	}
+	pruning := rs.pruning
+	if params.pruning != nil {
+		pruning = *params.pruning
+	}
 	switch params.typ {
 	case sdk.StoreTypeMulti:
-		panic("recursive MultiStores not yet supported")
-		// TODO: id?
-		// return NewCommitMultiStore(db, id)
+		params.multi.SetPruning(pruning)
+		err = params.multi.LoadVersion(id.Version)
+		store = params.multi
+		return
 	case sdk.StoreTypeIAVL:
-		store, err = LoadIAVLStore(db, id)
+		store, err = LoadIAVLStore(db, id, pruning)
 		return
 	case sdk.StoreTypeDB:
 		panic("dbm.DB is not a CommitStore")
+	case sdk.StoreTypeTransient:
+		store = newTransientStore()
+		return
 	default:
 		panic(fmt.Sprintf("unrecognized store type %v", params.typ))
 	}
@@ -272,12 +436,23 @@ func (rs *rootMultiStore) nameToKey(name string) StoreKey {
 	panic("Unknown name " + name)
 }
 
+// prunableStore is implemented by the stores which can commit a version
+// and prune their old versions separately. The rootMultiStore prunes them
+// only once a version is committed in every store, so that the versions
+// of a partial commit can be rolled back to the previous one.
+type prunableStore interface {
+	commit() CommitID
+	prune(version int64)
+}
+
 //----------------------------------------
 // storeParams
 
 type storeParams struct {
-	db  dbm.DB
-	typ StoreType
+	db      dbm.DB
+	typ     StoreType
+	pruning *PruningStrategy // nil for the pruning of the rootMultiStore
+	multi   *rootMultiStore  // the nested multistore, of StoreTypeMulti
 }
 
 //----------------------------------------
@@ -364,8 +539,18 @@ func commitStores(version int64, storeMap map[StoreKey]CommitStore) commitInfo {
 	storeInfos := make([]storeInfo, 0, len(storeMap))
 
 	for key, store := range storeMap {
-		// Commit
-		commitID := store.Commit()
+		// Commit, leaving the pruning until all stores are committed
+		var commitID CommitID
+		if pstore, ok := store.(prunableStore); ok {
+			commitID = pstore.commit()
+		} else {
+			commitID = store.Commit()
+		}
+
+		// Transient stores are wiped, they aren't part of the app hash
+		if store.GetStoreType() == sdk.StoreTypeTransient {
+			continue
+		}
 
 		// Record CommitID
 		si := storeInfo{}
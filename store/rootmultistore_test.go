@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	abci "github.com/tendermint/abci/types"
 	dbm "github.com/tendermint/tmlibs/db"
 	"github.com/tendermint/tmlibs/merkle"
@@ -71,6 +72,174 @@ func TestMultistoreCommitLoad(t *testing.T) {
 	checkStore(t, store, commitID, commitID)
 }
 
+func TestMultistorePruning(t *testing.T) {
+	key1, key2 := sdk.NewKVStoreKey("store1"), sdk.NewKVStoreKey("store2")
+	store := NewCommitMultiStore(dbm.NewMemDB())
+	store.SetPruning(sdk.PruneEverything)
+	store.MountStoreWithDB(key1, sdk.StoreTypeIAVL, dbm.NewMemDB())
+	store.MountStoreWithDB(key2, sdk.StoreTypeIAVL, dbm.NewMemDB(), sdk.WithPruning(sdk.PruneNothing))
+	err := store.LoadLatestVersion()
+	assert.Nil(t, err)
+
+	for i := 0; i < 5; i++ {
+		store.Commit()
+	}
+	tree1 := store.GetCommitStore(key1).(*iavlStore).tree
+	tree2 := store.GetCommitStore(key2).(*iavlStore).tree
+	for v := int64(1); v <= 5; v++ {
+		assert.Equal(t, v == 5, tree1.VersionExists(v), "version %d", v)
+		assert.True(t, tree2.VersionExists(v), "version %d", v)
+	}
+}
+
+func TestMultistoreTransient(t *testing.T) {
+	db := dbm.NewMemDB()
+	key, tkey := sdk.NewKVStoreKey("store1"), sdk.NewKVStoreKey("transient")
+	newStore := func() *rootMultiStore {
+		store := NewCommitMultiStore(db)
+		store.MountStoreWithDB(key, sdk.StoreTypeIAVL, nil)
+		store.MountStoreWithDB(tkey, sdk.StoreTypeTransient, nil)
+		return store
+	}
+	store := newStore()
+	err := store.LoadLatestVersion()
+	assert.Nil(t, err)
+
+	k, v := []byte("key"), []byte("value")
+	store.GetKVStore(key).Set(k, v)
+	store.GetKVStore(tkey).Set(k, v)
+
+	// writes through a cache reach the transient store
+	cache := store.CacheMultiStore()
+	k2 := []byte("key2")
+	cache.GetKVStore(tkey).Set(k2, v)
+	cache.Write()
+	assert.Equal(t, v, store.GetKVStore(tkey).Get(k2))
+
+	// the transient store is wiped and isn't part of the commit
+	commitID := store.Commit()
+	assert.Nil(t, store.GetKVStore(tkey).Get(k))
+	assert.Nil(t, store.GetKVStore(tkey).Get(k2))
+	assert.Equal(t, v, store.GetKVStore(key).Get(k))
+	cInfo, err := getCommitInfo(db, commitID.Version)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(cInfo.StoreInfos))
+	assert.Equal(t, "store1", cInfo.StoreInfos[0].Name)
+
+	// the app hash is the same as without the transient store
+	store.GetKVStore(tkey).Set(k, v)
+	commitID = store.Commit()
+	cInfo, err = getCommitInfo(db, commitID.Version)
+	assert.Nil(t, err)
+	assert.Equal(t, commitInfo{
+		Version:    2,
+		StoreInfos: []storeInfo{{"store1", storeCore{store.GetCommitStore(key).LastCommitID()}}},
+	}.Hash(), commitID.Hash)
+
+	// reloading gives an empty transient store
+	store = newStore()
+	err = store.LoadLatestVersion()
+	assert.Nil(t, err)
+	assert.Equal(t, commitID, store.LastCommitID())
+	assert.Nil(t, store.GetKVStore(tkey).Get(k))
+	assert.Equal(t, v, store.GetKVStore(key).Get(k))
+}
+
+func TestNestedMultistore(t *testing.T) {
+	db := dbm.NewMemDB()
+	key, multiKey := sdk.NewKVStoreKey("store1"), sdk.NewKVStoreKey("multi")
+	subKey := sdk.NewKVStoreKey("store1") // same name, in the nested store
+	newStore := func() (*rootMultiStore, CommitMultiStore) {
+		store := NewCommitMultiStore(db)
+		store.MountStoreWithDB(key, sdk.StoreTypeIAVL, nil)
+		nested := store.MountMultiStore(multiKey, nil)
+		nested.MountStoreWithDB(subKey, sdk.StoreTypeIAVL, nil)
+		return store, nested
+	}
+	store, nested := newStore()
+	err := store.LoadLatestVersion()
+	require.Nil(t, err)
+	assert.Equal(t, nested, store.GetCommitStore(multiKey))
+
+	// the keyspaces are isolated
+	k := []byte("key")
+	store.GetKVStore(key).Set(k, []byte("parent"))
+	nested.GetKVStore(subKey).Set(k, []byte("nested"))
+
+	// writes through caches reach the nested stores
+	cache := store.CacheMultiStore()
+	k2 := []byte("key2")
+	cache.GetStore(multiKey).(MultiStore).GetKVStore(subKey).Set(k2, []byte("cached"))
+	cache.Write()
+	assert.Equal(t, []byte("cached"), nested.GetKVStore(subKey).Get(k2))
+
+	// the nested store is committed with the others
+	commitID := store.Commit()
+	assert.Equal(t, int64(1), nested.LastCommitID().Version)
+	cInfo, err := getCommitInfo(db, 1)
+	require.Nil(t, err)
+	assert.Equal(t, 2, len(cInfo.StoreInfos))
+	assert.Equal(t, commitID.Hash, cInfo.Hash())
+	for _, si := range cInfo.StoreInfos {
+		if si.Name == "multi" {
+			assert.Equal(t, nested.LastCommitID(), si.Core.CommitID)
+		}
+	}
+
+	// and reloaded
+	store, nested = newStore()
+	err = store.LoadLatestVersion()
+	require.Nil(t, err)
+	assert.Equal(t, commitID, store.LastCommitID())
+	assert.Equal(t, []byte("parent"), store.GetKVStore(key).Get(k))
+	assert.Equal(t, []byte("nested"), nested.GetKVStore(subKey).Get(k))
+	assert.Equal(t, []byte("cached"), nested.GetKVStore(subKey).Get(k2))
+
+	// queries are routed through the nested store
+	res := store.Query(abci.RequestQuery{Path: "/multi/store1/key", Data: k, Height: 1})
+	assert.Equal(t, uint32(sdk.CodeOK), res.Code)
+	assert.Equal(t, []byte("nested"), res.Value)
+}
+
+func TestMultistoreCacheWithVersion(t *testing.T) {
+	key1, key2 := sdk.NewKVStoreKey("store1"), sdk.NewKVStoreKey("store2")
+	store := NewCommitMultiStore(dbm.NewMemDB())
+	store.SetPruning(sdk.NewPruningStrategy(2, 0))
+	store.MountStoreWithDB(key1, sdk.StoreTypeIAVL, dbm.NewMemDB())
+	nested := store.MountMultiStore(key2, nil)
+	nested.MountStoreWithDB(key1, sdk.StoreTypeIAVL, dbm.NewMemDB())
+	require.Nil(t, store.LoadLatestVersion())
+
+	k := []byte("key")
+	for i := byte(1); i <= 4; i++ {
+		store.GetKVStore(key1).Set(k, []byte{i})
+		store.GetCommitStore(key2).(CommitMultiStore).GetKVStore(key1).Set(k, []byte{i + 10})
+		store.Commit()
+	}
+
+	for _, version := range []int64{3, 4} {
+		cms, err := store.CacheMultiStoreWithVersion(version)
+		require.Nil(t, err)
+		require.Equal(t, []byte{byte(version)}, cms.GetKVStore(key1).Get(k))
+		nestedStore := cms.GetStore(key2).(CacheMultiStore)
+		require.Equal(t, []byte{byte(version) + 10}, nestedStore.GetKVStore(key1).Get(k))
+
+		// writes are discarded
+		cms.GetKVStore(key1).Set(k, []byte("other"))
+		cms.Write()
+	}
+	require.Equal(t, []byte{4}, store.GetKVStore(key1).Get(k))
+	cms, err := store.CacheMultiStoreWithVersion(3)
+	require.Nil(t, err)
+	require.Equal(t, []byte{3}, cms.GetKVStore(key1).Get(k))
+
+	// pruned and future versions
+	for _, version := range []int64{0, 1, 2, 5} {
+		_, err := store.CacheMultiStoreWithVersion(version)
+		require.NotNil(t, err, "version %d", version)
+	}
+}
+
 func TestParsePath(t *testing.T) {
 	_, _, err := parsePath("foo")
 	assert.Error(t, err)
@@ -0,0 +1,214 @@
+package store
+
+import (
+	"bytes"
+)
+
+const (
+	// skipListMaxLevel bounds the levels of the nodes, enough for
+	// 4^16 keys with the probability below.
+	skipListMaxLevel = 16
+
+	// A node of level n has a level n+1 with probability 1/4.
+	skipListBranching = 4
+)
+
+// skipList is a sorted map of keys to values, which are inserted or
+// updated but never removed. Its nodes are linked in both directions
+// on the lowest level, to iterate in either order from any node.
+//
+// The levels of the nodes come from a pseudo-random sequence of fixed
+// seed: they only matter for the speed of the lookups, so they don't
+// need better randomness, and creating a list doesn't allocate a source.
+type skipList struct {
+	head  skipListNode // before the first node, on every level
+	tail  *skipListNode
+	level int
+	seed  uint64
+}
+
+type skipListNode struct {
+	key   []byte
+	value []byte
+	prev  *skipListNode // on the lowest level, nil for the first node
+	next  []*skipListNode
+}
+
+func newSkipList() *skipList {
+	return &skipList{
+		head:  skipListNode{next: make([]*skipListNode, skipListMaxLevel)},
+		level: 1,
+		seed:  0x9E3779B97F4A7C15,
+	}
+}
+
+// randomLevel returns the level of a new node.
+func (sl *skipList) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel {
+		// xorshift64
+		sl.seed ^= sl.seed << 13
+		sl.seed ^= sl.seed >> 7
+		sl.seed ^= sl.seed << 17
+		if sl.seed%skipListBranching != 0 {
+			break
+		}
+		level++
+	}
+	return level
+}
+
+// set inserts a copy of key with value, or updates its value if key
+// is in the list.
+func (sl *skipList) set(key, value []byte) {
+	var update [skipListMaxLevel]*skipListNode
+	node := &sl.head
+	for i := sl.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && bytes.Compare(node.next[i].key, key) < 0 {
+			node = node.next[i]
+		}
+		update[i] = node
+	}
+	if next := node.next[0]; next != nil && bytes.Equal(next.key, key) {
+		next.value = value
+		return
+	}
+
+	level := sl.randomLevel()
+	for i := sl.level; i < level; i++ {
+		update[i] = &sl.head
+	}
+	if level > sl.level {
+		sl.level = level
+	}
+	node = &skipListNode{
+		key:   cp(key),
+		value: value,
+		next:  make([]*skipListNode, level),
+	}
+	for i := 0; i < level; i++ {
+		node.next[i] = update[i].next[i]
+		update[i].next[i] = node
+	}
+	if update[0] != &sl.head {
+		node.prev = update[0]
+	}
+	if node.next[0] != nil {
+		node.next[0].prev = node
+	} else {
+		sl.tail = node
+	}
+}
+
+// seek returns the first node whose key is not less than key,
+// or the first node if key is nil, or nil if there is none.
+func (sl *skipList) seek(key []byte) *skipListNode {
+	node := &sl.head
+	if key != nil {
+		for i := sl.level - 1; i >= 0; i-- {
+			for node.next[i] != nil && bytes.Compare(node.next[i].key, key) < 0 {
+				node = node.next[i]
+			}
+		}
+	}
+	return node.next[0]
+}
+
+// seekBefore returns the last node whose key is less than key,
+// or the last node if key is nil, or nil if there is none.
+func (sl *skipList) seekBefore(key []byte) *skipListNode {
+	if key == nil {
+		return sl.tail
+	}
+	node := sl.seek(key)
+	if node == nil {
+		return sl.tail
+	}
+	return node.prev
+}
+
+// first returns the first node, or nil if the list is empty.
+func (sl *skipList) first() *skipListNode {
+	return sl.head.next[0]
+}
+
+//----------------------------------------
+
+// skipListIterator iterates over the nodes of a skipList whose keys
+// are in [start, end), in ascending or descending order.
+// Implements Iterator.
+//
+// It walks the nodes of the list as they are: an update of a value is
+// seen if the iterator hasn't passed its key yet, and so is a key
+// inserted ahead of it. As for other iterators, the domain mustn't be
+// written while the iterator is open.
+type skipListIterator struct {
+	start, end []byte
+	ascending  bool
+	node       *skipListNode
+}
+
+var _ Iterator = (*skipListIterator)(nil)
+
+func newSkipListIterator(sl *skipList, start, end []byte, ascending bool) *skipListIterator {
+	iter := &skipListIterator{
+		start:     start,
+		end:       end,
+		ascending: ascending,
+	}
+	if ascending {
+		iter.node = sl.seek(start)
+	} else {
+		iter.node = sl.seekBefore(end)
+	}
+	return iter
+}
+
+// Implements Iterator.
+func (iter *skipListIterator) Domain() ([]byte, []byte) {
+	return iter.start, iter.end
+}
+
+// Implements Iterator.
+func (iter *skipListIterator) Valid() bool {
+	if iter.node == nil {
+		return false
+	}
+	if iter.ascending {
+		return iter.end == nil || bytes.Compare(iter.node.key, iter.end) < 0
+	}
+	return iter.start == nil || bytes.Compare(iter.node.key, iter.start) >= 0
+}
+
+func (iter *skipListIterator) assertValid() {
+	if !iter.Valid() {
+		panic("skipListIterator is invalid")
+	}
+}
+
+// Implements Iterator.
+func (iter *skipListIterator) Next() {
+	iter.assertValid()
+	if iter.ascending {
+		iter.node = iter.node.next[0]
+	} else {
+		iter.node = iter.node.prev
+	}
+}
+
+// Implements Iterator.
+func (iter *skipListIterator) Key() []byte {
+	iter.assertValid()
+	return iter.node.key
+}
+
+// Implements Iterator.
+func (iter *skipListIterator) Value() []byte {
+	iter.assertValid()
+	return iter.node.value
+}
+
+// Implements Iterator.
+func (iter *skipListIterator) Close() {
+	iter.node = nil
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkipList(t *testing.T) {
+	sl := newSkipList()
+	require.Nil(t, sl.first())
+	require.Nil(t, sl.seek(nil))
+	require.Nil(t, sl.seekBefore(nil))
+
+	key := bz("key2")
+	sl.set(key, bz("value2"))
+	sl.set(bz("key1"), bz("value1"))
+	sl.set(bz("key3"), nil)
+	sl.set(bz("key2"), bz("updated"))
+	// the key is copied
+	key[0] = 'x'
+
+	var keys, values []string
+	for node := sl.first(); node != nil; node = node.next[0] {
+		keys = append(keys, string(node.key))
+		values = append(values, string(node.value))
+	}
+	require.Equal(t, []string{"key1", "key2", "key3"}, keys)
+	require.Equal(t, []string{"value1", "updated", ""}, values)
+
+	require.Equal(t, bz("key2"), sl.seek(bz("key11")).key)
+	require.Nil(t, sl.seek(bz("key4")))
+	require.Equal(t, bz("key1"), sl.seekBefore(bz("key11")).key)
+	require.Nil(t, sl.seekBefore(bz("key1")))
+	require.Equal(t, bz("key3"), sl.seekBefore(bz("key4")).key)
+	require.Equal(t, bz("key3"), sl.seekBefore(nil).key)
+}
+
+// Test the iterators of a skipList against the sorted keys, on random
+// domains in both directions.
+func TestSkipListIterator(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sl := newSkipList()
+	inserted := make(map[int]bool)
+	for i := 0; i < 1000; i++ {
+		k := r.Intn(2000)
+		sl.set(keyFmt(k), valFmt(k))
+		inserted[k] = true
+	}
+	var keys [][]byte
+	for k := range inserted {
+		keys = append(keys, keyFmt(k))
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+
+	for i := 0; i < 200; i++ {
+		var start, end []byte
+		if i%4 != 0 {
+			start = keyFmt(r.Intn(2000))
+		}
+		if i%5 != 0 {
+			end = keyFmt(r.Intn(2000))
+		}
+		var expected [][]byte
+		for _, key := range keys {
+			if (start == nil || bytes.Compare(key, start) >= 0) &&
+				(end == nil || bytes.Compare(key, end) < 0) {
+				expected = append(expected, key)
+			}
+		}
+
+		var ascending [][]byte
+		for iter := newSkipListIterator(sl, start, end, true); iter.Valid(); iter.Next() {
+			ascending = append(ascending, iter.Key())
+		}
+		require.Equal(t, expected, ascending, "[%s, %s)", start, end)
+
+		var descending [][]byte
+		for iter := newSkipListIterator(sl, start, end, false); iter.Valid(); iter.Next() {
+			descending = append([][]byte{iter.Key()}, descending...)
+		}
+		require.Equal(t, expected, descending, "reverse [%s, %s)", start, end)
+	}
+}
@@ -0,0 +1,358 @@
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// DefaultSnapshotChunkSize is the approximate size in bytes of
+	// the chunks of a snapshot.
+	DefaultSnapshotChunkSize = 10 << 20
+
+	snapshotManifestFile = "manifest"
+)
+
+// Snapshot is the manifest of a snapshot of the stores of a rootMultiStore
+// at a committed version. The chunks of the snapshot hold the nodes of
+// the IAVL trees of the stores at that version, from which a node rebuilds
+// the stores with the same hashes. The commitInfo of the version ties the
+// store hashes to the CommitID.
+type Snapshot struct {
+	CommitID    CommitID // of the rootMultiStore at the version
+	CommitInfo  []byte   // the encoded commitInfo of the version
+	ChunkHashes [][]byte // the sha256 hash of each chunk
+	Metadata    []byte   // data of the app, eg. the block header
+}
+
+// Version is the version of the stores in the snapshot.
+func (s Snapshot) Version() int64 {
+	return s.CommitID.Version
+}
+
+// Snapshotter is implemented by the CommitMultiStores which can be
+// snapshotted, eg. the rootMultiStore. The snapshot of each version is
+// kept in its own directory under dir.
+// Only the IAVL and transient stores can be snapshotted: a rootMultiStore
+// with nested multistores can't, and creating or restoring its snapshots
+// fails.
+type Snapshotter interface {
+
+	// Snapshot the committed version of the stores in chunks of
+	// about chunkSize bytes, with the metadata of the app.
+	CreateSnapshot(dir string, version int64, chunkSize int, metadata []byte) (Snapshot, error)
+
+	// Restore the stores from the snapshot of version and load them.
+	// The stores must be empty.
+	RestoreSnapshot(dir string, version int64) (Snapshot, error)
+}
+
+var _ Snapshotter = (*rootMultiStore)(nil)
+
+// snapshotItem is an entry of the db of a store.
+type snapshotItem struct {
+	Store string
+	Key   []byte
+	Value []byte
+}
+
+// ListSnapshots returns the snapshots in dir, from the oldest to the latest.
+func ListSnapshots(dir string) ([]Snapshot, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []Snapshot
+	for _, file := range files {
+		version, err := strconv.ParseInt(file.Name(), 10, 64)
+		if err != nil || !file.IsDir() {
+			continue // not a snapshot
+		}
+		snapshot, err := readSnapshotManifest(dir, version)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Version() < snapshots[j].Version()
+	})
+	return snapshots, nil
+}
+
+// Implements Snapshotter.
+// The snapshot is written in a temporary directory, which is moved
+// to dir/<version> when complete.
+func (rs *rootMultiStore) CreateSnapshot(dir string, version int64, chunkSize int, metadata []byte) (Snapshot, error) {
+	cInfo, err := getCommitInfo(rs.db, version)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("no commit of version %d: %v", version, err)
+	}
+	dbs, err := rs.snapshotDBs(cInfo)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	cInfoBytes, err := cdc.MarshalBinary(cInfo)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	tmpDir, err := ioutil.TempDir(dir, "tmp")
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshot := Snapshot{
+		CommitID:   cInfo.CommitID(),
+		CommitInfo: cInfoBytes,
+		Metadata:   metadata,
+	}
+	var chunk []snapshotItem
+	size := 0
+	writeChunk := func() error {
+		bz, err := cdc.MarshalBinary(chunk)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(bz)
+		file := filepath.Join(tmpDir, strconv.Itoa(len(snapshot.ChunkHashes)))
+		err = ioutil.WriteFile(file, bz, 0600)
+		if err != nil {
+			return err
+		}
+		snapshot.ChunkHashes = append(snapshot.ChunkHashes, hash[:])
+		chunk, size = nil, 0
+		return nil
+	}
+	add := func(item snapshotItem) error {
+		chunk = append(chunk, item)
+		size += len(item.Key) + len(item.Value)
+		if size >= chunkSize {
+			return writeChunk()
+		}
+		return nil
+	}
+
+	// the stores by name, each root then its nodes from the top
+	for _, si := range sortedStoreInfos(cInfo) {
+		err := walkIAVLNodes(dbs[si.Name], version, func(key, hash []byte) error {
+			return add(snapshotItem{si.Name, key, hash})
+		}, func(node iavlNode) (bool, error) {
+			return true, add(snapshotItem{si.Name, node.Key, node.Value})
+		})
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("store %s: %v", si.Name, err)
+		}
+	}
+	if len(chunk) > 0 || len(snapshot.ChunkHashes) == 0 {
+		err = writeChunk()
+		if err != nil {
+			return Snapshot{}, err
+		}
+	}
+
+	bz, err := cdc.MarshalBinary(snapshot)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	err = ioutil.WriteFile(filepath.Join(tmpDir, snapshotManifestFile), bz, 0600)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	snapshotDir := filepath.Join(dir, strconv.FormatInt(version, 10))
+	err = os.RemoveAll(snapshotDir)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	err = os.Rename(tmpDir, snapshotDir)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// Implements Snapshotter.
+// Each chunk is checked against its hash in the manifest, and each node
+// against its hash and the root hash of its store in the commitInfo,
+// which is checked against the CommitID of the snapshot.
+func (rs *rootMultiStore) RestoreSnapshot(dir string, version int64) (Snapshot, error) {
+	if !rs.lastCommitID.IsZero() || getLatestVersion(rs.db) != 0 {
+		return Snapshot{}, fmt.Errorf("can't restore a snapshot over the committed version %d", rs.lastCommitID.Version)
+	}
+	snapshot, err := readSnapshotManifest(dir, version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	var cInfo commitInfo
+	err = cdc.UnmarshalBinary(snapshot.CommitInfo, &cInfo)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("invalid commit info: %v", err)
+	}
+	if cInfo.Version != version || !bytes.Equal(cInfo.Hash(), snapshot.CommitID.Hash) {
+		return Snapshot{}, fmt.Errorf("commit info doesn't match %v", snapshot.CommitID)
+	}
+	dbs, err := rs.snapshotDBs(cInfo)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	// the nodes expected in each store: the root and the children of
+	// the nodes restored so far
+	expected := make(map[string]map[string]bool)
+	rooted := make(map[string]bool)
+	roots := make(map[string][]byte)
+	for _, si := range cInfo.StoreInfos {
+		expected[si.Name] = make(map[string]bool)
+		roots[si.Name] = si.Core.CommitID.Hash
+	}
+	batches := make(map[string]dbm.Batch)
+	for name, db := range dbs {
+		batches[name] = db.NewBatch()
+	}
+
+	rootKey := iavlRootKey(version)
+	for i, chunkHash := range snapshot.ChunkHashes {
+		bz, err := ioutil.ReadFile(filepath.Join(dir, strconv.FormatInt(version, 10), strconv.Itoa(i)))
+		if err != nil {
+			return Snapshot{}, err
+		}
+		hash := sha256.Sum256(bz)
+		if !bytes.Equal(hash[:], chunkHash) {
+			return Snapshot{}, fmt.Errorf("chunk %d doesn't match its hash %X", i, chunkHash)
+		}
+		var chunk []snapshotItem
+		err = cdc.UnmarshalBinary(bz, &chunk)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("invalid chunk %d: %v", i, err)
+		}
+
+		for _, item := range chunk {
+			nodes, ok := expected[item.Store]
+			if !ok {
+				return Snapshot{}, fmt.Errorf("unknown store %s in chunk %d", item.Store, i)
+			}
+			if bytes.Equal(item.Key, rootKey) {
+				if rooted[item.Store] || !bytes.Equal(item.Value, roots[item.Store]) {
+					return Snapshot{}, fmt.Errorf("invalid root of store %s", item.Store)
+				}
+				rooted[item.Store] = true
+				if len(item.Value) > 0 {
+					nodes[string(item.Value)] = true
+				}
+				batches[item.Store].Set(item.Key, item.Value)
+				continue
+			}
+
+			hash, _, children, err := decodeIAVLNode(item.Value)
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("invalid node of store %s: %v", item.Store, err)
+			}
+			if !nodes[string(hash)] || !bytes.Equal(item.Key, iavlNodeKey(hash)) {
+				return Snapshot{}, fmt.Errorf("unexpected node %X of store %s", hash, item.Store)
+			}
+			delete(nodes, string(hash))
+			for _, child := range children {
+				nodes[string(child)] = true
+			}
+			batches[item.Store].Set(item.Key, item.Value)
+		}
+	}
+	for name, nodes := range expected {
+		if !rooted[name] || len(nodes) > 0 {
+			return Snapshot{}, fmt.Errorf("snapshot of store %s is incomplete", name)
+		}
+	}
+
+	// write the stores, then the commit, and load the version
+	for _, batch := range batches {
+		batch.WriteSync()
+	}
+	batch := rs.db.NewBatch()
+	setCommitInfo(batch, version, cInfo)
+	setLatestVersion(batch, version)
+	batch.WriteSync()
+
+	err = rs.LoadVersion(version)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if !bytes.Equal(rs.lastCommitID.Hash, snapshot.CommitID.Hash) {
+		return Snapshot{}, fmt.Errorf("restored hash %X doesn't match %v", rs.lastCommitID.Hash, snapshot.CommitID)
+	}
+	return snapshot, nil
+}
+
+// snapshotDBs returns the dbs of the stores committed in cInfo, which
+// must be the IAVL stores mounted in the rootMultiStore, not nested
+// multistores.
+func (rs *rootMultiStore) snapshotDBs(cInfo commitInfo) (map[string]dbm.DB, error) {
+	dbs := make(map[string]dbm.DB)
+	for _, si := range cInfo.StoreInfos {
+		key, ok := rs.keysByName[si.Name]
+		if !ok {
+			return nil, fmt.Errorf("store %s isn't mounted", si.Name)
+		}
+		params := rs.storesParams[key]
+		switch params.typ {
+		case sdk.StoreTypeIAVL:
+		case sdk.StoreTypeMulti:
+			return nil, fmt.Errorf("store %s is a nested multistore, which can't be snapshotted yet", si.Name)
+		default:
+			return nil, fmt.Errorf("store %s isn't an IAVL store, it can't be snapshotted", si.Name)
+		}
+		dbs[si.Name] = rs.db
+		if params.db != nil {
+			dbs[si.Name] = params.db
+		}
+	}
+	for key, params := range rs.storesParams {
+		if _, ok := dbs[key.Name()]; !ok && params.typ != sdk.StoreTypeTransient {
+			return nil, fmt.Errorf("store %s isn't in the commit", key.Name())
+		}
+	}
+	return dbs, nil
+}
+
+func readSnapshotManifest(dir string, version int64) (Snapshot, error) {
+	file := filepath.Join(dir, strconv.FormatInt(version, 10), snapshotManifestFile)
+	bz, err := ioutil.ReadFile(file)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("no snapshot of version %d: %v", version, err)
+	}
+	var snapshot Snapshot
+	err = cdc.UnmarshalBinary(bz, &snapshot)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("invalid snapshot of version %d: %v", version, err)
+	}
+	if snapshot.Version() != version {
+		return Snapshot{}, fmt.Errorf("snapshot in %s is of version %d", file, snapshot.Version())
+	}
+	return snapshot, nil
+}
+
+func sortedStoreInfos(cInfo commitInfo) []storeInfo {
+	infos := make([]storeInfo, len(cInfo.StoreInfos))
+	copy(infos, cInfo.StoreInfos)
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
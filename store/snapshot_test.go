@@ -0,0 +1,127 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func newSnapshotMultiStore(t *testing.T) *rootMultiStore {
+	store := NewCommitMultiStore(dbm.NewMemDB())
+	for _, name := range []string{"store1", "store2", "store3"} {
+		store.MountStoreWithDB(sdk.NewKVStoreKey(name), sdk.StoreTypeIAVL, dbm.NewMemDB())
+	}
+	store.MountStoreWithDB(sdk.NewKVStoreKey("transient"), sdk.StoreTypeTransient, nil)
+	require.Nil(t, store.LoadLatestVersion())
+	return store
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := newSnapshotMultiStore(t)
+	s1 := store.getStoreByName("store1").(KVStore)
+	s2 := store.getStoreByName("store2").(KVStore)
+	for i := 0; i < 100; i++ {
+		s1.Set([]byte{byte(i)}, []byte{byte(i), 1})
+		if i%3 == 0 {
+			s2.Set([]byte{byte(i)}, []byte{byte(i), 2})
+		}
+	}
+	store.Commit()
+	s1.Delete([]byte{5})
+	s1.Set([]byte{200}, []byte("new"))
+	commitID := store.Commit()
+	s1.Set([]byte{201}, []byte("later"))
+	store.Commit()
+
+	// small chunks to spread the stores over many chunks
+	snapshot, err := store.CreateSnapshot(dir, 2, 64, []byte("meta"))
+	require.Nil(t, err)
+	require.Equal(t, commitID, snapshot.CommitID)
+	require.True(t, len(snapshot.ChunkHashes) > 1)
+
+	_, err = store.CreateSnapshot(dir, 9, 64, nil)
+	require.NotNil(t, err)
+
+	snapshots, err := ListSnapshots(dir)
+	require.Nil(t, err)
+	require.Equal(t, []Snapshot{snapshot}, snapshots)
+
+	// a fresh store resumes at the version of the snapshot
+	restored := newSnapshotMultiStore(t)
+	got, err := restored.RestoreSnapshot(dir, 2)
+	require.Nil(t, err)
+	require.Equal(t, []byte("meta"), got.Metadata)
+	require.Equal(t, commitID, restored.LastCommitID())
+	r1 := restored.getStoreByName("store1").(KVStore)
+	require.Nil(t, r1.Get([]byte{5}))
+	require.Equal(t, []byte("new"), r1.Get([]byte{200}))
+	require.Nil(t, r1.Get([]byte{201}))
+	require.Equal(t, []byte{9, 2}, restored.getStoreByName("store2").(KVStore).Get([]byte{9}))
+
+	// and commits the same next versions
+	r1.Set([]byte{201}, []byte("later"))
+	require.Equal(t, store.LastCommitID(), restored.Commit())
+
+	// can't restore over committed stores
+	_, err = restored.RestoreSnapshot(dir, 2)
+	require.NotNil(t, err)
+}
+
+func TestSnapshotRestoreCorrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	store := newSnapshotMultiStore(t)
+	store.getStoreByName("store1").(KVStore).Set([]byte("foo"), []byte("bar"))
+	store.Commit()
+	_, err = store.CreateSnapshot(dir, 1, DefaultSnapshotChunkSize, nil)
+	require.Nil(t, err)
+
+	// a chunk which doesn't match its hash is rejected
+	chunk := filepath.Join(dir, "1", "0")
+	bz, err := ioutil.ReadFile(chunk)
+	require.Nil(t, err)
+	bz[len(bz)-1] ^= 0xff
+	require.Nil(t, ioutil.WriteFile(chunk, bz, 0600))
+	_, err = newSnapshotMultiStore(t).RestoreSnapshot(dir, 1)
+	require.NotNil(t, err)
+
+	// missing snapshot
+	_, err = newSnapshotMultiStore(t).RestoreSnapshot(dir, 2)
+	require.NotNil(t, err)
+
+	// the mounted stores must match the snapshot
+	other := NewCommitMultiStore(dbm.NewMemDB())
+	other.MountStoreWithDB(sdk.NewKVStoreKey("store1"), sdk.StoreTypeIAVL, dbm.NewMemDB())
+	require.Nil(t, other.LoadLatestVersion())
+	_, err = other.RestoreSnapshot(dir, 1)
+	require.NotNil(t, err)
+}
+
+func TestSnapshotNestedMultiStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "snapshots")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// nested multistores can't be snapshotted
+	store := NewCommitMultiStore(dbm.NewMemDB())
+	store.MountStoreWithDB(sdk.NewKVStoreKey("store1"), sdk.StoreTypeIAVL, dbm.NewMemDB())
+	nested := store.MountMultiStore(sdk.NewKVStoreKey("multi"), nil)
+	nested.MountStoreWithDB(sdk.NewKVStoreKey("store1"), sdk.StoreTypeIAVL, dbm.NewMemDB())
+	require.Nil(t, store.LoadLatestVersion())
+	store.Commit()
+	_, err = store.CreateSnapshot(dir, 1, DefaultSnapshotChunkSize, nil)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "nested multistore")
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+
+	cmn "github.com/tendermint/tmlibs/common"
+)
+
+// StateChange is a write to a store committed in the block of Height,
+// by the tx of TxIndex in the block, or outside of the txs, eg. in
+// BeginBlock or EndBlock, if TxIndex is -1.
+type StateChange struct {
+	Height    int64        `json:"height"`
+	TxIndex   int64        `json:"tx_index"`
+	StoreName string       `json:"store"`
+	Key       cmn.HexBytes `json:"key"`
+	Value     cmn.HexBytes `json:"value,omitempty"`
+	Deleted   bool         `json:"deleted,omitempty"`
+}
+
+// StateSink receives the writes to the stores of each committed block,
+// eg. to follow the state from an external indexer.
+type StateSink interface {
+
+	// Called after the commit of each block, with its state changes
+	// in the order they were written.
+	WriteBlock(height int64, changes []StateChange) error
+}
+
+var _ StateSink = (*FileStateSink)(nil)
+
+// FileStateSink appends the state changes to a file, one JSON
+// object per line, which is synced after each block.
+type FileStateSink struct {
+	file *os.File
+}
+
+// NewFileStateSink opens the file at path, creating it if needed,
+// to append the state changes to it.
+func NewFileStateSink(path string) (*FileStateSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileStateSink{file}, nil
+}
+
+// Implements StateSink.
+func (s *FileStateSink) WriteBlock(height int64, changes []StateChange) error {
+	w := bufio.NewWriter(s.file)
+	enc := json.NewEncoder(w)
+	for _, change := range changes {
+		err := enc.Encode(change)
+		if err != nil {
+			return err
+		}
+	}
+	err := w.Flush()
+	if err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file.
+func (s *FileStateSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// writeRecorder records the writes it is notified of.
+type writeRecorder struct {
+	changes []StateChange
+}
+
+func (r *writeRecorder) OnWrite(storeName string, key, value []byte, deleted bool) {
+	r.changes = append(r.changes, StateChange{StoreName: storeName, Key: key, Value: value, Deleted: deleted})
+}
+
+func TestCacheKVStoreWriteListener(t *testing.T) {
+	mem := dbStoreAdapter{dbm.NewMemDB()}
+	mem.Set(keyFmt(1), valFmt(1))
+	st := NewCacheKVStore(mem)
+	r := &writeRecorder{}
+	st.SetWriteListener("store", r)
+
+	st.Set(keyFmt(3), valFmt(3))
+	st.Delete(keyFmt(1))
+	st.Set(keyFmt(2), valFmt(2))
+	st.Get(keyFmt(4))
+	st.Write()
+
+	// in the order of the keys, without the reads
+	require.Equal(t, []StateChange{
+		{StoreName: "store", Key: keyFmt(1), Deleted: true},
+		{StoreName: "store", Key: keyFmt(2), Value: valFmt(2)},
+		{StoreName: "store", Key: keyFmt(3), Value: valFmt(3)},
+	}, r.changes)
+}
+
+func TestCacheMultiStoreWriteListener(t *testing.T) {
+	key1, key2 := sdk.NewKVStoreKey("store1"), sdk.NewKVStoreKey("store2")
+	nestedKey, tkey := sdk.NewKVStoreKey("nested"), sdk.NewKVStoreKey("transient")
+	store := NewCommitMultiStore(dbm.NewMemDB())
+	store.MountStoreWithDB(key2, sdk.StoreTypeIAVL, dbm.NewMemDB())
+	store.MountStoreWithDB(key1, sdk.StoreTypeIAVL, dbm.NewMemDB())
+	store.MountStoreWithDB(tkey, sdk.StoreTypeTransient, nil)
+	nested := store.MountMultiStore(nestedKey, nil)
+	nested.MountStoreWithDB(key1, sdk.StoreTypeIAVL, dbm.NewMemDB())
+	require.Nil(t, store.LoadLatestVersion())
+
+	cms := store.CacheMultiStore()
+	r := &writeRecorder{}
+	cms.SetWriteListener(r)
+	cms.GetKVStore(key2).Set([]byte("b"), []byte("2"))
+	cms.GetKVStore(key1).Set([]byte("a"), []byte("1"))
+	cms.GetKVStore(tkey).Set([]byte("t"), []byte("0"))
+	cms.GetStore(nestedKey).(CacheMultiStore).GetKVStore(key1).Set([]byte("n"), []byte("3"))
+	cms.Write()
+
+	// in the order of the stores, without the transient store
+	require.Equal(t, []StateChange{
+		{StoreName: "nested/store1", Key: []byte("n"), Value: []byte("3")},
+		{StoreName: "store1", Key: []byte("a"), Value: []byte("1")},
+		{StoreName: "store2", Key: []byte("b"), Value: []byte("2")},
+	}, r.changes)
+}
+
+func TestFileStateSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statesink")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "changes")
+
+	blocks := [][]StateChange{
+		{
+			{Height: 1, TxIndex: -1, StoreName: "main", Key: []byte("a"), Value: []byte("1")},
+			{Height: 1, TxIndex: 0, StoreName: "acc", Key: []byte("b"), Deleted: true},
+		},
+		{
+			{Height: 2, TxIndex: 3, StoreName: "main", Key: []byte("a"), Value: []byte("2")},
+		},
+	}
+	sink, err := NewFileStateSink(path)
+	require.Nil(t, err)
+	require.Nil(t, sink.WriteBlock(1, blocks[0]))
+	require.Nil(t, sink.Close())
+
+	// appends to the file
+	sink, err = NewFileStateSink(path)
+	require.Nil(t, err)
+	require.Nil(t, sink.WriteBlock(2, blocks[1]))
+	require.Nil(t, sink.Close())
+
+	file, err := os.Open(path)
+	require.Nil(t, err)
+	defer file.Close()
+	var changes []StateChange
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var change StateChange
+		require.Nil(t, json.Unmarshal(scanner.Bytes(), &change))
+		changes = append(changes, change)
+	}
+	require.Equal(t, append(blocks[0], blocks[1]...), changes)
+}
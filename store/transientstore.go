@@ -0,0 +1,49 @@
+package store
+
+import (
+	dbm "github.com/tendermint/tmlibs/db"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+var _ KVStore = (*transientStore)(nil)
+var _ CommitStore = (*transientStore)(nil)
+
+// transientStore is an in-memory KVStore which is wiped on Commit,
+// eg. for data which only lives for a block. It has no CommitID,
+// and isn't part of the root hash of the rootMultiStore.
+type transientStore struct {
+	dbStoreAdapter
+}
+
+func newTransientStore() *transientStore {
+	return &transientStore{dbStoreAdapter{dbm.NewMemDB()}}
+}
+
+// Implements Committer.
+// Commit wipes the store.
+func (ts *transientStore) Commit() CommitID {
+	ts.dbStoreAdapter = dbStoreAdapter{dbm.NewMemDB()}
+	return CommitID{}
+}
+
+// Implements Committer.
+func (ts *transientStore) LastCommitID() CommitID {
+	return CommitID{}
+}
+
+// Implements Store.
+// The cache writes to the current contents of the store, even across wipes.
+func (ts *transientStore) CacheWrap() CacheWrap {
+	return NewCacheKVStore(ts)
+}
+
+// Implements KVStore.
+func (ts *transientStore) Prefix(prefix []byte) KVStore {
+	return NewPrefixStore(ts, prefix)
+}
+
+// Implements Store.
+func (ts *transientStore) GetStoreType() StoreType {
+	return sdk.StoreTypeTransient
+}
@@ -0,0 +1,33 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransientStore(t *testing.T) {
+	tstore := newTransientStore()
+
+	k, v := []byte("key"), []byte("value")
+	tstore.Set(k, v)
+	assert.Equal(t, v, tstore.Get(k))
+
+	// the cache writes to the store
+	cache := tstore.CacheWrap().(CacheKVStore)
+	k2, v2 := []byte("key2"), []byte("value2")
+	cache.Set(k2, v2)
+	assert.Nil(t, tstore.Get(k2))
+	cache.Write()
+	assert.Equal(t, v2, tstore.Get(k2))
+
+	// commit wipes the store
+	assert.Equal(t, CommitID{}, tstore.Commit())
+	assert.Nil(t, tstore.Get(k))
+	assert.Nil(t, tstore.Get(k2))
+	assert.Equal(t, CommitID{}, tstore.LastCommitID())
+
+	// and the store can be used again
+	tstore.Set(k, v2)
+	assert.Equal(t, v2, tstore.Get(k))
+}
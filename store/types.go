@@ -21,3 +21,6 @@ type CommitID = types.CommitID
 type StoreKey = types.StoreKey
 type StoreType = types.StoreType
 type Queryable = types.Queryable
+type PruningStrategy = types.PruningStrategy
+type StoreOption = types.StoreOption
+type WriteListener = types.WriteListener
@@ -48,6 +48,9 @@ type AccountMapper interface {
 	NewAccountWithAddress(ctx Context, addr Address) Account
 	GetAccount(ctx Context, addr Address) Account
 	SetAccount(ctx Context, acc Account)
+	// IterateAccounts calls process on every stored account,
+	// in address order, until process returns true.
+	IterateAccounts(ctx Context, process func(Account) (stop bool))
 }
 
 // AccountDecoder unmarshals account bytes
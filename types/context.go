@@ -43,6 +43,8 @@ func NewContext(ms MultiStore, header abci.Header, isCheckTx bool, txBytes []byt
 	c = c.WithChainID(header.ChainID)
 	c = c.WithIsCheckTx(isCheckTx)
 	c = c.WithTxBytes(txBytes)
+	c = c.WithGasMeter(NewInfiniteGasMeter())
+	c = c.WithMinimumGasPrices(nil)
 	return c
 }
 
@@ -67,8 +69,15 @@ func (c Context) Value(key interface{}) interface{} {
 }
 
 // KVStore fetches a KVStore from the MultiStore.
+// Every access through the returned store is charged to the context's GasMeter.
 func (c Context) KVStore(key StoreKey) KVStore {
-	return c.multiStore().GetKVStore(key)
+	return c.multiStore().GetKVStoreWithGas(c.GasMeter(), key)
+}
+
+// SubKVStore fetches the KVStore of subKey from the nested MultiStore of key.
+// Every access through the returned store is charged to the context's GasMeter.
+func (c Context) SubKVStore(key, subKey StoreKey) KVStore {
+	return c.multiStore().GetStore(key).(MultiStore).GetKVStoreWithGas(c.GasMeter(), subKey)
 }
 
 //----------------------------------------
@@ -126,6 +135,8 @@ const (
 	contextKeyChainID
 	contextKeyIsCheckTx
 	contextKeyTxBytes
+	contextKeyGasMeter
+	contextKeyMinimumGasPrices
 )
 
 // NOTE: Do not expose MultiStore.
@@ -151,6 +162,12 @@ func (c Context) IsCheckTx() bool {
 func (c Context) TxBytes() []byte {
 	return c.Value(contextKeyTxBytes).([]byte)
 }
+func (c Context) GasMeter() GasMeter {
+	return c.Value(contextKeyGasMeter).(GasMeter)
+}
+func (c Context) MinimumGasPrices() Coins {
+	return c.Value(contextKeyMinimumGasPrices).(Coins)
+}
 func (c Context) WithMultiStore(ms MultiStore) Context {
 	return c.withValue(contextKeyMultiStore, ms)
 }
@@ -170,6 +187,12 @@ func (c Context) WithIsCheckTx(isCheckTx bool) Context {
 func (c Context) WithTxBytes(txBytes []byte) Context {
 	return c.withValue(contextKeyTxBytes, txBytes)
 }
+func (c Context) WithGasMeter(meter GasMeter) Context {
+	return c.withValue(contextKeyGasMeter, meter)
+}
+func (c Context) WithMinimumGasPrices(prices Coins) Context {
+	return c.withValue(contextKeyMinimumGasPrices, prices)
+}
 
 //----------------------------------------
 // thePast
@@ -33,6 +33,9 @@ const (
 	CodeUnknownAddress    CodeType = 9
 	CodeInsufficientCoins CodeType = 10
 	CodeInvalidCoins      CodeType = 11
+	CodeOutOfGas          CodeType = 12
+	CodeInvalidOption     CodeType = 13
+	CodeInsufficientFee   CodeType = 14
 
 	CodeGenesisParse CodeType = 0xdead // TODO: remove ? // why remove?
 )
@@ -64,6 +67,12 @@ func CodeToDefaultMsg(code CodeType) string {
 		return "Insufficient coins"
 	case CodeInvalidCoins:
 		return "Invalid coins"
+	case CodeOutOfGas:
+		return "Out of gas"
+	case CodeInvalidOption:
+		return "Invalid option"
+	case CodeInsufficientFee:
+		return "Insufficient fee"
 	default:
 		return fmt.Sprintf("Unknown code %d", code)
 	}
@@ -110,6 +119,15 @@ func ErrInsufficientCoins(msg string) Error {
 func ErrInvalidCoins(msg string) Error {
 	return newError(CodeInvalidCoins, msg)
 }
+func ErrOutOfGas(msg string) Error {
+	return newError(CodeOutOfGas, msg)
+}
+func ErrInvalidOption(msg string) Error {
+	return newError(CodeInvalidOption, msg)
+}
+func ErrInsufficientFee(msg string) Error {
+	return newError(CodeInsufficientFee, msg)
+}
 
 //----------------------------------------
 // Error & sdkError
@@ -17,6 +17,7 @@ var codeTypes = []CodeType{
 	CodeUnknownAddress,
 	CodeInvalidPubKey,
 	CodeGenesisParse,
+	CodeOutOfGas,
 }
 
 type errFn func(msg string) Error
@@ -31,6 +32,7 @@ var errFns = []errFn{
 	ErrUnknownAddress,
 	ErrInvalidPubKey,
 	ErrGenesisParse,
+	ErrOutOfGas,
 }
 
 func TestCodeType(t *testing.T) {
@@ -0,0 +1,70 @@
+package types
+
+// Gas measured by the SDK
+type Gas = int64
+
+// Error thrown when out of gas
+type ErrorOutOfGas struct {
+	Descriptor string
+}
+
+// GasMeter interface to track gas consumption
+type GasMeter interface {
+	GasConsumed() Gas
+	Limit() Gas
+	ConsumeGas(amount Gas, descriptor string)
+}
+
+type basicGasMeter struct {
+	limit    Gas
+	consumed Gas
+}
+
+// NewGasMeter returns a GasMeter which panics with ErrorOutOfGas
+// once more than limit gas has been consumed.
+func NewGasMeter(limit Gas) GasMeter {
+	return &basicGasMeter{
+		limit:    limit,
+		consumed: 0,
+	}
+}
+
+func (g *basicGasMeter) GasConsumed() Gas {
+	return g.consumed
+}
+
+func (g *basicGasMeter) Limit() Gas {
+	return g.limit
+}
+
+func (g *basicGasMeter) ConsumeGas(amount Gas, descriptor string) {
+	g.consumed += amount
+	if g.consumed > g.limit {
+		panic(ErrorOutOfGas{descriptor})
+	}
+}
+
+type infiniteGasMeter struct {
+	consumed Gas
+}
+
+// NewInfiniteGasMeter returns a GasMeter which tracks consumption
+// but never runs out. Used outside of transaction execution,
+// eg. in InitChain, BeginBlock and EndBlock.
+func NewInfiniteGasMeter() GasMeter {
+	return &infiniteGasMeter{
+		consumed: 0,
+	}
+}
+
+func (g *infiniteGasMeter) GasConsumed() Gas {
+	return g.consumed
+}
+
+func (g *infiniteGasMeter) Limit() Gas {
+	return 0
+}
+
+func (g *infiniteGasMeter) ConsumeGas(amount Gas, descriptor string) {
+	g.consumed += amount
+}
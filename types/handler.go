@@ -1,7 +1,18 @@
 package types
 
+import (
+	abci "github.com/tendermint/abci/types"
+)
+
 // core function variable which application runs for transactions
 type Handler func(ctx Context, msg Msg) Result
 
 // If newCtx.IsZero(), ctx is used instead.
+// The gas consumed by the GasMeter of newCtx is reported, even on abort.
 type AnteHandler func(ctx Context, tx Tx) (newCtx Context, result Result, abort bool)
+
+// Querier answers application specific queries routed to it by path.
+// path holds the elements of the query path after the route, eg. for
+// "/custom/stake/candidates" it is ["candidates"].
+// The ctx is read-only: any writes are discarded.
+type Querier func(ctx Context, path []string, req abci.RequestQuery) (res []byte, err Error)
@@ -7,4 +7,11 @@ import (
 // Run only once on chain initialization, should write genesis state to store
 // or throw an error if some required information was not provided, in which case
 // the application will panic.
+// data is the value registered for the module in the genesis app state,
+// or nil if the app state has no such value.
 type InitGenesis func(ctx Context, data json.RawMessage) error
+
+// Exports the module state as the data its InitGenesis consumes, so that
+// a chain can be restarted from the exported state. Returning nil
+// leaves the module out of the exported app state.
+type ExportGenesis func(ctx Context) json.RawMessage
@@ -0,0 +1,75 @@
+package types
+
+import (
+	"strconv"
+)
+
+// OptionHandler validates a new value for a node-local option,
+// as sent with ABCI SetOption, and applies it.
+// Nodes may set options differently, so they must never affect
+// consensus: use them for eg. CheckTx filtering or logging.
+type OptionHandler func(value string) error
+
+// StringOption returns an OptionHandler which passes the value to set
+// if validate, when not nil, accepts it.
+func StringOption(validate func(string) error, set func(string)) OptionHandler {
+	return func(value string) error {
+		if validate != nil {
+			if err := validate(value); err != nil {
+				return err
+			}
+		}
+		set(value)
+		return nil
+	}
+}
+
+// Int64Option returns an OptionHandler which parses the value as an int64
+// and passes it to set if validate, when not nil, accepts it.
+func Int64Option(validate func(int64) error, set func(int64)) OptionHandler {
+	return func(value string) error {
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		if validate != nil {
+			if err := validate(i); err != nil {
+				return err
+			}
+		}
+		set(i)
+		return nil
+	}
+}
+
+// BoolOption returns an OptionHandler which parses the value as a bool
+// and passes it to set.
+func BoolOption(set func(bool)) OptionHandler {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		set(b)
+		return nil
+	}
+}
+
+// CoinsOption returns an OptionHandler which parses the value as Coins,
+// eg. "10fermion,5steak", and passes them to set if validate, when not nil,
+// accepts them.
+func CoinsOption(validate func(Coins) error, set func(Coins)) OptionHandler {
+	return func(value string) error {
+		coins, err := ParseCoins(value)
+		if err != nil {
+			return err
+		}
+		if validate != nil {
+			if err := validate(coins); err != nil {
+				return err
+			}
+		}
+		set(coins)
+		return nil
+	}
+}
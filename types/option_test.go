@@ -0,0 +1,49 @@
+package types
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionHandlers(t *testing.T) {
+	var s string
+	nonEmpty := func(value string) error {
+		if value == "" {
+			return errors.New("empty")
+		}
+		return nil
+	}
+	handler := StringOption(nonEmpty, func(value string) { s = value })
+	assert.Nil(t, handler("info"))
+	assert.Equal(t, "info", s)
+	assert.NotNil(t, handler(""))
+	assert.Equal(t, "info", s)
+
+	var i int64
+	positive := func(value int64) error {
+		if value <= 0 {
+			return errors.New("not positive")
+		}
+		return nil
+	}
+	handler = Int64Option(positive, func(value int64) { i = value })
+	assert.Nil(t, handler("42"))
+	assert.Equal(t, int64(42), i)
+	assert.NotNil(t, handler("-1"))
+	assert.NotNil(t, handler("forty-two"))
+	assert.Equal(t, int64(42), i)
+
+	var b bool
+	handler = BoolOption(func(value bool) { b = value })
+	assert.Nil(t, handler("true"))
+	assert.True(t, b)
+	assert.NotNil(t, handler("yes please"))
+
+	var coins Coins
+	handler = CoinsOption(nil, func(value Coins) { coins = value })
+	assert.Nil(t, handler("5steak,10fermion"))
+	assert.Equal(t, Coins{{"fermion", 10}, {"steak", 5}}, coins)
+	assert.NotNil(t, handler("10"))
+}
@@ -0,0 +1,106 @@
+package types
+
+import (
+	"fmt"
+)
+
+// PruningStrategy specifies which versions of a CommitStore are kept
+// as new versions are committed. The latest KeepRecent versions are
+// kept, as well as every version which is a multiple of KeepEvery.
+// A KeepRecent of 0 keeps every version, and a KeepEvery of 0 keeps
+// no older version.
+type PruningStrategy struct {
+	KeepRecent int64
+	KeepEvery  int64
+}
+
+var (
+	// PruneNothing keeps every version, eg. for archive nodes.
+	PruneNothing = PruningStrategy{}
+
+	// PruneEverything keeps only the latest version, eg. for validators.
+	PruneEverything = PruningStrategy{KeepRecent: 1}
+
+	// PruneSyncable keeps the last 100 versions, and a snapshot of
+	// every 10000th version from which other nodes can sync.
+	PruneSyncable = PruningStrategy{KeepRecent: 100, KeepEvery: 10000}
+)
+
+// Names of the pruning strategies, as used in the server config.
+const (
+	PruningNothing    = "nothing"
+	PruningEverything = "everything"
+	PruningSyncable   = "syncable"
+)
+
+// NewPruningStrategy returns a strategy which keeps the last keepRecent
+// versions and every version which is a multiple of keepEvery.
+func NewPruningStrategy(keepRecent, keepEvery int64) PruningStrategy {
+	return PruningStrategy{
+		KeepRecent: keepRecent,
+		KeepEvery:  keepEvery,
+	}
+}
+
+// ParsePruningStrategy returns the strategy of the given name.
+func ParsePruningStrategy(name string) (PruningStrategy, error) {
+	switch name {
+	case PruningNothing:
+		return PruneNothing, nil
+	case PruningEverything:
+		return PruneEverything, nil
+	case PruningSyncable:
+		return PruneSyncable, nil
+	default:
+		return PruningStrategy{}, fmt.Errorf("unknown pruning strategy %q", name)
+	}
+}
+
+// ValidateBasic checks that the strategy keeps no negative number of versions.
+func (ps PruningStrategy) ValidateBasic() error {
+	if ps.KeepRecent < 0 || ps.KeepEvery < 0 {
+		return fmt.Errorf("invalid pruning strategy %v", ps)
+	}
+	return nil
+}
+
+// PruneVersion returns whether version is to be deleted when
+// latestVersion is committed.
+func (ps PruningStrategy) PruneVersion(version, latestVersion int64) bool {
+	if ps.KeepRecent == 0 || version <= 0 {
+		return false
+	}
+	if version > latestVersion-ps.KeepRecent {
+		return false
+	}
+	if ps.KeepEvery > 0 && version%ps.KeepEvery == 0 {
+		return false
+	}
+	return true
+}
+
+// String implements fmt.Stringer.
+func (ps PruningStrategy) String() string {
+	return fmt.Sprintf("PruningStrategy{KeepRecent: %d, KeepEvery: %d}", ps.KeepRecent, ps.KeepEvery)
+}
+
+//----------------------------------------
+// StoreOption
+
+// StoreOptions are the options of a store mounted in a CommitMultiStore.
+type StoreOptions struct {
+
+	// The pruning strategy of the store, or nil for the default
+	// strategy of the CommitMultiStore.
+	Pruning *PruningStrategy
+}
+
+// StoreOption sets an option of a mounted store.
+type StoreOption func(*StoreOptions)
+
+// WithPruning sets the pruning strategy of a mounted store.
+func WithPruning(pruning PruningStrategy) StoreOption {
+	return func(opts *StoreOptions) {
+		opts.Pruning = &pruning
+	}
+}
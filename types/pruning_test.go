@@ -0,0 +1,59 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePruningStrategy(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected PruningStrategy
+		ok       bool
+	}{
+		{PruningNothing, PruneNothing, true},
+		{PruningEverything, PruneEverything, true},
+		{PruningSyncable, PruneSyncable, true},
+		{"", PruningStrategy{}, false},
+		{"some", PruningStrategy{}, false},
+	}
+	for _, tc := range cases {
+		pruning, err := ParsePruningStrategy(tc.name)
+		if !tc.ok {
+			assert.NotNil(t, err, tc.name)
+			continue
+		}
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.expected, pruning, tc.name)
+	}
+}
+
+func TestPruneVersion(t *testing.T) {
+	cases := []struct {
+		pruning         PruningStrategy
+		version, latest int64
+		prune           bool
+	}{
+		{PruneNothing, 1, 100, false},
+		{PruneEverything, 99, 100, true},
+		{PruneEverything, 100, 100, false},
+		{PruneEverything, 0, 100, false},
+		{NewPruningStrategy(5, 0), 95, 100, true},
+		{NewPruningStrategy(5, 0), 96, 100, false},
+		{NewPruningStrategy(5, 10), 95, 100, true},
+		{NewPruningStrategy(5, 10), 90, 100, false},
+		{NewPruningStrategy(5, 10), 100, 100, false},
+		{PruneSyncable, 10000, 20000, false},
+		{PruneSyncable, 10001, 20000, true},
+	}
+	for i, tc := range cases {
+		assert.Equal(t, tc.prune, tc.pruning.PruneVersion(tc.version, tc.latest), "case %d", i)
+	}
+}
+
+func TestPruningStrategyValidateBasic(t *testing.T) {
+	assert.Nil(t, PruneSyncable.ValidateBasic())
+	assert.NotNil(t, NewPruningStrategy(-1, 0).ValidateBasic())
+	assert.NotNil(t, NewPruningStrategy(1, -1).ValidateBasic())
+}
@@ -48,12 +48,24 @@ type MultiStore interface { //nolint
 	// Convenience for fetching substores.
 	GetStore(StoreKey) Store
 	GetKVStore(StoreKey) KVStore
+
+	// Fetch a KVStore which charges all accesses to the GasMeter.
+	GetKVStoreWithGas(GasMeter, StoreKey) KVStore
 }
 
 // From MultiStore.CacheMultiStore()....
 type CacheMultiStore interface {
 	MultiStore
 	Write() // Writes operations to underlying KVStore
+
+	// Set the listener notified of the writes of its stores on Write.
+	SetWriteListener(listener WriteListener)
+}
+
+// WriteListener is notified of each key written by a cache store to
+// its parent on Write, in the order of the stores and of the keys.
+type WriteListener interface {
+	OnWrite(storeName string, key, value []byte, deleted bool)
 }
 
 // A non-cache MultiStore.
@@ -63,7 +75,20 @@ type CommitMultiStore interface {
 
 	// Mount a store of type using the given db.
 	// If db == nil, the new store will use the CommitMultiStore db.
-	MountStoreWithDB(key StoreKey, typ StoreType, db dbm.DB)
+	// The options apply only to this store, eg. WithPruning.
+	MountStoreWithDB(key StoreKey, typ StoreType, db dbm.DB, opts ...StoreOption)
+
+	// Mount a nested CommitMultiStore, committed as one store under key.
+	// Its stores are mounted on the returned CommitMultiStore before
+	// loading, and keep their data isolated from the other stores, in
+	// db or the CommitMultiStore db if db == nil. It is pruned with the
+	// strategy of the options, or else of the CommitMultiStore.
+	// MountStoreWithDB of StoreTypeMulti is the same.
+	MountMultiStore(key StoreKey, db dbm.DB, opts ...StoreOption) CommitMultiStore
+
+	// Set the pruning strategy of the stores mounted without one.
+	// Must be called before loading a version.
+	SetPruning(pruning PruningStrategy)
 
 	// Panics on a nil key.
 	GetCommitStore(key StoreKey) CommitStore
@@ -75,6 +100,10 @@ type CommitMultiStore interface {
 	// calls to Mount*Store() are complete.
 	LoadLatestVersion() error
 
+	// Load a read-only view of a retained version, eg. for queries.
+	// Writes to it are discarded.
+	CacheMultiStoreWithVersion(version int64) (CacheMultiStore, error)
+
 	// Load a specific persisted version.  When you load an old
 	// version, or when the last commit attempt didn't complete,
 	// the next commit after loading must be idempotent (return the
@@ -111,17 +140,39 @@ type KVStore interface {
 	// CONTRACT: No writes may happen within a domain while an iterator exists over it.
 	ReverseIterator(start, end []byte) Iterator
 
-	// TODO Not yet implemented.
-	// CreateSubKVStore(key *storeKey) (KVStore, error)
-
-	// TODO Not yet implemented.
-	// GetSubKVStore(key *storeKey) KVStore
-
+	// Prefix returns the KVStore of the keys which begin with prefix,
+	// with the prefix stripped. Iterators over it stay within the prefix.
+	Prefix(prefix []byte) KVStore
 }
 
 // Alias iterator to db's Iterator for convenience.
 type Iterator = dbm.Iterator
 
+// PrefixEndBytes returns the []byte that would end a
+// range query for all []byte with a certain prefix.
+// Deals with last byte of prefix being FF without overflowing.
+// Returns nil (the end of the keyspace) if prefix is empty or all FF.
+func PrefixEndBytes(prefix []byte) []byte {
+	if len(prefix) == 0 {
+		return nil
+	}
+
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+
+	for {
+		if end[len(end)-1] != byte(255) {
+			end[len(end)-1]++
+			break
+		}
+		end = end[:len(end)-1]
+		if len(end) == 0 {
+			return nil
+		}
+	}
+	return end
+}
+
 // CacheKVStore cache-wraps a KVStore.  After calling .Write() on
 // the CacheKVStore, all previously created CacheKVStores on the
 // object expire.
@@ -190,6 +241,7 @@ const (
 	StoreTypeMulti StoreType = iota
 	StoreTypeDB
 	StoreTypeIAVL
+	StoreTypeTransient
 )
 
 //----------------------------------------
@@ -7,8 +7,8 @@ import (
 // Transactions messages must fulfill the Msg
 type Msg interface {
 
-	// Return the message type.
-	// Must be alphanumeric or empty.
+	// Return the message type, used to route the Msg to its handler.
+	// Must be alphanumeric segments separated by "/", eg. "stake/delegate".
 	Type() string
 
 	// Get some property of the Msg.
@@ -32,13 +32,13 @@ type Msg interface {
 // Transactions objects must fulfill the Tx
 type Tx interface {
 
-	// Gets the Msg.
-	GetMsg() Msg
+	// Gets the Msgs, in the order they are to be executed.
+	GetMsgs() []Msg
 
-	// Signatures returns the signature of signers who signed the Msg.
+	// Signatures returns the signature of signers who signed the Msgs.
 	// CONTRACT: Length returned is same as length of
-	// pubkeys returned from MsgKeySigners, and the order
-	// matches.
+	// the signers returned from GetSigners(tx.GetMsgs()),
+	// and the order matches.
 	// CONTRACT: If the signature is missing (ie the Msg is
 	// invalid), then the corresponding signature is
 	// .Empty().
@@ -47,31 +47,54 @@ type Tx interface {
 
 var _ Tx = (*StdTx)(nil)
 
-// StdTx is a standard way to wrap a Msg with Fee and Signatures.
-// NOTE: the first signature is the FeePayer (Signatures must not be nil).
+// StdTx is a standard way to wrap Msgs with Fee and Signatures.
+// NOTE: the first signature is the FeePayer (Signatures must not be nil),
+// unless the Fee names a Granter.
 type StdTx struct {
-	Msg        `json:"msg"`
+	Msgs       []Msg          `json:"msgs"`
 	Fee        StdFee         `json:"fee"`
 	Signatures []StdSignature `json:"signatures"`
 }
 
-func NewStdTx(msg Msg, fee StdFee, sigs []StdSignature) StdTx {
+func NewStdTx(msgs []Msg, fee StdFee, sigs []StdSignature) StdTx {
 	return StdTx{
-		Msg:        msg,
+		Msgs:       msgs,
 		Fee:        fee,
 		Signatures: sigs,
 	}
 }
 
 //nolint
-func (tx StdTx) GetMsg() Msg                   { return tx.Msg }
+func (tx StdTx) GetMsgs() []Msg                { return tx.Msgs }
 func (tx StdTx) GetSignatures() []StdSignature { return tx.Signatures }
 
+// GetSigners returns the addresses that must sign the transaction.
+// Addresses are returned in a deterministic order: the signers of each
+// Msg in order, with duplicates removed after their first appearance.
+func GetSigners(msgs []Msg) []Address {
+	seen := make(map[string]bool)
+	var signers []Address
+	for _, msg := range msgs {
+		for _, addr := range msg.GetSigners() {
+			if !seen[string(addr)] {
+				signers = append(signers, addr)
+				seen[string(addr)] = true
+			}
+		}
+	}
+	return signers
+}
+
 // FeePayer returns the address responsible for paying the fees
-// for the transactions. It's the first address returned by msg.GetSigners().
-// If GetSigners() is empty, this panics.
+// for the transactions. It's the granter of the fee of a StdTx,
+// if any, or else the first address returned by
+// GetSigners(tx.GetMsgs()), ie. the first signer of the first Msg.
+// If there are no signers, this panics.
 func FeePayer(tx Tx) Address {
-	return tx.GetMsg().GetSigners()[0]
+	if stdTx, ok := tx.(StdTx); ok && len(stdTx.Fee.Granter) > 0 {
+		return stdTx.Fee.Granter
+	}
+	return GetSigners(tx.GetMsgs())[0]
 }
 
 //__________________________________________________________
@@ -79,9 +102,12 @@ func FeePayer(tx Tx) Address {
 // StdFee includes the amount of coins paid in fees and the maximum
 // gas to be used by the transaction. The ratio yields an effective "gasprice",
 // which must be above some miminum to be accepted into the mempool.
+// If Granter is set, it pays the fees of the first signer, who must hold
+// a fee allowance of the Granter.
 type StdFee struct {
-	Amount Coins `json"amount"`
-	Gas    int64 `json"gas"`
+	Amount  Coins   `json"amount"`
+	Gas     int64   `json"gas"`
+	Granter Address `json:"granter,omitempty"`
 }
 
 func NewStdFee(gas int64, amount ...Coin) StdFee {
@@ -110,26 +136,31 @@ func (fee StdFee) Bytes() []byte {
 //__________________________________________________________
 
 // StdSignDoc is replay-prevention structure.
-// It includes the result of msg.GetSignBytes(),
+// It includes the result of msg.GetSignBytes() for each Msg,
 // as well as the ChainID (prevent cross chain replay)
 // and the Sequence numbers for each signature (prevent
 // inchain replay and enforce tx ordering per account).
 type StdSignDoc struct {
-	ChainID   string  `json:"chain_id"`
-	Sequences []int64 `json:"sequences"`
-	FeeBytes  []byte  `json:"fee_bytes"`
-	MsgBytes  []byte  `json:"msg_bytes"`
-	AltBytes  []byte  `json:"alt_bytes"`
+	ChainID   string   `json:"chain_id"`
+	Sequences []int64  `json:"sequences"`
+	FeeBytes  []byte   `json:"fee_bytes"`
+	MsgsBytes [][]byte `json:"msgs_bytes"`
+	AltBytes  []byte   `json:"alt_bytes"`
 }
 
 // StdSignBytes returns the bytes to sign for a transaction.
+// Every signer signs over all of the Msgs.
 // TODO: change the API to just take a chainID and StdTx ?
-func StdSignBytes(chainID string, sequences []int64, fee StdFee, msg Msg) []byte {
+func StdSignBytes(chainID string, sequences []int64, fee StdFee, msgs []Msg) []byte {
+	msgsBytes := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		msgsBytes[i] = msg.GetSignBytes()
+	}
 	bz, err := json.Marshal(StdSignDoc{
 		ChainID:   chainID,
 		Sequences: sequences,
 		FeeBytes:  fee.Bytes(),
-		MsgBytes:  msg.GetSignBytes(),
+		MsgsBytes: msgsBytes,
 	})
 	if err != nil {
 		panic(err)
@@ -138,19 +169,19 @@ func StdSignBytes(chainID string, sequences []int64, fee StdFee, msg Msg) []byte
 }
 
 // StdSignMsg is a convenience structure for passing along
-// a Msg with the other requirements for a StdSignDoc before
+// Msgs with the other requirements for a StdSignDoc before
 // it is signed. For use in the CLI.
 type StdSignMsg struct {
 	ChainID   string
 	Sequences []int64
 	Fee       StdFee
-	Msg       Msg
+	Msgs      []Msg
 	// XXX: Alt
 }
 
 // get message bytes
 func (msg StdSignMsg) Bytes() []byte {
-	return StdSignBytes(msg.ChainID, msg.Sequences, msg.Fee, msg.Msg)
+	return StdSignBytes(msg.ChainID, msg.Sequences, msg.Fee, msg.Msgs)
 }
 
 //__________________________________________________________
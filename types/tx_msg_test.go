@@ -21,10 +21,47 @@ func TestStdTx(t *testing.T) {
 	fee := newStdFee()
 	sigs := []StdSignature{}
 
-	tx := NewStdTx(msg, fee, sigs)
-	assert.Equal(t, msg, tx.GetMsg())
+	tx := NewStdTx([]Msg{msg}, fee, sigs)
+	assert.Equal(t, []Msg{msg}, tx.GetMsgs())
 	assert.Equal(t, sigs, tx.GetSignatures())
 
 	feePayer := FeePayer(tx)
 	assert.Equal(t, addr, feePayer)
+
+	// a granter pays the fee, and is signed over
+	granter := crypto.GenPrivKeyEd25519().PubKey().Address()
+	tx.Fee.Granter = granter
+	assert.Equal(t, granter, FeePayer(tx))
+	assert.NotContains(t, string(fee.Bytes()), "granter")
+	assert.Contains(t, string(tx.Fee.Bytes()), "granter")
+}
+
+func TestGetSigners(t *testing.T) {
+	addr1 := crypto.GenPrivKeyEd25519().PubKey().Address()
+	addr2 := crypto.GenPrivKeyEd25519().PubKey().Address()
+	addr3 := crypto.GenPrivKeyEd25519().PubKey().Address()
+
+	msgs := []Msg{
+		NewTestMsg(addr2, addr1),
+		NewTestMsg(addr1, addr3),
+		NewTestMsg(addr2),
+	}
+	signers := GetSigners(msgs)
+	assert.Equal(t, []Address{addr2, addr1, addr3}, signers)
+
+	tx := NewStdTx(msgs, newStdFee(), nil)
+	assert.Equal(t, addr2, FeePayer(tx))
+}
+
+func TestStdSignBytes(t *testing.T) {
+	addr1 := crypto.GenPrivKeyEd25519().PubKey().Address()
+	addr2 := crypto.GenPrivKeyEd25519().PubKey().Address()
+	msg1, msg2 := NewTestMsg(addr1), NewTestMsg(addr2)
+	fee := newStdFee()
+
+	// sign bytes cover every msg and their order
+	bz := StdSignBytes("chain", []int64{0, 0}, fee, []Msg{msg1, msg2})
+	assert.NotEqual(t, bz, StdSignBytes("chain", []int64{0, 0}, fee, []Msg{msg1}))
+	assert.NotEqual(t, bz, StdSignBytes("chain", []int64{0, 0}, fee, []Msg{msg2, msg1}))
+	assert.Equal(t, bz, StdSignBytes("chain", []int64{0, 0}, fee, []Msg{msg1, msg2}))
 }
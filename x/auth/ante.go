@@ -3,18 +3,42 @@ package auth
 import (
 	"bytes"
 	"fmt"
+	"math"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
-	"github.com/spf13/viper"
 )
 
+const (
+	// gas charged for each signature verification
+	verifyCost = 100
+)
+
+// FeeGrantKeeper lets the granter of a fee pay the fees of a grantee.
+type FeeGrantKeeper interface {
+	// UseGrantedFees checks that granter allows grantee to pay fee
+	// for msgs, and takes fee out of the allowance.
+	UseGrantedFees(ctx sdk.Context, granter, grantee sdk.Address, fee sdk.Coins, msgs []sdk.Msg) sdk.Error
+}
+
 // NewAnteHandler returns an AnteHandler that checks
 // and increments sequence numbers, checks signatures,
-// and deducts fees from the first signer.
-func NewAnteHandler(accountMapper sdk.AccountMapper) sdk.AnteHandler {
+// deducts fees from the first signer into the fee pool
+// of the FeeCollectionKeeper, and installs a GasMeter
+// limited by the fee's gas.
+// In CheckTx, it also rejects fees below the node's
+// minimum gas prices.
+// It rejects fees with a granter.
+func NewAnteHandler(accountMapper sdk.AccountMapper, feeCollectionKeeper FeeCollectionKeeper) sdk.AnteHandler {
+	return NewAnteHandlerWithFeeGrants(accountMapper, feeCollectionKeeper, nil)
+}
+
+// NewAnteHandlerWithFeeGrants returns the AnteHandler of NewAnteHandler,
+// which deducts fees with a granter from the granter instead of the first
+// signer, once the FeeGrantKeeper took them out of its allowance.
+func NewAnteHandlerWithFeeGrants(accountMapper sdk.AccountMapper, feeCollectionKeeper FeeCollectionKeeper, feeGrantKeeper FeeGrantKeeper) sdk.AnteHandler {
 	return func(
 		ctx sdk.Context, tx sdk.Tx,
-	) (_ sdk.Context, _ sdk.Result, abort bool) {
+	) (newCtx sdk.Context, res sdk.Result, abort bool) {
 
 		// Assert that there are signatures.
 		var sigs = tx.GetSignatures()
@@ -24,8 +48,7 @@ func NewAnteHandler(accountMapper sdk.AccountMapper) sdk.AnteHandler {
 				true
 		}
 
-		// TODO: can tx just implement message?
-		msg := tx.GetMsg()
+		msgs := tx.GetMsgs()
 
 		// TODO: will this always be a stdtx? should that be used in the function signature?
 		stdTx, ok := tx.(sdk.StdTx)
@@ -33,8 +56,35 @@ func NewAnteHandler(accountMapper sdk.AccountMapper) sdk.AnteHandler {
 			return ctx, sdk.ErrInternal("tx must be sdk.StdTx").Result(), true
 		}
 
+		// Everything from here on, including the handler,
+		// is charged against the gas limit of the fee.
+		ctx = ctx.WithGasMeter(sdk.NewGasMeter(stdTx.Fee.Gas))
+
+		// Running out of gas aborts with the limited context,
+		// so that its gas is reported.
+		defer func() {
+			if r := recover(); r != nil {
+				rType, ok := r.(sdk.ErrorOutOfGas)
+				if !ok {
+					panic(r)
+				}
+				log := fmt.Sprintf("Out of gas in location: %v", rType.Descriptor)
+				newCtx, res, abort = ctx, sdk.ErrOutOfGas(log).Result(), true
+			}
+		}()
+
+		// Keep cheap txs out of the mempool.
+		// The minimum is node-local, so it must not apply in DeliverTx.
+		if ctx.IsCheckTx() {
+			res := checkMinimumFee(ctx.MinimumGasPrices(), stdTx.Fee)
+			if !res.IsOK() {
+				return ctx, res, true
+			}
+		}
+
 		// Assert that number of signatures is correct.
-		var signerAddrs = msg.GetSigners()
+		// Each signer signs once, over all the Msgs.
+		var signerAddrs = sdk.GetSigners(msgs)
 		if len(sigs) != len(signerAddrs) {
 			return ctx,
 				sdk.ErrUnauthorized("wrong number of signers").Result(),
@@ -47,13 +97,7 @@ func NewAnteHandler(accountMapper sdk.AccountMapper) sdk.AnteHandler {
 			sequences[i] = sigs[i].Sequence
 		}
 		fee := stdTx.Fee
-		chainID := ctx.ChainID()
-		// XXX: major hack; need to get ChainID
-		// into the app right away (#565)
-		if chainID == "" {
-			chainID = viper.GetString("chain-id")
-		}
-		signBytes := sdk.StdSignBytes(ctx.ChainID(), sequences, fee, msg)
+		signBytes := sdk.StdSignBytes(ctx.ChainID(), sequences, fee, msgs)
 
 		// Check sig and nonce and collect signer accounts.
 		var signerAccs = make([]sdk.Account, len(signerAddrs))
@@ -69,14 +113,14 @@ func NewAnteHandler(accountMapper sdk.AccountMapper) sdk.AnteHandler {
 				return ctx, res, true
 			}
 
-			// first sig pays the fees
-			if i == 0 {
-				// TODO: min fee
+			// first sig pays the fees, unless they are granted
+			if i == 0 && len(fee.Granter) == 0 {
 				if !fee.Amount.IsZero() {
-					signerAcc, res = deductFees(signerAcc, fee)
+					signerAcc, res = deductFees(ctx, signerAcc, fee)
 					if !res.IsOK() {
 						return ctx, res, true
 					}
+					feeCollectionKeeper.AddCollectedFees(ctx, fee.Amount)
 				}
 			}
 
@@ -85,6 +129,22 @@ func NewAnteHandler(accountMapper sdk.AccountMapper) sdk.AnteHandler {
 			signerAccs[i] = signerAcc
 		}
 
+		// the granter pays granted fees, once the signers are saved,
+		// as it may be one of them
+		if len(fee.Granter) > 0 {
+			granterAcc, res := deductGrantedFees(ctx, accountMapper, feeGrantKeeper, signerAddrs[0], fee, msgs)
+			if !res.IsOK() {
+				return ctx, res, true
+			}
+			accountMapper.SetAccount(ctx, granterAcc)
+			feeCollectionKeeper.AddCollectedFees(ctx, fee.Amount)
+			for i, signerAddr := range signerAddrs {
+				if bytes.Equal(signerAddr, fee.Granter) {
+					signerAccs[i] = granterAcc
+				}
+			}
+		}
+
 		// cache the signer accounts in the context
 		ctx = WithSigners(ctx, signerAccs)
 
@@ -133,7 +193,8 @@ func processSig(
 		}
 	}
 
-	// Check sig.
+	// Check sig, charging for each signature of a multisig.
+	ctx.GasMeter().ConsumeGas(verifyCost*signatureCount(sig.Signature), "ante verify")
 	if !pubKey.VerifyBytes(signBytes, sig.Signature) {
 		return nil, sdk.ErrUnauthorized("signature verification failed").Result()
 	}
@@ -141,18 +202,58 @@ func processSig(
 	return
 }
 
-// Deduct the fee from the account.
+// Check that the fee pays at least the minimum price
+// for its gas in one of the denoms of prices.
+// Any fee will do if there are no prices.
+func checkMinimumFee(prices sdk.Coins, fee sdk.StdFee) sdk.Result {
+	if len(prices) == 0 {
+		return sdk.Result{}
+	}
+	for _, price := range prices {
+		if fee.Gas > 0 && price.Amount > math.MaxInt64/fee.Gas {
+			continue // no fee can pay that much
+		}
+		if fee.Amount.AmountOf(price.Denom) >= price.Amount*fee.Gas {
+			return sdk.Result{}
+		}
+	}
+	errMsg := fmt.Sprintf("fee %s for %d gas is below the minimum gas prices %s",
+		fee.Amount, fee.Gas, prices)
+	return sdk.ErrInsufficientFee(errMsg).Result()
+}
+
+// Deduct the fee from the spendable coins of the account.
 // We could use the CoinKeeper (in addition to the AccountMapper,
 // because the CoinKeeper doesn't give us accounts), but it seems easier to do this.
-func deductFees(acc sdk.Account, fee sdk.StdFee) (sdk.Account, sdk.Result) {
-	coins := acc.GetCoins()
+func deductFees(ctx sdk.Context, acc sdk.Account, fee sdk.StdFee) (sdk.Account, sdk.Result) {
+	spendable := SpendableCoins(ctx, acc)
 	feeAmount := fee.Amount
 
-	newCoins := coins.Minus(feeAmount)
-	if !newCoins.IsNotNegative() {
-		errMsg := fmt.Sprintf("%s < %s", coins, feeAmount)
+	if !spendable.Minus(feeAmount).IsNotNegative() {
+		errMsg := fmt.Sprintf("%s < %s", spendable, feeAmount)
 		return nil, sdk.ErrInsufficientFunds(errMsg).Result()
 	}
-	acc.SetCoins(newCoins)
+	acc.SetCoins(acc.GetCoins().Minus(feeAmount))
+	return acc, sdk.Result{}
+}
+
+// Deduct the fee from the spendable coins of its granter, and take it
+// out of the allowance of the grantee, the first signer.
+func deductGrantedFees(ctx sdk.Context, am sdk.AccountMapper, fgk FeeGrantKeeper, grantee sdk.Address, fee sdk.StdFee, msgs []sdk.Msg) (sdk.Account, sdk.Result) {
+	if fgk == nil {
+		return nil, sdk.ErrUnauthorized("fee grants are not supported").Result()
+	}
+	acc := am.GetAccount(ctx, fee.Granter)
+	if acc == nil {
+		return nil, sdk.ErrUnknownAddress(fee.Granter.String()).Result()
+	}
+	acc, res := deductFees(ctx, acc, fee)
+	if !res.IsOK() {
+		return nil, res
+	}
+	err := fgk.UseGrantedFees(ctx, fee.Granter, grantee, fee.Amount, msgs)
+	if err != nil {
+		return nil, err.Result()
+	}
 	return acc, sdk.Result{}
 }
@@ -1,9 +1,11 @@
 package auth
 
 import (
+	"math"
 	"testing"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	wire "github.com/cosmos/cosmos-sdk/wire"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	abci "github.com/tendermint/abci/types"
@@ -15,7 +17,7 @@ func newTestMsg(addrs ...sdk.Address) *sdk.TestMsg {
 }
 
 func newStdFee() sdk.StdFee {
-	return sdk.NewStdFee(100,
+	return sdk.NewStdFee(10000,
 		sdk.Coin{"atom", 150},
 	)
 }
@@ -50,16 +52,20 @@ func checkInvalidTx(t *testing.T, anteHandler sdk.AnteHandler, ctx sdk.Context,
 }
 
 func newTestTx(ctx sdk.Context, msg sdk.Msg, privs []crypto.PrivKey, seqs []int64, fee sdk.StdFee) sdk.Tx {
-	signBytes := sdk.StdSignBytes(ctx.ChainID(), seqs, fee, msg)
-	return newTestTxWithSignBytes(msg, privs, seqs, fee, signBytes)
+	return newTestTxMsgs(ctx, []sdk.Msg{msg}, privs, seqs, fee)
 }
 
-func newTestTxWithSignBytes(msg sdk.Msg, privs []crypto.PrivKey, seqs []int64, fee sdk.StdFee, signBytes []byte) sdk.Tx {
+func newTestTxMsgs(ctx sdk.Context, msgs []sdk.Msg, privs []crypto.PrivKey, seqs []int64, fee sdk.StdFee) sdk.Tx {
+	signBytes := sdk.StdSignBytes(ctx.ChainID(), seqs, fee, msgs)
+	return newTestTxWithSignBytes(msgs, privs, seqs, fee, signBytes)
+}
+
+func newTestTxWithSignBytes(msgs []sdk.Msg, privs []crypto.PrivKey, seqs []int64, fee sdk.StdFee, signBytes []byte) sdk.Tx {
 	sigs := make([]sdk.StdSignature, len(privs))
 	for i, priv := range privs {
 		sigs[i] = sdk.StdSignature{PubKey: priv.PubKey(), Signature: priv.Sign(signBytes), Sequence: seqs[i]}
 	}
-	tx := sdk.NewStdTx(msg, fee, sigs)
+	tx := sdk.NewStdTx(msgs, fee, sigs)
 	return tx
 }
 
@@ -68,7 +74,8 @@ func TestAnteHandlerSigErrors(t *testing.T) {
 	// setup
 	ms, capKey := setupMultiStore()
 	mapper := NewAccountMapper(capKey, &BaseAccount{})
-	anteHandler := NewAnteHandler(mapper)
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
 	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
 
 	// keys and addresses
@@ -107,7 +114,8 @@ func TestAnteHandlerSequences(t *testing.T) {
 	// setup
 	ms, capKey := setupMultiStore()
 	mapper := NewAccountMapper(capKey, &BaseAccount{})
-	anteHandler := NewAnteHandler(mapper)
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
 	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
 
 	// keys and addresses
@@ -166,12 +174,67 @@ func TestAnteHandlerSequences(t *testing.T) {
 	checkValidTx(t, anteHandler, ctx, tx)
 }
 
+// Test that a tx with many Msgs is signed once by the union of their signers.
+func TestAnteHandlerMultiMsgs(t *testing.T) {
+	// setup
+	ms, capKey := setupMultiStore()
+	mapper := NewAccountMapper(capKey, &BaseAccount{})
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
+	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
+
+	// keys and addresses
+	priv1, addr1 := privAndAddr()
+	priv2, addr2 := privAndAddr()
+	priv3, addr3 := privAndAddr()
+
+	// set the accounts
+	for _, addr := range []sdk.Address{addr1, addr2, addr3} {
+		acc := mapper.NewAccountWithAddress(ctx, addr)
+		acc.SetCoins(newCoins())
+		mapper.SetAccount(ctx, acc)
+	}
+
+	var tx sdk.Tx
+	msg1 := newTestMsg(addr1, addr2)
+	msg2 := newTestMsg(addr3, addr1)
+	msgs := []sdk.Msg{msg1, msg2}
+	fee := newStdFee()
+
+	// addr1 only signs once even though it is a signer of both msgs
+	privs, seqs := []crypto.PrivKey{priv1, priv2, priv3, priv1}, []int64{0, 0, 0, 0}
+	tx = newTestTxMsgs(ctx, msgs, privs, seqs, fee)
+	checkInvalidTx(t, anteHandler, ctx, tx, sdk.CodeUnauthorized)
+
+	privs, seqs = []crypto.PrivKey{priv1, priv2, priv3}, []int64{0, 0, 0}
+	tx = newTestTxMsgs(ctx, msgs, privs, seqs, fee)
+	checkValidTx(t, anteHandler, ctx, tx)
+
+	// signing over only one of the msgs fails
+	signBytes := sdk.StdSignBytes(ctx.ChainID(), []int64{1, 1, 1}, fee, []sdk.Msg{msg1})
+	privs, seqs = []crypto.PrivKey{priv1, priv2, priv3}, []int64{1, 1, 1}
+	tx = newTestTxWithSignBytes(msgs, privs, seqs, fee, signBytes)
+	checkInvalidTx(t, anteHandler, ctx, tx, sdk.CodeUnauthorized)
+
+	// only the fee payer pays
+	acc1 := mapper.GetAccount(ctx, addr1)
+	acc2 := mapper.GetAccount(ctx, addr2)
+	assert.Equal(t, newCoins().Minus(fee.Amount), acc1.GetCoins())
+	assert.Equal(t, newCoins(), acc2.GetCoins())
+
+	// signatures must be in the order of first appearance
+	privs, seqs = []crypto.PrivKey{priv1, priv3, priv2}, []int64{1, 1, 1}
+	tx = newTestTxMsgs(ctx, msgs, privs, seqs, fee)
+	checkInvalidTx(t, anteHandler, ctx, tx, sdk.CodeUnauthorized)
+}
+
 // Test logic around fee deduction.
 func TestAnteHandlerFees(t *testing.T) {
 	// setup
 	ms, capKey := setupMultiStore()
 	mapper := NewAccountMapper(capKey, &BaseAccount{})
-	anteHandler := NewAnteHandler(mapper)
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
 	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
 
 	// keys and addresses
@@ -185,7 +248,7 @@ func TestAnteHandlerFees(t *testing.T) {
 	var tx sdk.Tx
 	msg := newTestMsg(addr1)
 	privs, seqs := []crypto.PrivKey{priv1}, []int64{0}
-	fee := sdk.NewStdFee(100,
+	fee := sdk.NewStdFee(10000,
 		sdk.Coin{"atom", 150},
 	)
 
@@ -197,16 +260,135 @@ func TestAnteHandlerFees(t *testing.T) {
 	mapper.SetAccount(ctx, acc1)
 	checkInvalidTx(t, anteHandler, ctx, tx, sdk.CodeInsufficientFunds)
 
+	assert.True(t, feeCollector.GetCollectedFees(ctx).IsZero())
+
 	acc1.SetCoins(sdk.Coins{{"atom", 150}})
 	mapper.SetAccount(ctx, acc1)
 	checkValidTx(t, anteHandler, ctx, tx)
+
+	// the fee is collected
+	assert.True(t, mapper.GetAccount(ctx, addr1).GetCoins().IsZero())
+	assert.Equal(t, sdk.Coins{{"atom", 150}}, feeCollector.GetCollectedFees(ctx))
+}
+
+// Test that the minimum gas prices are enforced in CheckTx only.
+func TestAnteHandlerMinimumGasPrices(t *testing.T) {
+	// setup
+	ms, capKey := setupMultiStore()
+	mapper := NewAccountMapper(capKey, &BaseAccount{})
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
+	header := abci.Header{ChainID: "mychainid"}
+	prices := sdk.Coins{{"atom", 2}, {"photon", 1}}
+	checkCtx := sdk.NewContext(ms, header, true, nil).WithMinimumGasPrices(prices)
+	deliverCtx := sdk.NewContext(ms, header, false, nil).WithMinimumGasPrices(prices)
+
+	// keys and addresses
+	priv1, addr1 := privAndAddr()
+
+	// set the accounts
+	acc1 := mapper.NewAccountWithAddress(checkCtx, addr1)
+	acc1.SetCoins(sdk.Coins{{"atom", 100000}, {"photon", 100000}, {"steak", 100000}})
+	mapper.SetAccount(checkCtx, acc1)
+
+	msg := newTestMsg(addr1)
+	privs := []crypto.PrivKey{priv1}
+
+	// the fee is below the price of every denom
+	fee := sdk.NewStdFee(10000, sdk.Coin{"atom", 19999}, sdk.Coin{"photon", 9999})
+	tx := newTestTx(checkCtx, msg, privs, []int64{0}, fee)
+	checkInvalidTx(t, anteHandler, checkCtx, tx, sdk.CodeInsufficientFee)
+
+	// delivered txs don't need the minimum
+	checkValidTx(t, anteHandler, deliverCtx, tx)
+
+	// a fee paying the price of one denom is enough
+	fee = sdk.NewStdFee(10000, sdk.Coin{"photon", 10000})
+	tx = newTestTx(checkCtx, msg, privs, []int64{1}, fee)
+	checkValidTx(t, anteHandler, checkCtx, tx)
+
+	fee = sdk.NewStdFee(10000, sdk.Coin{"atom", 20000})
+	tx = newTestTx(checkCtx, msg, privs, []int64{2}, fee)
+	checkValidTx(t, anteHandler, checkCtx, tx)
+
+	// fees in other denoms don't count
+	fee = sdk.NewStdFee(10000, sdk.Coin{"steak", 100000})
+	tx = newTestTx(checkCtx, msg, privs, []int64{3}, fee)
+	checkInvalidTx(t, anteHandler, checkCtx, tx, sdk.CodeInsufficientFee)
+
+	// no minimum without prices
+	checkValidTx(t, anteHandler, checkCtx.WithMinimumGasPrices(nil), tx)
+}
+
+func TestCheckMinimumFee(t *testing.T) {
+	var testCases = []struct {
+		prices sdk.Coins
+		fee    sdk.StdFee
+		ok     bool
+	}{
+		{nil, sdk.NewStdFee(100), true},
+		{sdk.Coins{{"atom", 1}}, sdk.NewStdFee(100), false},
+		{sdk.Coins{{"atom", 1}}, sdk.NewStdFee(100, sdk.Coin{"atom", 100}), true},
+		{sdk.Coins{{"atom", 1}}, sdk.NewStdFee(0), true},
+		{sdk.Coins{{"atom", 0}}, sdk.NewStdFee(100), true},
+		// the required fee overflows
+		{sdk.Coins{{"atom", math.MaxInt64}}, sdk.NewStdFee(2, sdk.Coin{"atom", math.MaxInt64}), false},
+	}
+
+	for i, tc := range testCases {
+		res := checkMinimumFee(tc.prices, tc.fee)
+		assert.Equal(t, tc.ok, res.IsOK(), "case %d: %s", i, res.Log)
+	}
+}
+
+// Test that the gas limit of the fee is enforced.
+func TestAnteHandlerOutOfGas(t *testing.T) {
+	// setup
+	ms, capKey := setupMultiStore()
+	mapper := NewAccountMapper(capKey, &BaseAccount{})
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
+	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
+
+	// keys and addresses
+	priv1, addr1 := privAndAddr()
+
+	// set the accounts
+	acc1 := mapper.NewAccountWithAddress(ctx, addr1)
+	acc1.SetCoins(newCoins())
+	mapper.SetAccount(ctx, acc1)
+
+	// msg and signatures
+	var tx sdk.Tx
+	msg := newTestMsg(addr1)
+	privs, seqs := []crypto.PrivKey{priv1}, []int64{0}
+
+	// not enough gas to even load the account,
+	// which aborts with the limited meter
+	fee := sdk.NewStdFee(1, sdk.Coin{"atom", 150})
+	tx = newTestTx(ctx, msg, privs, seqs, fee)
+	newCtx, result, abort := anteHandler(ctx.WithMultiStore(ms.CacheMultiStore()), tx)
+	assert.True(t, abort)
+	assert.Equal(t, sdk.CodeOutOfGas, result.Code, result.Log)
+	assert.Equal(t, fee.Gas, newCtx.GasMeter().Limit())
+	assert.True(t, newCtx.GasMeter().GasConsumed() > fee.Gas)
+
+	// enough gas, and the returned context carries the limited meter
+	fee = newStdFee()
+	tx = newTestTx(ctx, msg, privs, seqs, fee)
+	newCtx, result, abort = anteHandler(ctx, tx)
+	assert.False(t, abort)
+	assert.True(t, result.IsOK())
+	assert.Equal(t, fee.Gas, newCtx.GasMeter().Limit())
+	assert.True(t, newCtx.GasMeter().GasConsumed() >= verifyCost)
 }
 
 func TestAnteHandlerBadSignBytes(t *testing.T) {
 	// setup
 	ms, capKey := setupMultiStore()
 	mapper := NewAccountMapper(capKey, &BaseAccount{})
-	anteHandler := NewAnteHandler(mapper)
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
 	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
 
 	// keys and addresses
@@ -256,8 +438,8 @@ func TestAnteHandlerBadSignBytes(t *testing.T) {
 	privs, seqs = []crypto.PrivKey{priv1}, []int64{1}
 	for _, cs := range cases {
 		tx := newTestTxWithSignBytes(
-			msg, privs, seqs, fee,
-			sdk.StdSignBytes(cs.chainID, cs.seqs, cs.fee, cs.msg),
+			[]sdk.Msg{msg}, privs, seqs, fee,
+			sdk.StdSignBytes(cs.chainID, cs.seqs, cs.fee, []sdk.Msg{cs.msg}),
 		)
 		checkInvalidTx(t, anteHandler, ctx, tx, cs.code)
 	}
@@ -279,7 +461,8 @@ func TestAnteHandlerSetPubKey(t *testing.T) {
 	// setup
 	ms, capKey := setupMultiStore()
 	mapper := NewAccountMapper(capKey, &BaseAccount{})
-	anteHandler := NewAnteHandler(mapper)
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
 	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
 
 	// keys and addresses
@@ -323,3 +506,177 @@ func TestAnteHandlerSetPubKey(t *testing.T) {
 	acc2 = mapper.GetAccount(ctx, addr2)
 	assert.True(t, acc2.GetPubKey().Empty())
 }
+
+// Test a 2-of-3 multisig account, and the gas of its signatures.
+func TestAnteHandlerMultiSig(t *testing.T) {
+	// setup
+	ms, capKey := setupMultiStore()
+	mapper := NewAccountMapper(capKey, &BaseAccount{})
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
+	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
+
+	// keys and addresses
+	privs, pubKeys := privsAndPubKeys(3)
+	pk, err := NewMultiSigPubKey(2, pubKeys)
+	require.Nil(t, err)
+	addr := pk.Address()
+
+	// set the account
+	acc := mapper.NewAccountWithAddress(ctx, addr)
+	acc.SetCoins(newCoins())
+	mapper.SetAccount(ctx, acc)
+
+	msg := newTestMsg(addr)
+	fee := newStdFee()
+	newMultiSigTx := func(seq int64, signers []crypto.PrivKey) sdk.Tx {
+		signBytes := sdk.StdSignBytes(ctx.ChainID(), []int64{seq}, fee, []sdk.Msg{msg})
+		sigs := []sdk.StdSignature{{
+			PubKey:    pk.Wrap(),
+			Signature: multiSign(t, pk, signers, signBytes),
+			Sequence:  seq,
+		}}
+		return sdk.NewStdTx([]sdk.Msg{msg}, fee, sigs)
+	}
+
+	// one signature is not enough
+	checkInvalidTx(t, anteHandler, ctx, newMultiSigTx(0, privs[:1]), sdk.CodeUnauthorized)
+	assert.True(t, mapper.GetAccount(ctx, addr).GetPubKey().Empty())
+
+	// two are, and set the multisig key
+	checkValidTx(t, anteHandler, ctx, newMultiSigTx(0, privs[1:]))
+	acc = mapper.GetAccount(ctx, addr)
+	assert.True(t, pk.Equals(acc.GetPubKey()))
+
+	// each signature is charged
+	newCtx, result, abort := anteHandler(ctx, newMultiSigTx(1, privs))
+	require.False(t, abort, result.Log)
+	assert.True(t, newCtx.GasMeter().GasConsumed() >= 3*verifyCost)
+
+	// a key of a single member can't sign for the account
+	tx := newTestTx(ctx, msg, privs[:1], []int64{2}, fee)
+	checkInvalidTx(t, anteHandler, ctx, tx, sdk.CodeUnauthorized)
+}
+
+// Test that fees can't be paid with the locked coins of a vesting account.
+func TestAnteHandlerVestingFees(t *testing.T) {
+	// setup
+	ms, capKey := setupMultiStore()
+	mapper := NewAccountMapper(capKey, &BaseAccount{})
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	anteHandler := NewAnteHandler(mapper, feeCollector)
+	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid", Height: 1}, false, nil)
+
+	// keys and addresses
+	priv1, addr1 := privAndAddr()
+
+	// set an account whose coins are locked until height 2
+	base := NewBaseAccountWithAddress(addr1)
+	base.SetCoins(newCoins())
+	mapper.SetAccount(ctx, NewDelayedVestingAccount(base, 2, true))
+
+	// msg and signatures
+	var tx sdk.Tx
+	msg := newTestMsg(addr1)
+	fee := newStdFee()
+	privs, seqs := []crypto.PrivKey{priv1}, []int64{0}
+
+	tx = newTestTx(ctx, msg, privs, seqs, fee)
+	checkInvalidTx(t, anteHandler, ctx, tx, sdk.CodeInsufficientFunds)
+
+	// once vested, the coins pay the fee
+	ctx = ctx.WithBlockHeight(2)
+	tx = newTestTx(ctx, msg, privs, seqs, fee)
+	checkValidTx(t, anteHandler, ctx, tx)
+	acc := mapper.GetAccount(ctx, addr1)
+	assert.Equal(t, newCoins().Minus(fee.Amount), acc.GetCoins())
+	assert.IsType(t, &DelayedVestingAccount{}, acc)
+}
+
+// a FeeGrantKeeper whose granters allow their grantees a total of fees
+type testFeeGrantKeeper map[string]sdk.Coins
+
+func (fgk testFeeGrantKeeper) UseGrantedFees(ctx sdk.Context, granter, grantee sdk.Address, fee sdk.Coins, msgs []sdk.Msg) sdk.Error {
+	key := string(granter) + string(grantee)
+	left := fgk[key].Minus(fee)
+	if !left.IsNotNegative() {
+		return sdk.ErrUnauthorized("no fee allowance")
+	}
+	fgk[key] = left
+	return nil
+}
+
+// Test that granters pay the fees of their grantees.
+func TestAnteHandlerFeeGrants(t *testing.T) {
+	// setup
+	ms, capKey := setupMultiStore()
+	mapper := NewAccountMapper(capKey, &BaseAccount{})
+	feeCollector := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	feeGrants := testFeeGrantKeeper{}
+	anteHandler := NewAnteHandlerWithFeeGrants(mapper, feeCollector, feeGrants)
+	ctx := sdk.NewContext(ms, abci.Header{ChainID: "mychainid"}, false, nil)
+
+	// keys and addresses
+	priv1, addr1 := privAndAddr()
+	priv2, addr2 := privAndAddr()
+	_, addr3 := privAndAddr()
+
+	// addr1 has no coins, addr2 and addr3 grant it fees
+	acc1 := mapper.NewAccountWithAddress(ctx, addr1)
+	mapper.SetAccount(ctx, acc1)
+	for _, addr := range []sdk.Address{addr2, addr3} {
+		acc := mapper.NewAccountWithAddress(ctx, addr)
+		acc.SetCoins(newCoins())
+		mapper.SetAccount(ctx, acc)
+	}
+	feeGrants[string(addr2)+string(addr1)] = sdk.Coins{{"atom", 200}}
+	feeGrants[string(addr3)+string(addr1)] = sdk.Coins{{"atom", 100}}
+
+	// msg and signatures
+	var tx sdk.Tx
+	msg := newTestMsg(addr1)
+	fee := newStdFee()
+
+	// invalid txs run on a cache, as in baseapp, which drops their writes
+	checkInvalidGrantTx := func(anteHandler sdk.AnteHandler, tx sdk.Tx, code sdk.CodeType) {
+		checkInvalidTx(t, anteHandler, ctx.WithMultiStore(ms.CacheMultiStore()), tx, code)
+	}
+
+	// without a granter, addr1 can't pay the fee
+	tx = newTestTx(ctx, msg, []crypto.PrivKey{priv1}, []int64{0}, fee)
+	checkInvalidGrantTx(anteHandler, tx, sdk.CodeInsufficientFunds)
+
+	// with a granter, the granter pays it, but no more than it allows
+	fee.Granter = addr3
+	tx = newTestTx(ctx, msg, []crypto.PrivKey{priv1}, []int64{0}, fee)
+	checkInvalidGrantTx(anteHandler, tx, sdk.CodeUnauthorized)
+	fee.Granter = addr2
+	tx = newTestTx(ctx, msg, []crypto.PrivKey{priv1}, []int64{0}, fee)
+	checkValidTx(t, anteHandler, ctx, tx)
+	assert.Equal(t, newCoins().Minus(fee.Amount), mapper.GetAccount(ctx, addr2).GetCoins())
+	assert.Empty(t, mapper.GetAccount(ctx, addr1).GetCoins())
+	assert.Equal(t, int64(1), mapper.GetAccount(ctx, addr1).GetSequence())
+	assert.Equal(t, fee.Amount, feeCollector.GetCollectedFees(ctx))
+	assert.Equal(t, sdk.Coins{{"atom", 50}}, feeGrants[string(addr2)+string(addr1)])
+
+	// the granter may also sign
+	msg = newTestMsg(addr1, addr2)
+	feeGrants[string(addr2)+string(addr1)] = sdk.Coins{{"atom", 150}}
+	tx = newTestTx(ctx, msg, []crypto.PrivKey{priv1, priv2}, []int64{1, 0}, fee)
+	checkValidTx(t, anteHandler, ctx, tx)
+	acc2 := mapper.GetAccount(ctx, addr2)
+	assert.Equal(t, newCoins().Minus(fee.Amount).Minus(fee.Amount), acc2.GetCoins())
+	assert.Equal(t, int64(1), acc2.GetSequence())
+
+	// the granter is signed over
+	msg = newTestMsg(addr1)
+	feeGrants[string(addr3)+string(addr1)] = sdk.Coins{{"atom", 150}}
+	tx = newTestTx(ctx, msg, []crypto.PrivKey{priv1}, []int64{2}, fee)
+	stdTx := tx.(sdk.StdTx)
+	stdTx.Fee.Granter = addr3
+	checkInvalidGrantTx(anteHandler, stdTx, sdk.CodeUnauthorized)
+
+	// NewAnteHandler doesn't support fee grants
+	anteHandler = NewAnteHandler(mapper, feeCollector)
+	checkInvalidGrantTx(anteHandler, tx, sdk.CodeUnauthorized)
+}
@@ -66,7 +66,7 @@ func (c commander) getAccountCmd(cmd *cobra.Command, args []string) error {
 
 	ctx := context.NewCoreContextFromViper()
 
-	res, err := ctx.Query(key, c.storeName)
+	res, err := ctx.Query(auth.AddressStoreKey(key), c.storeName)
 	if err != nil {
 		return err
 	}
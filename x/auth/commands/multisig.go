@@ -0,0 +1,239 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	crypto "github.com/tendermint/go-crypto"
+
+	"github.com/cosmos/cosmos-sdk/client"
+	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/client/keys"
+	"github.com/cosmos/cosmos-sdk/client/tx"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+)
+
+// A multisig account signs a tx offline:
+//
+// 1. the multisig-address command gives the address of the account,
+//    and its key,
+// 2. a command with --generate-only, such as send, prints the unsigned tx
+//    from the address,
+// 3. the members of the account sign the tx with the sign command,
+// 4. the multisign command combines their signatures into the tx,
+// 5. and the broadcast command broadcasts it.
+
+// MultiSigAddressCmd prints the address and the key of a multisig account
+func MultiSigAddressCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "multisig-address <threshold> <key>...",
+		Short: "Show the address of the multisig account of a threshold of keys",
+		Long: `Show the address and the key of the account which signs with a
+threshold of keys. Each key is the name of a local key, or the hex key
+of someone else's key, from keys show.`,
+		RunE: multiSigAddressCmd,
+	}
+}
+
+func multiSigAddressCmd(cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return errors.New("You must provide a threshold and keys")
+	}
+	threshold, err := strconv.Atoi(args[0])
+	if err != nil {
+		return errors.Errorf("Invalid threshold %s", args[0])
+	}
+	pubKeys := make([]crypto.PubKey, len(args)-1)
+	for i, arg := range args[1:] {
+		pubKeys[i], err = getPubKey(arg)
+		if err != nil {
+			return err
+		}
+	}
+	pk, err := auth.NewMultiSigPubKey(threshold, pubKeys)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Address: %s\nKey: %X\n", pk.Address(), pk.Bytes())
+	return nil
+}
+
+// getPubKey returns the key of a local key name, or of its hex
+func getPubKey(nameOrHex string) (crypto.PubKey, error) {
+	kb, err := keys.GetKeyBase()
+	if err != nil {
+		return crypto.PubKey{}, err
+	}
+	info, err := kb.Get(nameOrHex)
+	if err == nil {
+		return info.PubKey, nil
+	}
+	bz, err := hex.DecodeString(nameOrHex)
+	if err != nil {
+		return crypto.PubKey{}, errors.Errorf("No key for: %s", nameOrHex)
+	}
+	return crypto.PubKeyFromBytes(bz)
+}
+
+// SignTxCmd signs an unsigned tx offline
+func SignTxCmd(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign <tx file>",
+		Short: "Sign an unsigned tx offline",
+		Long: `Sign an unsigned tx, printed by a command with --generate-only,
+and print the signature for the multisign command.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return signTxCmd(cdc, args)
+		},
+	}
+	cmd.Flags().String(client.FlagName, "", "Name of private key with which to sign")
+	cmd.Flags().String(client.FlagChainID, "", "Chain ID of tendermint node")
+	return cmd
+}
+
+func signTxCmd(cdc *wire.Codec, args []string) error {
+	if len(args) != 1 || len(args[0]) == 0 {
+		return errors.New("You must provide a tx file")
+	}
+	stdTx, err := readStdTx(cdc, args[0])
+	if err != nil {
+		return err
+	}
+	ctx := context.NewCoreContextFromViper()
+	if ctx.FromAddressName == "" {
+		return errors.Errorf("must provide a from address name")
+	}
+	passphrase, err := ctx.GetPassphraseFromStdin(ctx.FromAddressName)
+	if err != nil {
+		return err
+	}
+
+	kb, err := keys.GetKeyBase()
+	if err != nil {
+		return err
+	}
+	sig, pubKey, err := kb.Sign(ctx.FromAddressName, passphrase, stdSignBytes(ctx.ChainID, stdTx))
+	if err != nil {
+		return err
+	}
+	bz, err := cdc.MarshalBinary(sdk.StdSignature{PubKey: pubKey, Signature: sig})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%X\n", bz)
+	return nil
+}
+
+// MultiSignTxCmd combines the signatures of the members of a multisig
+// account into a tx
+func MultiSignTxCmd(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "multisign <tx file> <multisig key> <signature file>...",
+		Short: "Combine the signatures of a multisig account into a tx",
+		Long: `Combine the signatures of the members of a multisig account, from
+the sign command, into a tx, and print the signed tx for the broadcast
+command. The multisig key is the one of multisig-address.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return multiSignTxCmd(cdc, args)
+		},
+	}
+	cmd.Flags().String(client.FlagChainID, "", "Chain ID of tendermint node")
+	return cmd
+}
+
+func multiSignTxCmd(cdc *wire.Codec, args []string) error {
+	if len(args) < 3 {
+		return errors.New("You must provide a tx file, a multisig key and signature files")
+	}
+	stdTx, err := readStdTx(cdc, args[0])
+	if err != nil {
+		return err
+	}
+	pubKey, err := getPubKey(args[1])
+	if err != nil {
+		return err
+	}
+	pk, ok := pubKey.Unwrap().(auth.MultiSigPubKey)
+	if !ok {
+		return errors.Errorf("%s is not a multisig key", args[1])
+	}
+
+	// find the signature of the account
+	signers := sdk.GetSigners(stdTx.Msgs)
+	if len(stdTx.Signatures) != len(signers) {
+		return errors.Errorf("%d signatures for %d signers", len(stdTx.Signatures), len(signers))
+	}
+	index := -1
+	for i, signer := range signers {
+		if bytes.Equal(signer, pk.Address()) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return errors.Errorf("%s is not a signer of the tx", pk.Address())
+	}
+
+	// check the signatures of the members, and combine them
+	signBytes := stdSignBytes(viper.GetString(client.FlagChainID), stdTx)
+	pubKeys := make([]crypto.PubKey, len(args)-2)
+	sigs := make([]crypto.Signature, len(args)-2)
+	for i, path := range args[2:] {
+		bz, err := tx.ReadHexFile(path)
+		if err != nil {
+			return err
+		}
+		var sig sdk.StdSignature
+		if err = cdc.UnmarshalBinary(bz, &sig); err != nil {
+			return err
+		}
+		if !sig.PubKey.VerifyBytes(signBytes, sig.Signature) {
+			return errors.Errorf("Invalid signature in %s", path)
+		}
+		pubKeys[i], sigs[i] = sig.PubKey, sig.Signature
+	}
+	ms, err := auth.NewMultiSignature(pk, pubKeys, sigs)
+	if err != nil {
+		return err
+	}
+	if !pk.VerifyBytes(signBytes, ms.Wrap()) {
+		return errors.Errorf("%d signatures for a threshold of %d", len(sigs), pk.Threshold)
+	}
+
+	stdTx.Signatures[index].PubKey = pk.Wrap()
+	stdTx.Signatures[index].Signature = ms.Wrap()
+	bz, err := cdc.MarshalBinary(stdTx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%X\n", bz)
+	return nil
+}
+
+func readStdTx(cdc *wire.Codec, path string) (stdTx sdk.StdTx, err error) {
+	bz, err := tx.ReadHexFile(path)
+	if err != nil {
+		return
+	}
+	err = cdc.UnmarshalBinary(bz, &stdTx)
+	return
+}
+
+// stdSignBytes returns the bytes signed by the signers of stdTx, whose
+// signatures hold their sequences.
+func stdSignBytes(chainID string, stdTx sdk.StdTx) []byte {
+	sequences := make([]int64, len(stdTx.Signatures))
+	for i, sig := range stdTx.Signatures {
+		sequences[i] = sig.Sequence
+	}
+	return sdk.StdSignBytes(chainID, sequences, stdTx.Fee, stdTx.Msgs)
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"encoding/json"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	wire "github.com/cosmos/cosmos-sdk/wire"
+)
+
+// CollectedFeesKey is the store key of the fee pool
+var CollectedFeesKey = []byte("collectedFees")
+
+// FeeCollectionKeeper keeps the fee pool: the fees deducted by the
+// ante handler, which accumulate until a module pays them out,
+// eg. to the validators at the end of the block.
+type FeeCollectionKeeper struct {
+
+	// The (unexposed) key used to access the fee pool from the Context.
+	key sdk.StoreKey
+
+	// The wire codec for binary encoding/decoding of the fee pool.
+	cdc *wire.Codec
+}
+
+// NewFeeCollectionKeeper returns a FeeCollectionKeeper
+// which keeps the fee pool in the store of key.
+// The store may be shared, eg. with the AccountMapper.
+func NewFeeCollectionKeeper(cdc *wire.Codec, key sdk.StoreKey) FeeCollectionKeeper {
+	return FeeCollectionKeeper{
+		key: key,
+		cdc: cdc,
+	}
+}
+
+// GetCollectedFees returns the fees in the fee pool.
+func (fck FeeCollectionKeeper) GetCollectedFees(ctx sdk.Context) sdk.Coins {
+	store := ctx.KVStore(fck.key)
+	bz := store.Get(CollectedFeesKey)
+	if bz == nil {
+		return sdk.Coins{}
+	}
+
+	var fees sdk.Coins
+	err := fck.cdc.UnmarshalBinary(bz, &fees)
+	if err != nil {
+		panic(err)
+	}
+	return fees
+}
+
+func (fck FeeCollectionKeeper) setCollectedFees(ctx sdk.Context, fees sdk.Coins) {
+	store := ctx.KVStore(fck.key)
+	if fees.IsZero() {
+		store.Delete(CollectedFeesKey)
+		return
+	}
+	bz, err := fck.cdc.MarshalBinary(fees)
+	if err != nil {
+		panic(err)
+	}
+	store.Set(CollectedFeesKey, bz)
+}
+
+// AddCollectedFees adds fees to the fee pool
+// and returns the fees in the pool.
+func (fck FeeCollectionKeeper) AddCollectedFees(ctx sdk.Context, fees sdk.Coins) sdk.Coins {
+	newFees := fck.GetCollectedFees(ctx).Plus(fees)
+	fck.setCollectedFees(ctx, newFees)
+	return newFees
+}
+
+// ClearCollectedFees empties the fee pool.
+func (fck FeeCollectionKeeper) ClearCollectedFees(ctx sdk.Context) {
+	fck.setCollectedFees(ctx, sdk.Coins{})
+}
+
+// InitGenesis sets the fee pool from the genesis coins, if any.
+func (fck FeeCollectionKeeper) InitGenesis(ctx sdk.Context, data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var fees sdk.Coins
+	err := json.Unmarshal(data, &fees)
+	if err != nil {
+		return err
+	}
+	if !fees.IsValid() || !fees.IsNotNegative() {
+		return sdk.ErrInvalidCoins(fees.String())
+	}
+	fck.setCollectedFees(ctx, fees)
+	return nil
+}
+
+// ExportGenesis exports the fees in the fee pool,
+// or nothing if the pool is empty.
+func (fck FeeCollectionKeeper) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	fees := fck.GetCollectedFees(ctx)
+	if fees.IsZero() {
+		return nil
+	}
+	bz, err := json.Marshal(fees)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
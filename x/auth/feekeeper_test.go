@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	abci "github.com/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	wire "github.com/cosmos/cosmos-sdk/wire"
+)
+
+func TestFeeCollectionKeeper(t *testing.T) {
+	ms, capKey := setupMultiStore()
+	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
+	fck := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+
+	// no fees at first
+	assert.True(t, fck.GetCollectedFees(ctx).IsZero())
+
+	// fees accumulate
+	fees := fck.AddCollectedFees(ctx, sdk.Coins{{"atom", 10}})
+	assert.Equal(t, sdk.Coins{{"atom", 10}}, fees)
+	fck.AddCollectedFees(ctx, sdk.Coins{{"atom", 5}, {"photon", 3}})
+	assert.Equal(t, sdk.Coins{{"atom", 15}, {"photon", 3}}, fck.GetCollectedFees(ctx))
+
+	fck.ClearCollectedFees(ctx)
+	assert.True(t, fck.GetCollectedFees(ctx).IsZero())
+}
+
+func TestFeeCollectionKeeperGenesis(t *testing.T) {
+	ms, capKey := setupMultiStore()
+	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
+	fck := NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+
+	// an empty pool isn't exported
+	assert.Nil(t, fck.ExportGenesis(ctx))
+	assert.Nil(t, fck.InitGenesis(ctx, nil))
+
+	fck.AddCollectedFees(ctx, sdk.Coins{{"atom", 15}, {"photon", 3}})
+	data := fck.ExportGenesis(ctx)
+	fck.ClearCollectedFees(ctx)
+
+	err := fck.InitGenesis(ctx, data)
+	assert.Nil(t, err)
+	assert.Equal(t, sdk.Coins{{"atom", 15}, {"photon", 3}}, fck.GetCollectedFees(ctx))
+
+	err = fck.InitGenesis(ctx, []byte(`[{"denom":"atom","amount":-1}]`))
+	assert.NotNil(t, err)
+}
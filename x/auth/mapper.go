@@ -14,6 +14,18 @@ import (
 var _ sdk.AccountMapper = (*accountMapper)(nil)
 var _ sdk.AccountMapper = (*sealedAccountMapper)(nil)
 
+// AddressStorePrefix prefixes the keys of all accounts in the store,
+// so that accounts can be iterated and share a store with other data.
+var AddressStorePrefix = []byte("account:")
+
+// AddressStoreKey turns an address into the key
+// under which its account is stored.
+func AddressStoreKey(addr sdk.Address) []byte {
+	key := make([]byte, 0, len(AddressStorePrefix)+len(addr))
+	key = append(key, AddressStorePrefix...)
+	return append(key, addr.Bytes()...)
+}
+
 // Implements sdk.AccountMapper.
 // This AccountMapper encodes/decodes accounts using the
 // go-wire (binary) encoding/decoding library.
@@ -79,7 +91,7 @@ func (am accountMapper) NewAccountWithAddress(ctx sdk.Context, addr sdk.Address)
 // Implements sdk.AccountMapper.
 func (am accountMapper) GetAccount(ctx sdk.Context, addr sdk.Address) sdk.Account {
 	store := ctx.KVStore(am.key)
-	bz := store.Get(addr)
+	bz := store.Get(AddressStoreKey(addr))
 	if bz == nil {
 		return nil
 	}
@@ -92,7 +104,20 @@ func (am accountMapper) SetAccount(ctx sdk.Context, acc sdk.Account) {
 	addr := acc.GetAddress()
 	store := ctx.KVStore(am.key)
 	bz := am.encodeAccount(acc)
-	store.Set(addr, bz)
+	store.Set(AddressStoreKey(addr), bz)
+}
+
+// Implements sdk.AccountMapper.
+func (am accountMapper) IterateAccounts(ctx sdk.Context, process func(sdk.Account) (stop bool)) {
+	store := ctx.KVStore(am.key)
+	iter := store.Iterator(AddressStorePrefix, sdk.PrefixEndBytes(AddressStorePrefix))
+	defer iter.Close()
+	for ; iter.Valid(); iter.Next() {
+		acc := am.decodeAccount(iter.Value())
+		if process(acc) {
+			return
+		}
+	}
 }
 
 //----------------------------------------
@@ -111,22 +136,6 @@ func (sam sealedAccountMapper) WireCodec() *wire.Codec {
 //----------------------------------------
 // misc.
 
-// NOTE: currently unused
-func (am accountMapper) clonePrototypePtr() interface{} {
-	protoRt := reflect.TypeOf(am.proto)
-	if protoRt.Kind() == reflect.Ptr {
-		protoErt := protoRt.Elem()
-		if protoErt.Kind() != reflect.Struct {
-			panic("accountMapper requires a struct proto sdk.Account, or a pointer to one")
-		}
-		protoRv := reflect.New(protoErt)
-		return protoRv.Interface()
-	} else {
-		protoRv := reflect.New(protoRt)
-		return protoRv.Interface()
-	}
-}
-
 // Creates a new struct (or pointer to struct) from am.proto.
 func (am accountMapper) clonePrototype() sdk.Account {
 	protoRt := reflect.TypeOf(am.proto)
@@ -152,7 +161,9 @@ func (am accountMapper) clonePrototype() sdk.Account {
 }
 
 func (am accountMapper) encodeAccount(acc sdk.Account) []byte {
-	bz, err := am.cdc.MarshalBinary(acc)
+	// encode the interface, with the type byte of the concrete account,
+	// which decodeAccount reads
+	bz, err := am.cdc.MarshalBinary(struct{ sdk.Account }{acc})
 	if err != nil {
 		panic(err)
 	}
@@ -169,17 +180,4 @@ func (am accountMapper) decodeAccount(bz []byte) sdk.Account {
 
 	acc := accI.(struct{ sdk.Account }).Account
 	return acc
-
-	/*
-		accPtr := am.clonePrototypePtr()
-			err := am.cdc.UnmarshalBinary(bz, accPtr)
-			if err != nil {
-				panic(err)
-			}
-			if reflect.ValueOf(am.proto).Kind() == reflect.Ptr {
-				return reflect.ValueOf(accPtr).Interface().(sdk.Account)
-			} else {
-				return reflect.ValueOf(accPtr).Elem().Interface().(sdk.Account)
-			}
-	*/
 }
@@ -25,6 +25,8 @@ func setupMultiStore() (sdk.MultiStore, *sdk.KVStoreKey) {
 	var _ = oldwire.RegisterInterface(
 		struct{ sdk.Account }{},
 		oldwire.ConcreteType{&BaseAccount{}, 0x1},
+		oldwire.ConcreteType{&ContinuousVestingAccount{}, 0x2},
+		oldwire.ConcreteType{&DelayedVestingAccount{}, 0x3},
 	)
 
 	return ms, capKey
@@ -79,3 +81,38 @@ func TestAccountMapperSealed(t *testing.T) {
 	mapperSealed = NewAccountMapperSealed(capKey, &BaseAccount{})
 	assert.Panics(t, func() { mapperSealed.WireCodec() })
 }
+
+func TestAccountMapperIterate(t *testing.T) {
+	ms, capKey := setupMultiStore()
+
+	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
+	mapper := NewAccountMapper(capKey, &BaseAccount{})
+
+	// data of other modules sharing the store must be skipped
+	ctx.KVStore(capKey).Set([]byte("TrendKey"), []byte("icecold"))
+
+	addrs := []sdk.Address{
+		sdk.Address([]byte("addr3")),
+		sdk.Address([]byte("addr1")),
+		sdk.Address([]byte("addr2")),
+	}
+	for _, addr := range addrs {
+		mapper.SetAccount(ctx, mapper.NewAccountWithAddress(ctx, addr))
+	}
+
+	// accounts are visited in address order
+	var visited []sdk.Address
+	mapper.IterateAccounts(ctx, func(acc sdk.Account) bool {
+		visited = append(visited, acc.GetAddress())
+		return false
+	})
+	assert.Equal(t, []sdk.Address{addrs[1], addrs[2], addrs[0]}, visited)
+
+	// returning true stops the iteration
+	visited = nil
+	mapper.IterateAccounts(ctx, func(acc sdk.Account) bool {
+		visited = append(visited, acc.GetAddress())
+		return true
+	})
+	assert.Equal(t, []sdk.Address{addrs[1]}, visited)
+}
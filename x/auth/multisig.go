@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+
+	crypto "github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+)
+
+// The type byte and name of multisig keys and signatures in the
+// encodings of crypto.PubKey and crypto.Signature.
+const (
+	TypeMultiSig = 0x10
+	NameMultiSig = "multisig"
+)
+
+func init() {
+	crypto.PubKeyMapper.RegisterImplementation(MultiSigPubKey{}, NameMultiSig, TypeMultiSig)
+	crypto.SignatureMapper.RegisterImplementation(MultiSignature{}, NameMultiSig, TypeMultiSig)
+}
+
+//-----------------------------------------------------------
+// MultiSigPubKey
+
+var _ crypto.PubKeyInner = MultiSigPubKey{}
+
+// MultiSigPubKey is the public key of a k-of-n multisig account:
+// it verifies a MultiSignature of at least Threshold of its PubKeys.
+// Its address is the hash of its encoding, so it depends on the
+// threshold and on the member keys in order.
+type MultiSigPubKey struct {
+	Threshold int             `json:"threshold"`
+	PubKeys   []crypto.PubKey `json:"pubkeys"`
+}
+
+// NewMultiSigPubKey returns the multisig key of threshold of pubKeys.
+// The member keys must be distinct, and can't be multisig keys.
+func NewMultiSigPubKey(threshold int, pubKeys []crypto.PubKey) (MultiSigPubKey, error) {
+	pk := MultiSigPubKey{Threshold: threshold, PubKeys: pubKeys}
+	if err := pk.ValidateBasic(); err != nil {
+		return MultiSigPubKey{}, err
+	}
+	return pk, nil
+}
+
+// ValidateBasic checks that the key can verify signatures.
+func (pk MultiSigPubKey) ValidateBasic() error {
+	if pk.Threshold <= 0 || pk.Threshold > len(pk.PubKeys) {
+		return fmt.Errorf("threshold %d out of 1 to %d keys", pk.Threshold, len(pk.PubKeys))
+	}
+	for i, member := range pk.PubKeys {
+		if member.Empty() {
+			return fmt.Errorf("empty key %d", i)
+		}
+		if _, ok := member.Unwrap().(MultiSigPubKey); ok {
+			return fmt.Errorf("key %d is a multisig key", i)
+		}
+		for _, other := range pk.PubKeys[:i] {
+			if member.Equals(other) {
+				return fmt.Errorf("duplicate key %s", member.KeyString())
+			}
+		}
+	}
+	return nil
+}
+
+// Implements crypto.PubKeyInner.
+func (pk MultiSigPubKey) AssertIsPubKeyInner() {}
+
+// Implements crypto.PubKeyInner.
+func (pk MultiSigPubKey) Address() crypto.Address {
+	return crypto.Address(crypto.Ripemd160(pk.Bytes()))
+}
+
+// Implements crypto.PubKeyInner.
+func (pk MultiSigPubKey) Bytes() []byte {
+	return wire.BinaryBytes(crypto.PubKey{pk})
+}
+
+// Implements crypto.PubKeyInner.
+func (pk MultiSigPubKey) KeyString() string {
+	return fmt.Sprintf("%X", pk.Bytes())
+}
+
+// Implements crypto.PubKeyInner.
+// The signature must be a MultiSignature of at least Threshold member
+// keys, each of which must be valid.
+func (pk MultiSigPubKey) VerifyBytes(msg []byte, sig crypto.Signature) bool {
+	ms, ok := sig.Unwrap().(MultiSignature)
+	if !ok || pk.ValidateBasic() != nil || !ms.fits(pk) {
+		return false
+	}
+	if len(ms.Sigs) < pk.Threshold {
+		return false
+	}
+	n := 0
+	for i, signed := range ms.Signed {
+		if !signed {
+			continue
+		}
+		if !pk.PubKeys[i].VerifyBytes(msg, ms.Sigs[n]) {
+			return false
+		}
+		n++
+	}
+	return true
+}
+
+// Implements crypto.PubKeyInner.
+func (pk MultiSigPubKey) Equals(other crypto.PubKey) bool {
+	otherPk, ok := other.Unwrap().(MultiSigPubKey)
+	return ok && bytes.Equal(pk.Bytes(), otherPk.Bytes())
+}
+
+// Implements crypto.PubKeyInner.
+func (pk MultiSigPubKey) Wrap() crypto.PubKey {
+	return crypto.PubKey{pk}
+}
+
+// index returns the index of member in the keys, or -1.
+func (pk MultiSigPubKey) index(member crypto.PubKey) int {
+	for i, pubKey := range pk.PubKeys {
+		if pubKey.Equals(member) {
+			return i
+		}
+	}
+	return -1
+}
+
+//-----------------------------------------------------------
+// MultiSignature
+
+var _ crypto.SignatureInner = MultiSignature{}
+
+// MultiSignature is a signature of a MultiSigPubKey. Signed tells which
+// member keys signed, and Sigs holds their signatures in the same order.
+type MultiSignature struct {
+	Signed []bool             `json:"signed"`
+	Sigs   []crypto.Signature `json:"sigs"`
+}
+
+// NewMultiSignature returns the signature of pk made of the signatures
+// sigs of its member keys pubKeys, in any order.
+func NewMultiSignature(pk MultiSigPubKey, pubKeys []crypto.PubKey, sigs []crypto.Signature) (MultiSignature, error) {
+	if len(pubKeys) != len(sigs) {
+		return MultiSignature{}, fmt.Errorf("%d keys for %d signatures", len(pubKeys), len(sigs))
+	}
+	byIndex := make([]crypto.Signature, len(pk.PubKeys))
+	for i, pubKey := range pubKeys {
+		j := pk.index(pubKey)
+		if j < 0 {
+			return MultiSignature{}, fmt.Errorf("key %s is not a member of the multisig key", pubKey.KeyString())
+		}
+		if !byIndex[j].Empty() {
+			return MultiSignature{}, fmt.Errorf("key %s signed twice", pubKey.KeyString())
+		}
+		byIndex[j] = sigs[i]
+	}
+	ms := MultiSignature{Signed: make([]bool, len(pk.PubKeys))}
+	for i, sig := range byIndex {
+		if !sig.Empty() {
+			ms.Signed[i] = true
+			ms.Sigs = append(ms.Sigs, sig)
+		}
+	}
+	return ms, nil
+}
+
+// fits tells if the signature has the shape of a signature of pk.
+func (ms MultiSignature) fits(pk MultiSigPubKey) bool {
+	if len(ms.Signed) != len(pk.PubKeys) {
+		return false
+	}
+	n := 0
+	for _, signed := range ms.Signed {
+		if signed {
+			n++
+		}
+	}
+	return n == len(ms.Sigs)
+}
+
+// Implements crypto.SignatureInner.
+func (ms MultiSignature) AssertIsSignatureInner() {}
+
+// Implements crypto.SignatureInner.
+func (ms MultiSignature) Bytes() []byte {
+	return wire.BinaryBytes(crypto.Signature{ms})
+}
+
+// Implements crypto.SignatureInner.
+func (ms MultiSignature) IsZero() bool { return len(ms.Sigs) == 0 }
+
+// Implements crypto.SignatureInner.
+func (ms MultiSignature) Equals(other crypto.Signature) bool {
+	otherMs, ok := other.Unwrap().(MultiSignature)
+	return ok && bytes.Equal(ms.Bytes(), otherMs.Bytes())
+}
+
+// Implements crypto.SignatureInner.
+func (ms MultiSignature) Wrap() crypto.Signature {
+	return crypto.Signature{ms}
+}
+
+// signatureCount returns the number of signatures to verify in sig.
+func signatureCount(sig crypto.Signature) int64 {
+	if ms, ok := sig.Unwrap().(MultiSignature); ok {
+		return int64(len(ms.Sigs))
+	}
+	return 1
+}
@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	crypto "github.com/tendermint/go-crypto"
+
+	wire "github.com/cosmos/cosmos-sdk/wire"
+)
+
+// generate n private keys and their public keys
+func privsAndPubKeys(n int) ([]crypto.PrivKey, []crypto.PubKey) {
+	privs := make([]crypto.PrivKey, n)
+	pubKeys := make([]crypto.PubKey, n)
+	for i := range privs {
+		privs[i] = crypto.GenPrivKeyEd25519().Wrap()
+		pubKeys[i] = privs[i].PubKey()
+	}
+	return privs, pubKeys
+}
+
+// sign msg with privs into a signature of pk
+func multiSign(t *testing.T, pk MultiSigPubKey, privs []crypto.PrivKey, msg []byte) crypto.Signature {
+	pubKeys := make([]crypto.PubKey, len(privs))
+	sigs := make([]crypto.Signature, len(privs))
+	for i, priv := range privs {
+		pubKeys[i] = priv.PubKey()
+		sigs[i] = priv.Sign(msg)
+	}
+	ms, err := NewMultiSignature(pk, pubKeys, sigs)
+	require.Nil(t, err)
+	return ms.Wrap()
+}
+
+func TestNewMultiSigPubKey(t *testing.T) {
+	_, pubKeys := privsAndPubKeys(3)
+
+	pk, err := NewMultiSigPubKey(2, pubKeys)
+	require.Nil(t, err)
+	assert.Equal(t, 20, len(pk.Address()))
+
+	// the address depends on the threshold and the keys in order
+	other, err := NewMultiSigPubKey(3, pubKeys)
+	require.Nil(t, err)
+	assert.NotEqual(t, pk.Address(), other.Address())
+	other, err = NewMultiSigPubKey(2, []crypto.PubKey{pubKeys[1], pubKeys[0], pubKeys[2]})
+	require.Nil(t, err)
+	assert.NotEqual(t, pk.Address(), other.Address())
+	assert.False(t, pk.Equals(other.Wrap()))
+	assert.True(t, pk.Equals(pk.Wrap()))
+
+	bad := []struct {
+		threshold int
+		pubKeys   []crypto.PubKey
+	}{
+		{0, pubKeys},
+		{4, pubKeys},
+		{1, nil},
+		{1, []crypto.PubKey{pubKeys[0], {}}},
+		{1, []crypto.PubKey{pubKeys[0], pubKeys[0]}},
+		{1, []crypto.PubKey{pubKeys[0], pk.Wrap()}},
+	}
+	for i, tc := range bad {
+		_, err := NewMultiSigPubKey(tc.threshold, tc.pubKeys)
+		assert.NotNil(t, err, "case %d", i)
+	}
+}
+
+func TestMultiSigVerifyBytes(t *testing.T) {
+	privs, pubKeys := privsAndPubKeys(3)
+	pk, err := NewMultiSigPubKey(2, pubKeys)
+	require.Nil(t, err)
+	msg := []byte("msg")
+
+	// any 2 of 3 keys, or all of them, can sign
+	assert.True(t, pk.VerifyBytes(msg, multiSign(t, pk, privs[:2], msg)))
+	assert.True(t, pk.VerifyBytes(msg, multiSign(t, pk, []crypto.PrivKey{privs[2], privs[0]}, msg)))
+	assert.True(t, pk.VerifyBytes(msg, multiSign(t, pk, privs, msg)))
+
+	// but not one of them, nor the signers of another msg
+	assert.False(t, pk.VerifyBytes(msg, multiSign(t, pk, privs[:1], msg)))
+	assert.False(t, pk.VerifyBytes(msg, multiSign(t, pk, privs, []byte("other"))))
+	assert.False(t, pk.VerifyBytes(msg, privs[0].Sign(msg)))
+
+	// the signers must match the signatures
+	ms := multiSign(t, pk, privs[:2], msg).Unwrap().(MultiSignature)
+	ms.Signed = []bool{true, false, true}
+	assert.False(t, pk.VerifyBytes(msg, ms.Wrap()))
+	ms.Signed = []bool{true, true}
+	assert.False(t, pk.VerifyBytes(msg, ms.Wrap()))
+	ms.Signed = []bool{true, true, true}
+	assert.False(t, pk.VerifyBytes(msg, ms.Wrap()))
+
+	// only members sign, once
+	otherPrivs, _ := privsAndPubKeys(1)
+	_, err = NewMultiSignature(pk, []crypto.PubKey{otherPrivs[0].PubKey()}, []crypto.Signature{otherPrivs[0].Sign(msg)})
+	assert.NotNil(t, err)
+	_, err = NewMultiSignature(pk, []crypto.PubKey{pubKeys[0], pubKeys[0]}, []crypto.Signature{privs[0].Sign(msg), privs[0].Sign(msg)})
+	assert.NotNil(t, err)
+}
+
+func TestMultiSigEncoding(t *testing.T) {
+	privs, pubKeys := privsAndPubKeys(3)
+	pk, err := NewMultiSigPubKey(2, pubKeys)
+	require.Nil(t, err)
+	sig := multiSign(t, pk, privs[1:], []byte("msg"))
+	cdc := wire.NewCodec()
+
+	// in an account
+	acc := NewBaseAccountWithAddress(pk.Address())
+	require.Nil(t, acc.SetPubKey(pk.Wrap()))
+	bz, err := cdc.MarshalBinary(acc)
+	require.Nil(t, err)
+	var acc2 BaseAccount
+	require.Nil(t, cdc.UnmarshalBinary(bz, &acc2))
+	assert.True(t, pk.Equals(acc2.PubKey))
+	assert.Equal(t, pk.Address(), acc2.PubKey.Address())
+
+	// as bytes
+	pubKey, err := crypto.PubKeyFromBytes(pk.Bytes())
+	require.Nil(t, err)
+	assert.True(t, pk.Equals(pubKey))
+	sig2, err := crypto.SignatureFromBytes(sig.Bytes())
+	require.Nil(t, err)
+	assert.True(t, sig.Equals(sig2))
+	assert.True(t, pubKey.VerifyBytes([]byte("msg"), sig2))
+
+	// in JSON
+	bz, err = cdc.MarshalJSON(pk.Wrap())
+	require.Nil(t, err)
+	var pubKey2 crypto.PubKey
+	require.Nil(t, cdc.UnmarshalJSON(bz, &pubKey2))
+	assert.True(t, pk.Equals(pubKey2))
+}
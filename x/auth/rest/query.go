@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/gorilla/mux"
 
 	"github.com/cosmos/cosmos-sdk/client/context"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
 )
 
 type commander struct {
@@ -34,7 +36,19 @@ func QueryAccountRequestHandler(storeName string, cdc *wire.Codec, decoder sdk.A
 		}
 		key := sdk.Address(bz)
 
-		res, err := ctx.Query(key, c.storeName)
+		// query the latest state, or the state at ?height=
+		queryCtx := ctx
+		if height := r.URL.Query().Get("height"); height != "" {
+			h, err := strconv.ParseInt(height, 10, 64)
+			if err != nil || h < 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(fmt.Sprintf("Invalid height %s", height)))
+				return
+			}
+			queryCtx = ctx.WithHeight(h)
+		}
+
+		res, err := queryCtx.Query(auth.AddressStoreKey(key), c.storeName)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(fmt.Sprintf("Could't query account. Error: %s", err.Error())))
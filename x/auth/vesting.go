@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// VestingAccount is an account whose original vesting coins unlock on a
+// schedule. The coins which haven't vested are locked: they can't be
+// spent, but they can be delegated.
+type VestingAccount interface {
+	sdk.Account
+
+	// GetVestingCoins returns the original vesting coins which haven't
+	// vested at the block of ctx.
+	GetVestingCoins(ctx sdk.Context) sdk.Coins
+	// GetSpendableCoins returns the coins of the account which aren't
+	// locked at the block of ctx.
+	GetSpendableCoins(ctx sdk.Context) sdk.Coins
+
+	// TrackDelegation records the delegation of amt, from the locked
+	// coins first, before it is subtracted from the coins.
+	TrackDelegation(ctx sdk.Context, amt sdk.Coins)
+	// TrackUndelegation records the undelegation of amt, to the free
+	// coins first, before it is added to the coins.
+	TrackUndelegation(amt sdk.Coins)
+}
+
+// SpendableCoins returns the coins of acc which it can spend at the block
+// of ctx: all of them, but for the locked coins of vesting accounts.
+func SpendableCoins(ctx sdk.Context, acc sdk.Account) sdk.Coins {
+	if vacc, ok := acc.(VestingAccount); ok {
+		return vacc.GetSpendableCoins(ctx)
+	}
+	return acc.GetCoins()
+}
+
+//-----------------------------------------------------------
+// BaseVestingAccount
+
+// BaseVestingAccount holds the coins of a vesting account. Of the coins
+// it delegated, DelegatedVesting were locked, and DelegatedFree weren't.
+// Its schedule is in block times (unix seconds), or in block heights if
+// ByHeight is set, and ends at EndTime.
+type BaseVestingAccount struct {
+	BaseAccount
+	OriginalVesting  sdk.Coins `json:"original_vesting"`
+	DelegatedFree    sdk.Coins `json:"delegated_free"`
+	DelegatedVesting sdk.Coins `json:"delegated_vesting"`
+	EndTime          int64     `json:"end_time"`
+	ByHeight         bool      `json:"by_height"`
+}
+
+// now returns the time of the block of ctx on the schedule.
+func (bva BaseVestingAccount) now(ctx sdk.Context) int64 {
+	if bva.ByHeight {
+		return ctx.BlockHeight()
+	}
+	return ctx.BlockHeader().Time
+}
+
+// spendableCoins returns the coins which aren't locked, given the
+// vesting coins: the vesting coins which weren't delegated are locked.
+func (bva BaseVestingAccount) spendableCoins(vesting sdk.Coins) sdk.Coins {
+	return mapCoins(bva.Coins, func(denom string, amount int64) int64 {
+		locked := max64(vesting.AmountOf(denom)-bva.DelegatedVesting.AmountOf(denom), 0)
+		return max64(amount-locked, 0)
+	})
+}
+
+func (bva *BaseVestingAccount) trackDelegation(vesting, amt sdk.Coins) {
+	var delegatedVesting, delegatedFree sdk.Coins
+	for _, coin := range amt {
+		locked := max64(vesting.AmountOf(coin.Denom)-bva.DelegatedVesting.AmountOf(coin.Denom), 0)
+		x := min64(locked, coin.Amount)
+		delegatedVesting = append(delegatedVesting, sdk.Coin{coin.Denom, x})
+		delegatedFree = append(delegatedFree, sdk.Coin{coin.Denom, coin.Amount - x})
+	}
+	bva.DelegatedVesting = bva.DelegatedVesting.Plus(positive(delegatedVesting))
+	bva.DelegatedFree = bva.DelegatedFree.Plus(positive(delegatedFree))
+}
+
+// Implements VestingAccount.
+func (bva *BaseVestingAccount) TrackUndelegation(amt sdk.Coins) {
+	var undelegatedVesting, undelegatedFree sdk.Coins
+	for _, coin := range amt {
+		x := min64(bva.DelegatedFree.AmountOf(coin.Denom), coin.Amount)
+		y := min64(bva.DelegatedVesting.AmountOf(coin.Denom), coin.Amount-x)
+		undelegatedFree = append(undelegatedFree, sdk.Coin{coin.Denom, x})
+		undelegatedVesting = append(undelegatedVesting, sdk.Coin{coin.Denom, y})
+	}
+	bva.DelegatedFree = bva.DelegatedFree.Minus(positive(undelegatedFree))
+	bva.DelegatedVesting = bva.DelegatedVesting.Minus(positive(undelegatedVesting))
+}
+
+//-----------------------------------------------------------
+// ContinuousVestingAccount
+
+var _ VestingAccount = (*ContinuousVestingAccount)(nil)
+
+// ContinuousVestingAccount vests its coins linearly from StartTime to
+// EndTime.
+type ContinuousVestingAccount struct {
+	BaseVestingAccount
+	StartTime int64 `json:"start_time"`
+}
+
+// NewContinuousVestingAccount returns an account whose coins vest
+// linearly from startTime to endTime, in block heights if byHeight.
+func NewContinuousVestingAccount(base BaseAccount, startTime, endTime int64, byHeight bool) (*ContinuousVestingAccount, error) {
+	if startTime >= endTime {
+		return nil, errors.New("vesting must start before it ends")
+	}
+	return &ContinuousVestingAccount{
+		BaseVestingAccount: BaseVestingAccount{
+			BaseAccount:     base,
+			OriginalVesting: base.Coins,
+			EndTime:         endTime,
+			ByHeight:        byHeight,
+		},
+		StartTime: startTime,
+	}, nil
+}
+
+// Implements VestingAccount.
+func (cva ContinuousVestingAccount) GetVestingCoins(ctx sdk.Context) sdk.Coins {
+	now := cva.now(ctx)
+	switch {
+	case now <= cva.StartTime:
+		return cva.OriginalVesting
+	case now >= cva.EndTime:
+		return nil
+	}
+	// the vested part is (now - start) / (end - start)
+	elapsed := big.NewInt(now - cva.StartTime)
+	duration := big.NewInt(cva.EndTime - cva.StartTime)
+	return mapCoins(cva.OriginalVesting, func(denom string, amount int64) int64 {
+		vested := new(big.Int).Mul(big.NewInt(amount), elapsed)
+		vested.Quo(vested, duration)
+		return amount - vested.Int64()
+	})
+}
+
+// Implements VestingAccount.
+func (cva ContinuousVestingAccount) GetSpendableCoins(ctx sdk.Context) sdk.Coins {
+	return cva.spendableCoins(cva.GetVestingCoins(ctx))
+}
+
+// Implements VestingAccount.
+func (cva *ContinuousVestingAccount) TrackDelegation(ctx sdk.Context, amt sdk.Coins) {
+	cva.trackDelegation(cva.GetVestingCoins(ctx), amt)
+}
+
+//-----------------------------------------------------------
+// DelayedVestingAccount
+
+var _ VestingAccount = (*DelayedVestingAccount)(nil)
+
+// DelayedVestingAccount vests all its coins at EndTime.
+type DelayedVestingAccount struct {
+	BaseVestingAccount
+}
+
+// NewDelayedVestingAccount returns an account whose coins all vest at
+// endTime, a block height if byHeight.
+func NewDelayedVestingAccount(base BaseAccount, endTime int64, byHeight bool) *DelayedVestingAccount {
+	return &DelayedVestingAccount{
+		BaseVestingAccount: BaseVestingAccount{
+			BaseAccount:     base,
+			OriginalVesting: base.Coins,
+			EndTime:         endTime,
+			ByHeight:        byHeight,
+		},
+	}
+}
+
+// Implements VestingAccount.
+func (dva DelayedVestingAccount) GetVestingCoins(ctx sdk.Context) sdk.Coins {
+	if dva.now(ctx) >= dva.EndTime {
+		return nil
+	}
+	return dva.OriginalVesting
+}
+
+// Implements VestingAccount.
+func (dva DelayedVestingAccount) GetSpendableCoins(ctx sdk.Context) sdk.Coins {
+	return dva.spendableCoins(dva.GetVestingCoins(ctx))
+}
+
+// Implements VestingAccount.
+func (dva *DelayedVestingAccount) TrackDelegation(ctx sdk.Context, amt sdk.Coins) {
+	dva.trackDelegation(dva.GetVestingCoins(ctx), amt)
+}
+
+//-----------------------------------------------------------
+// GenesisVesting
+
+// The types of GenesisVesting.
+const (
+	VestingContinuous = "continuous"
+	VestingDelayed    = "delayed"
+)
+
+// GenesisVesting is the schedule of a vesting account in a genesis file.
+// Its coins vest linearly from StartTime to EndTime if Type is
+// VestingContinuous, or all at EndTime if it is VestingDelayed.
+type GenesisVesting struct {
+	Type             string    `json:"type"`
+	OriginalVesting  sdk.Coins `json:"original_vesting,omitempty"` // the coins of the account if empty
+	DelegatedFree    sdk.Coins `json:"delegated_free,omitempty"`
+	DelegatedVesting sdk.Coins `json:"delegated_vesting,omitempty"`
+	StartTime        int64     `json:"start_time,omitempty"`
+	EndTime          int64     `json:"end_time"`
+	ByHeight         bool      `json:"by_height,omitempty"`
+}
+
+// NewGenesisVesting returns the schedule of acc, to export it.
+func NewGenesisVesting(acc VestingAccount) *GenesisVesting {
+	var gv GenesisVesting
+	var bva BaseVestingAccount
+	switch acc := acc.(type) {
+	case *ContinuousVestingAccount:
+		gv.Type, gv.StartTime = VestingContinuous, acc.StartTime
+		bva = acc.BaseVestingAccount
+	case *DelayedVestingAccount:
+		gv.Type = VestingDelayed
+		bva = acc.BaseVestingAccount
+	default:
+		panic("unknown vesting account type")
+	}
+	gv.OriginalVesting = bva.OriginalVesting
+	gv.DelegatedFree = bva.DelegatedFree
+	gv.DelegatedVesting = bva.DelegatedVesting
+	gv.EndTime = bva.EndTime
+	gv.ByHeight = bva.ByHeight
+	return &gv
+}
+
+// ToVestingAccount returns the vesting account of base on the schedule.
+func (gv GenesisVesting) ToVestingAccount(base BaseAccount) (VestingAccount, error) {
+	var acc VestingAccount
+	var bva *BaseVestingAccount
+	switch gv.Type {
+	case VestingContinuous:
+		cva, err := NewContinuousVestingAccount(base, gv.StartTime, gv.EndTime, gv.ByHeight)
+		if err != nil {
+			return nil, err
+		}
+		acc, bva = cva, &cva.BaseVestingAccount
+	case VestingDelayed:
+		dva := NewDelayedVestingAccount(base, gv.EndTime, gv.ByHeight)
+		acc, bva = dva, &dva.BaseVestingAccount
+	default:
+		return nil, fmt.Errorf("unknown vesting type %q", gv.Type)
+	}
+	if len(gv.OriginalVesting) > 0 {
+		if !gv.OriginalVesting.IsValid() || !gv.OriginalVesting.IsPositive() {
+			return nil, fmt.Errorf("invalid original vesting coins %s", gv.OriginalVesting)
+		}
+		bva.OriginalVesting = gv.OriginalVesting
+	}
+	bva.DelegatedFree = gv.DelegatedFree
+	bva.DelegatedVesting = gv.DelegatedVesting
+	return acc, nil
+}
+
+//-----------------------------------------------------------
+// misc.
+
+// mapCoins returns the positive coins f(denom, amount) of coins.
+func mapCoins(coins sdk.Coins, f func(denom string, amount int64) int64) sdk.Coins {
+	var res sdk.Coins
+	for _, coin := range coins {
+		if amount := f(coin.Denom, coin.Amount); amount > 0 {
+			res = append(res, sdk.Coin{coin.Denom, amount})
+		}
+	}
+	return res
+}
+
+// positive returns the positive coins of coins.
+func positive(coins sdk.Coins) sdk.Coins {
+	return mapCoins(coins, func(_ string, amount int64) int64 { return amount })
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
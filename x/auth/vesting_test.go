@@ -0,0 +1,136 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// a context of the block at time and height
+func blockContext(ms sdk.MultiStore, time, height int64) sdk.Context {
+	return sdk.NewContext(ms, abci.Header{Time: time, Height: height}, false, nil)
+}
+
+func TestContinuousVestingAccount(t *testing.T) {
+	ms, _ := setupMultiStore()
+	_, addr := privAndAddr()
+	base := NewBaseAccountWithAddress(addr)
+	base.SetCoins(sdk.Coins{{"atom", 1000}, {"steak", 100}})
+	_, err := NewContinuousVestingAccount(base, 200, 100, false)
+	require.NotNil(t, err)
+	cva, err := NewContinuousVestingAccount(base, 100, 200, false)
+	require.Nil(t, err)
+
+	cases := []struct {
+		time      int64
+		vesting   sdk.Coins
+		spendable sdk.Coins
+	}{
+		{0, sdk.Coins{{"atom", 1000}, {"steak", 100}}, nil},
+		{100, sdk.Coins{{"atom", 1000}, {"steak", 100}}, nil},
+		{125, sdk.Coins{{"atom", 750}, {"steak", 75}}, sdk.Coins{{"atom", 250}, {"steak", 25}}},
+		{199, sdk.Coins{{"atom", 10}, {"steak", 1}}, sdk.Coins{{"atom", 990}, {"steak", 99}}},
+		{200, nil, sdk.Coins{{"atom", 1000}, {"steak", 100}}},
+		{300, nil, sdk.Coins{{"atom", 1000}, {"steak", 100}}},
+	}
+	for _, tc := range cases {
+		ctx := blockContext(ms, tc.time, 1)
+		assert.Equal(t, tc.vesting, cva.GetVestingCoins(ctx), "time %d", tc.time)
+		assert.Equal(t, tc.spendable, cva.GetSpendableCoins(ctx), "time %d", tc.time)
+		assert.Equal(t, tc.spendable, SpendableCoins(ctx, cva), "time %d", tc.time)
+	}
+
+	// coins received are spendable
+	ctx := blockContext(ms, 150, 1)
+	cva.SetCoins(sdk.Coins{{"atom", 1100}, {"steak", 100}})
+	assert.Equal(t, sdk.Coins{{"atom", 600}, {"steak", 50}}, cva.GetSpendableCoins(ctx))
+}
+
+func TestVestingAccountDelegation(t *testing.T) {
+	ms, _ := setupMultiStore()
+	_, addr := privAndAddr()
+	base := NewBaseAccountWithAddress(addr)
+	base.SetCoins(sdk.Coins{{"atom", 1000}})
+	cva, err := NewContinuousVestingAccount(base, 100, 200, false)
+	require.Nil(t, err)
+	ctx := blockContext(ms, 150, 1)
+
+	// delegate the 500 locked coins, and 200 free coins
+	cva.TrackDelegation(ctx, sdk.Coins{{"atom", 700}})
+	cva.SetCoins(sdk.Coins{{"atom", 300}})
+	assert.Equal(t, sdk.Coins{{"atom", 500}}, cva.DelegatedVesting)
+	assert.Equal(t, sdk.Coins{{"atom", 200}}, cva.DelegatedFree)
+	assert.Equal(t, sdk.Coins{{"atom", 300}}, cva.GetSpendableCoins(ctx))
+
+	// undelegate the free coins first
+	cva.TrackUndelegation(sdk.Coins{{"atom", 300}})
+	cva.SetCoins(sdk.Coins{{"atom", 600}})
+	assert.Equal(t, sdk.Coins{{"atom", 400}}, cva.DelegatedVesting)
+	assert.Empty(t, cva.DelegatedFree)
+	assert.Equal(t, sdk.Coins{{"atom", 500}}, cva.GetSpendableCoins(ctx))
+
+	// once vested, delegations are free
+	ctx = blockContext(ms, 200, 1)
+	cva.TrackDelegation(ctx, sdk.Coins{{"atom", 100}})
+	assert.Equal(t, sdk.Coins{{"atom", 400}}, cva.DelegatedVesting)
+	assert.Equal(t, sdk.Coins{{"atom", 100}}, cva.DelegatedFree)
+
+	// undelegating more than delegated, eg. with rewards, clears both
+	cva.TrackUndelegation(sdk.Coins{{"atom", 1000}})
+	assert.Empty(t, cva.DelegatedVesting)
+	assert.Empty(t, cva.DelegatedFree)
+}
+
+func TestDelayedVestingAccount(t *testing.T) {
+	ms, _ := setupMultiStore()
+	_, addr := privAndAddr()
+	base := NewBaseAccountWithAddress(addr)
+	base.SetCoins(sdk.Coins{{"atom", 1000}})
+	dva := NewDelayedVestingAccount(base, 10, true)
+
+	// all the coins vest at the end height, whatever the time
+	ctx := blockContext(ms, 1000, 9)
+	assert.Equal(t, sdk.Coins{{"atom", 1000}}, dva.GetVestingCoins(ctx))
+	assert.Nil(t, dva.GetSpendableCoins(ctx))
+	ctx = blockContext(ms, 0, 10)
+	assert.Nil(t, dva.GetVestingCoins(ctx))
+	assert.Equal(t, sdk.Coins{{"atom", 1000}}, dva.GetSpendableCoins(ctx))
+}
+
+func TestGenesisVesting(t *testing.T) {
+	_, addr := privAndAddr()
+	base := NewBaseAccountWithAddress(addr)
+	base.SetCoins(sdk.Coins{{"atom", 1000}})
+
+	cva, err := NewContinuousVestingAccount(base, 100, 200, false)
+	require.Nil(t, err)
+	cva.DelegatedVesting = sdk.Coins{{"atom", 10}}
+	dva := NewDelayedVestingAccount(base, 10, true)
+	dva.DelegatedFree = sdk.Coins{{"atom", 20}}
+	for _, acc := range []VestingAccount{cva, dva} {
+		gv := NewGenesisVesting(acc)
+		acc2, err := gv.ToVestingAccount(base)
+		require.Nil(t, err)
+		assert.Equal(t, acc, acc2)
+	}
+
+	// the original vesting coins are the coins of the account by default
+	gv := GenesisVesting{Type: VestingDelayed, EndTime: 10}
+	acc, err := gv.ToVestingAccount(base)
+	require.Nil(t, err)
+	assert.Equal(t, base.Coins, acc.(*DelayedVestingAccount).OriginalVesting)
+
+	bad := []GenesisVesting{
+		{Type: "other", EndTime: 10},
+		{Type: VestingContinuous, StartTime: 10, EndTime: 10},
+		{Type: VestingDelayed, EndTime: 10, OriginalVesting: sdk.Coins{{"atom", -1}}},
+	}
+	for _, gv := range bad {
+		_, err := gv.ToVestingAccount(base)
+		assert.NotNil(t, err, "%+v", gv)
+	}
+}
@@ -0,0 +1,31 @@
+package authz
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// authz errors reserve 600 - 699.
+	CodeNoAuthorization sdk.CodeType = 601
+	CodeExpired         sdk.CodeType = 602
+	CodeInvalidExec     sdk.CodeType = 603
+)
+
+func ErrNoAuthorization(msgType string) sdk.Error {
+	return newError(CodeNoAuthorization, "no authorization for msg type "+msgType)
+}
+
+func ErrExpired(msgType string) sdk.Error {
+	return newError(CodeExpired, "authorization expired for msg type "+msgType)
+}
+
+func ErrInvalidExec(msg string) sdk.Error {
+	return newError(CodeInvalidExec, msg)
+}
+
+// -----------------------------
+// Helpers
+
+func newError(code sdk.CodeType, msg string) sdk.Error {
+	return sdk.NewError(code, msg)
+}
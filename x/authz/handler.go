@@ -0,0 +1,56 @@
+package authz
+
+import (
+	"reflect"
+
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// NewHandler returns a handler for "authz" type messages, which routes
+// the Msgs of MsgExecs with router.
+func NewHandler(k Keeper, router baseapp.Router) sdk.Handler {
+	return func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		switch msg := msg.(type) {
+		case MsgGrant:
+			k.Grant(ctx, msg.Granter, msg.Grantee, msg.MsgType, Authorization{msg.Expiration})
+			return sdk.Result{}
+		case MsgRevoke:
+			err := k.Revoke(ctx, msg.Granter, msg.Grantee, msg.MsgType)
+			if err != nil {
+				return err.Result()
+			}
+			return sdk.Result{}
+		case MsgExec:
+			return handleMsgExec(ctx, k, router, msg)
+		default:
+			errMsg := "Unrecognized authz Msg type: " + reflect.TypeOf(msg).Name()
+			return sdk.ErrUnknownRequest(errMsg).Result()
+		}
+	}
+}
+
+// Run the Msgs of msg in order, once the grantee is authorized for all of
+// them. They run on the cache of the tx, so they fail together with it.
+func handleMsgExec(ctx sdk.Context, k Keeper, router baseapp.Router, msg MsgExec) sdk.Result {
+	handlers := make([]sdk.Handler, len(msg.Msgs))
+	for i, m := range msg.Msgs {
+		if err := k.Authorize(ctx, msg.Grantee, m); err != nil {
+			return err.Result()
+		}
+		handlers[i] = router.Route(m.Type())
+		if handlers[i] == nil {
+			return sdk.ErrUnknownRequest("Unrecognized Msg type: " + m.Type()).Result()
+		}
+	}
+
+	var data []byte
+	for i, m := range msg.Msgs {
+		res := handlers[i](ctx, m)
+		if !res.IsOK() {
+			return res
+		}
+		data = append(data, res.Data...)
+	}
+	return sdk.Result{Data: data}
+}
@@ -0,0 +1,145 @@
+package authz
+
+import (
+	"bytes"
+	"encoding/json"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+// Keeper keeps the authorizations of grantees from granters, per msg type.
+type Keeper struct {
+	key sdk.StoreKey
+	cdc *wire.Codec
+}
+
+// NewKeeper returns a Keeper which keeps the authorizations in the store of
+// key, which it must not share, as ExportGenesis exports the whole store.
+func NewKeeper(cdc *wire.Codec, key sdk.StoreKey) Keeper {
+	return Keeper{
+		key: key,
+		cdc: cdc,
+	}
+}
+
+// the store key of the authorization of grantee from granter for msgType:
+// the length prefixed granter and grantee, and msgType
+func authorizationKey(granter, grantee sdk.Address, msgType string) []byte {
+	key := append([]byte{byte(len(granter))}, granter...)
+	key = append(append(key, byte(len(grantee))), grantee...)
+	return append(key, msgType...)
+}
+
+// parse the granter, grantee and msg type of a store key
+func parseAuthorizationKey(key []byte) (granter, grantee sdk.Address, msgType string) {
+	n := int(key[0])
+	granter, key = key[1:1+n], key[1+n:]
+	n = int(key[0])
+	grantee, key = key[1:1+n], key[1+n:]
+	return granter, grantee, string(key)
+}
+
+// GetAuthorization returns the authorization of grantee from granter for msgType.
+func (k Keeper) GetAuthorization(ctx sdk.Context, granter, grantee sdk.Address, msgType string) (authorization Authorization, found bool) {
+	store := ctx.KVStore(k.key)
+	bz := store.Get(authorizationKey(granter, grantee, msgType))
+	if bz == nil {
+		return authorization, false
+	}
+	err := k.cdc.UnmarshalBinary(bz, &authorization)
+	if err != nil {
+		panic(err)
+	}
+	return authorization, true
+}
+
+// Grant sets the authorization of grantee from granter for msgType,
+// replacing any previous one.
+func (k Keeper) Grant(ctx sdk.Context, granter, grantee sdk.Address, msgType string, authorization Authorization) {
+	store := ctx.KVStore(k.key)
+	bz, err := k.cdc.MarshalBinary(authorization)
+	if err != nil {
+		panic(err)
+	}
+	store.Set(authorizationKey(granter, grantee, msgType), bz)
+}
+
+// Revoke deletes the authorization of grantee from granter for msgType.
+func (k Keeper) Revoke(ctx sdk.Context, granter, grantee sdk.Address, msgType string) sdk.Error {
+	if _, found := k.GetAuthorization(ctx, granter, grantee, msgType); !found {
+		return ErrNoAuthorization(msgType)
+	}
+	store := ctx.KVStore(k.key)
+	store.Delete(authorizationKey(granter, grantee, msgType))
+	return nil
+}
+
+// Authorize checks that grantee may sign msg for all of its signers:
+// each signer is the grantee, or authorized it for the type of msg.
+func (k Keeper) Authorize(ctx sdk.Context, grantee sdk.Address, msg sdk.Msg) sdk.Error {
+	msgType := msg.Type()
+	for _, signer := range msg.GetSigners() {
+		if bytes.Equal(signer, grantee) {
+			continue
+		}
+		authorization, found := k.GetAuthorization(ctx, signer, grantee, msgType)
+		if !found {
+			return ErrNoAuthorization(msgType)
+		}
+		if authorization.expired(ctx) {
+			return ErrExpired(msgType)
+		}
+	}
+	return nil
+}
+
+// InitGenesis stores the grants exported by ExportGenesis.
+func (k Keeper) InitGenesis(ctx sdk.Context, data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var grants []Grant
+	if err := json.Unmarshal(data, &grants); err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		msg := NewMsgGrant(grant.Granter, grant.Grantee, grant.MsgType, grant.Authorization.Expiration)
+		if err := msg.ValidateBasic(); err != nil {
+			return err
+		}
+		k.Grant(ctx, grant.Granter, grant.Grantee, grant.MsgType, grant.Authorization)
+	}
+	return nil
+}
+
+// ExportGenesis returns all grants, ordered by granter, grantee and msg
+// type, or nothing if there are none.
+func (k Keeper) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	store := ctx.KVStore(k.key)
+	iter := store.Iterator(nil, nil)
+	defer iter.Close()
+	var grants []Grant
+	for ; iter.Valid(); iter.Next() {
+		var authorization Authorization
+		err := k.cdc.UnmarshalBinary(iter.Value(), &authorization)
+		if err != nil {
+			panic(err)
+		}
+		granter, grantee, msgType := parseAuthorizationKey(iter.Key())
+		grants = append(grants, Grant{
+			Granter:       granter,
+			Grantee:       grantee,
+			MsgType:       msgType,
+			Authorization: authorization,
+		})
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	bz, err := json.Marshal(grants)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
@@ -0,0 +1,126 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/cosmos/cosmos-sdk/baseapp"
+	"github.com/cosmos/cosmos-sdk/mock"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+var (
+	granter  = sdk.Address([]byte("granter"))
+	granter2 = sdk.Address([]byte("granter2"))
+	grantee  = sdk.Address([]byte("grantee"))
+)
+
+func setupKeeper() (sdk.Context, Keeper) {
+	key := sdk.NewKVStoreKey("authz")
+	ctx := sdk.NewContext(mock.SetupMultiStore(key), abci.Header{Time: 100}, false, nil)
+	return ctx, NewKeeper(wire.NewCodec(), key)
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx, k := setupKeeper()
+	msg := sdk.NewTestMsg(granter)
+
+	// the grantee may sign its own msgs only
+	assert.Nil(t, k.Authorize(ctx, grantee, sdk.NewTestMsg(grantee)))
+	err := k.Authorize(ctx, grantee, msg)
+	require.NotNil(t, err)
+	assert.Equal(t, CodeNoAuthorization, err.ABCICode())
+
+	// until the granter authorizes it for the msg type
+	k.Grant(ctx, granter, grantee, msg.Type(), Authorization{})
+	assert.Nil(t, k.Authorize(ctx, grantee, msg))
+	assert.NotNil(t, k.Authorize(ctx, granter, sdk.NewTestMsg(grantee)))
+	k.Grant(ctx, granter, grantee, "bank/send", Authorization{})
+	err = k.Authorize(ctx, grantee, sdk.NewTestMsg(granter, granter2))
+	require.NotNil(t, err)
+	assert.Equal(t, CodeNoAuthorization, err.ABCICode())
+
+	// and all the signers
+	k.Grant(ctx, granter2, grantee, msg.Type(), Authorization{Expiration: 200})
+	assert.Nil(t, k.Authorize(ctx, grantee, sdk.NewTestMsg(granter, grantee, granter2)))
+
+	// until the authorization expires
+	ctx = ctx.WithBlockHeader(abci.Header{Time: 200})
+	err = k.Authorize(ctx, grantee, sdk.NewTestMsg(granter2))
+	require.NotNil(t, err)
+	assert.Equal(t, CodeExpired, err.ABCICode())
+
+	// or is revoked
+	require.Nil(t, k.Revoke(ctx, granter, grantee, msg.Type()))
+	assert.NotNil(t, k.Revoke(ctx, granter, grantee, msg.Type()))
+	assert.NotNil(t, k.Authorize(ctx, grantee, msg))
+	_, found := k.GetAuthorization(ctx, granter, grantee, "bank/send")
+	assert.True(t, found)
+}
+
+func TestHandler(t *testing.T) {
+	ctx, k := setupKeeper()
+	var handled []sdk.Msg
+	router := baseapp.NewRouter()
+	router.AddRoute("TestMsg", func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		handled = append(handled, msg)
+		return sdk.Result{Data: []byte{byte(len(handled))}}
+	})
+	handler := NewHandler(k, router)
+	msg, msg2 := sdk.NewTestMsg(granter), sdk.NewTestMsg(granter2)
+
+	// the grantee needs the authorizations of all the msgs
+	res := handler(ctx, NewMsgGrant(granter, grantee, msg.Type(), 0))
+	require.True(t, res.IsOK(), res.Log)
+	res = handler(ctx, NewMsgExec(grantee, []sdk.Msg{msg, msg2}))
+	assert.Equal(t, CodeNoAuthorization, res.Code)
+	assert.Empty(t, handled)
+
+	// which are routed in order
+	res = handler(ctx, NewMsgExec(grantee, []sdk.Msg{msg}))
+	require.True(t, res.IsOK(), res.Log)
+	res = handler(ctx, NewMsgExec(grantee, []sdk.Msg{msg, msg}))
+	require.True(t, res.IsOK(), res.Log)
+	assert.Equal(t, []sdk.Msg{msg, msg, msg}, handled)
+	assert.Equal(t, []byte{2, 3}, res.Data)
+
+	// but not without a route
+	revoke := NewMsgRevoke(granter, granter2, "bank/send")
+	res = handler(ctx, NewMsgGrant(granter, grantee, revoke.Type(), 0))
+	require.True(t, res.IsOK(), res.Log)
+	res = handler(ctx, NewMsgExec(grantee, []sdk.Msg{revoke}))
+	assert.Equal(t, sdk.CodeUnknownRequest, res.Code)
+
+	res = handler(ctx, NewMsgRevoke(granter, grantee, msg.Type()))
+	require.True(t, res.IsOK(), res.Log)
+	res = handler(ctx, NewMsgRevoke(granter, grantee, msg.Type()))
+	assert.Equal(t, CodeNoAuthorization, res.Code)
+	res = handler(ctx, NewMsgExec(grantee, []sdk.Msg{msg}))
+	assert.Equal(t, CodeNoAuthorization, res.Code)
+	assert.Len(t, handled, 3)
+}
+
+func TestGenesis(t *testing.T) {
+	ctx, k := setupKeeper()
+	assert.Nil(t, k.ExportGenesis(ctx))
+
+	k.Grant(ctx, granter, grantee, "bank/send", Authorization{})
+	k.Grant(ctx, granter2, grantee, "stake/delegate", Authorization{Expiration: 200})
+	exported := k.ExportGenesis(ctx)
+
+	ctx2, k2 := setupKeeper()
+	require.Nil(t, k2.InitGenesis(ctx2, exported))
+	assert.Equal(t, string(exported), string(k2.ExportGenesis(ctx2)))
+	authorization, found := k2.GetAuthorization(ctx2, granter2, grantee, "stake/delegate")
+	require.True(t, found)
+	assert.Equal(t, int64(200), authorization.Expiration)
+	_, found = k2.GetAuthorization(ctx2, granter, grantee, "bank/send")
+	assert.True(t, found)
+
+	bad := []byte(`[{"granter":"00","grantee":"01","msg_type":""}]`)
+	assert.NotNil(t, k2.InitGenesis(ctx2, bad))
+}
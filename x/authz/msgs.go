@@ -0,0 +1,171 @@
+package authz
+
+import (
+	"bytes"
+	"encoding/json"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// name to identify transaction types
+const MsgType = "authz"
+
+//Verify interface at compile time
+var _, _, _ sdk.Msg = MsgGrant{}, MsgRevoke{}, MsgExec{}
+
+//______________________________________________________________________
+
+// MsgGrant lets the grantee sign the Msgs of MsgType for the granter, until
+// the block time reaches the Expiration, if any, replacing any previous
+// authorization
+type MsgGrant struct {
+	Granter    sdk.Address `json:"granter"`
+	Grantee    sdk.Address `json:"grantee"`
+	MsgType    string      `json:"msg_type"`
+	Expiration int64       `json:"expiration"`
+}
+
+func NewMsgGrant(granter, grantee sdk.Address, msgType string, expiration int64) MsgGrant {
+	return MsgGrant{
+		Granter:    granter,
+		Grantee:    grantee,
+		MsgType:    msgType,
+		Expiration: expiration,
+	}
+}
+
+//nolint
+func (msg MsgGrant) Type() string                            { return MsgType + "/grant" }
+func (msg MsgGrant) Get(key interface{}) (value interface{}) { return nil }
+func (msg MsgGrant) GetSigners() []sdk.Address               { return []sdk.Address{msg.Granter} }
+
+// get the bytes for the message signer to sign on
+func (msg MsgGrant) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// quick validity check
+func (msg MsgGrant) ValidateBasic() sdk.Error {
+	if err := validateGrant(msg.Granter, msg.Grantee, msg.MsgType); err != nil {
+		return err
+	}
+	if msg.Expiration < 0 {
+		return sdk.ErrUnknownRequest("negative expiration")
+	}
+	return nil
+}
+
+//______________________________________________________________________
+
+// MsgRevoke deletes the authorization of the grantee from the granter
+// for MsgType
+type MsgRevoke struct {
+	Granter sdk.Address `json:"granter"`
+	Grantee sdk.Address `json:"grantee"`
+	MsgType string      `json:"msg_type"`
+}
+
+func NewMsgRevoke(granter, grantee sdk.Address, msgType string) MsgRevoke {
+	return MsgRevoke{
+		Granter: granter,
+		Grantee: grantee,
+		MsgType: msgType,
+	}
+}
+
+//nolint
+func (msg MsgRevoke) Type() string                            { return MsgType + "/revoke" }
+func (msg MsgRevoke) Get(key interface{}) (value interface{}) { return nil }
+func (msg MsgRevoke) GetSigners() []sdk.Address               { return []sdk.Address{msg.Granter} }
+
+// get the bytes for the message signer to sign on
+func (msg MsgRevoke) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// quick validity check
+func (msg MsgRevoke) ValidateBasic() sdk.Error {
+	return validateGrant(msg.Granter, msg.Grantee, msg.MsgType)
+}
+
+func validateGrant(granter, grantee sdk.Address, msgType string) sdk.Error {
+	if len(granter) == 0 {
+		return sdk.ErrInvalidAddress("missing granter address")
+	}
+	if len(grantee) == 0 {
+		return sdk.ErrInvalidAddress("missing grantee address")
+	}
+	if bytes.Equal(granter, grantee) {
+		return sdk.ErrInvalidAddress("granter is the grantee")
+	}
+	if msgType == "" {
+		return sdk.ErrUnknownRequest("missing msg type")
+	}
+	return nil
+}
+
+//______________________________________________________________________
+
+// MsgExec runs the Msgs as if their signers had signed them: the grantee
+// signs for them, and must be authorized by each of them for the type of
+// each Msg
+type MsgExec struct {
+	Grantee sdk.Address `json:"grantee"`
+	Msgs    []sdk.Msg   `json:"msgs"`
+}
+
+func NewMsgExec(grantee sdk.Address, msgs []sdk.Msg) MsgExec {
+	return MsgExec{
+		Grantee: grantee,
+		Msgs:    msgs,
+	}
+}
+
+//nolint
+func (msg MsgExec) Type() string                            { return MsgType + "/exec" }
+func (msg MsgExec) Get(key interface{}) (value interface{}) { return nil }
+func (msg MsgExec) GetSigners() []sdk.Address               { return []sdk.Address{msg.Grantee} }
+
+// get the bytes for the message signer to sign on,
+// which include the sign bytes of the Msgs
+func (msg MsgExec) GetSignBytes() []byte {
+	msgsBytes := make([][]byte, len(msg.Msgs))
+	for i, m := range msg.Msgs {
+		msgsBytes[i] = m.GetSignBytes()
+	}
+	b, err := json.Marshal(struct {
+		Grantee   sdk.Address `json:"grantee"`
+		MsgsBytes [][]byte    `json:"msgs_bytes"`
+	}{msg.Grantee, msgsBytes})
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// quick validity check, of the Msgs too, which can't be MsgExecs
+func (msg MsgExec) ValidateBasic() sdk.Error {
+	if len(msg.Grantee) == 0 {
+		return sdk.ErrInvalidAddress("missing grantee address")
+	}
+	if len(msg.Msgs) == 0 {
+		return ErrInvalidExec("no msgs to exec")
+	}
+	for _, m := range msg.Msgs {
+		if _, ok := m.(MsgExec); ok {
+			return ErrInvalidExec("nested exec")
+		}
+		if err := m.ValidateBasic(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package authz
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestMsgGrantValidation(t *testing.T) {
+	cases := []struct {
+		valid bool
+		msg   MsgGrant
+	}{
+		{true, NewMsgGrant(granter, grantee, "bank/send", 0)},
+		{true, NewMsgGrant(granter, grantee, "stake/delegate", 100)},
+		{false, NewMsgGrant(nil, grantee, "bank/send", 0)},
+		{false, NewMsgGrant(granter, nil, "bank/send", 0)},
+		{false, NewMsgGrant(granter, granter, "bank/send", 0)},
+		{false, NewMsgGrant(granter, grantee, "", 0)},
+		{false, NewMsgGrant(granter, grantee, "bank/send", -1)},
+	}
+	for i, tc := range cases {
+		err := tc.msg.ValidateBasic()
+		assert.Equal(t, tc.valid, err == nil, "case %d: %v", i, err)
+	}
+	assert.Equal(t, []sdk.Address{granter}, cases[0].msg.GetSigners())
+}
+
+func TestMsgRevokeValidation(t *testing.T) {
+	assert.Nil(t, NewMsgRevoke(granter, grantee, "bank/send").ValidateBasic())
+	assert.NotNil(t, NewMsgRevoke(granter, nil, "bank/send").ValidateBasic())
+	assert.NotNil(t, NewMsgRevoke(nil, grantee, "bank/send").ValidateBasic())
+	assert.NotNil(t, NewMsgRevoke(granter, grantee, "").ValidateBasic())
+	assert.Equal(t, []sdk.Address{granter}, NewMsgRevoke(granter, grantee, "bank/send").GetSigners())
+}
+
+func TestMsgExecValidation(t *testing.T) {
+	msg := sdk.NewTestMsg(granter)
+	exec := NewMsgExec(grantee, []sdk.Msg{msg})
+	cases := []struct {
+		valid bool
+		msg   MsgExec
+	}{
+		{true, exec},
+		{true, NewMsgExec(grantee, []sdk.Msg{msg, NewMsgRevoke(granter, grantee, "bank/send")})},
+		{false, NewMsgExec(nil, []sdk.Msg{msg})},
+		{false, NewMsgExec(grantee, nil)},
+		{false, NewMsgExec(grantee, []sdk.Msg{NewMsgRevoke(granter, nil, "bank/send")})},
+		{false, NewMsgExec(grantee, []sdk.Msg{exec})},
+	}
+	for i, tc := range cases {
+		err := tc.msg.ValidateBasic()
+		assert.Equal(t, tc.valid, err == nil, "case %d: %v", i, err)
+	}
+	assert.Equal(t, []sdk.Address{grantee}, exec.GetSigners())
+
+	// the grantee signs the msgs too
+	exec2 := NewMsgExec(grantee, []sdk.Msg{sdk.NewTestMsg(granter2)})
+	assert.NotEqual(t, string(exec.GetSignBytes()), string(exec2.GetSignBytes()))
+}
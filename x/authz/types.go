@@ -0,0 +1,26 @@
+package authz
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Authorization lets a grantee sign the Msgs of a type for a granter,
+// until the block time reaches the Expiration, if any. Msg types must
+// identify a single Msg, eg. "bank/send", not a route such as "bank".
+type Authorization struct {
+	Expiration int64 `json:"expiration,omitempty"` // unix seconds
+}
+
+// expired returns whether the authorization expired at the block of ctx.
+func (a Authorization) expired(ctx sdk.Context) bool {
+	return a.Expiration > 0 && ctx.BlockHeader().Time >= a.Expiration
+}
+
+// Grant is the authorization of a grantee from a granter for a msg type,
+// as carried over genesis
+type Grant struct {
+	Granter       sdk.Address   `json:"granter"`
+	Grantee       sdk.Address   `json:"grantee"`
+	MsgType       string        `json:"msg_type"`
+	Authorization Authorization `json:"authorization"`
+}
@@ -4,11 +4,13 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/cosmos/cosmos-sdk/client"
 	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/client/core"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire"
 	"github.com/cosmos/cosmos-sdk/x/bank"
@@ -16,8 +18,10 @@ import (
 )
 
 const (
-	flagTo     = "to"
-	flagAmount = "amount"
+	flagTo           = "to"
+	flagAmount       = "amount"
+	flagFromAddress  = "from-address"
+	flagGenerateOnly = "generate-only"
 )
 
 // SendTxCommand will create a send tx and sign it with the given key
@@ -30,6 +34,8 @@ func SendTxCmd(Cdc *wire.Codec) *cobra.Command {
 	}
 	cmd.Flags().String(flagTo, "", "Address to send coins")
 	cmd.Flags().String(flagAmount, "", "Amount of coins to send")
+	cmd.Flags().String(flagFromAddress, "", "Address to send coins from, instead of the address of the key, with --generate-only")
+	cmd.Flags().Bool(flagGenerateOnly, false, "Print the unsigned tx, for its signers to sign offline, instead of signing and broadcasting it")
 	return cmd
 }
 
@@ -41,7 +47,8 @@ func (c Commander) sendTxCmd(cmd *cobra.Command, args []string) error {
 	ctx := context.NewCoreContextFromViper()
 
 	// get the from address
-	from, err := ctx.GetFromAddress()
+	generateOnly := viper.GetBool(flagGenerateOnly)
+	from, err := getFromAddress(ctx, generateOnly)
 	if err != nil {
 		return err
 	}
@@ -64,6 +71,16 @@ func (c Commander) sendTxCmd(cmd *cobra.Command, args []string) error {
 	// build message
 	msg := BuildMsg(from, to, coins)
 
+	// print the unsigned tx to sign offline
+	if generateOnly {
+		txBytes, err := ctx.BuildUnsigned(msg, c.Cdc)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%X\n", txBytes)
+		return nil
+	}
+
 	// build and sign the transaction, then broadcast to Tendermint
 	res, err := ctx.SignBuildBroadcast(ctx.FromAddressName, msg, c.Cdc)
 	if err != nil {
@@ -74,6 +91,23 @@ func (c Commander) sendTxCmd(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// getFromAddress returns the address of the --from-address flag, which
+// can only be used with --generate-only, or else the address of the key.
+func getFromAddress(ctx core.CoreContext, generateOnly bool) (sdk.Address, error) {
+	fromAddress := viper.GetString(flagFromAddress)
+	if fromAddress == "" {
+		return ctx.GetFromAddress()
+	}
+	if !generateOnly {
+		return nil, errors.Errorf("--%s requires --%s", flagFromAddress, flagGenerateOnly)
+	}
+	bz, err := hex.DecodeString(fromAddress)
+	if err != nil {
+		return nil, err
+	}
+	return sdk.Address(bz), nil
+}
+
 func BuildMsg(from sdk.Address, to sdk.Address, coins sdk.Coins) sdk.Msg {
 	input := bank.NewInput(from, coins)
 	output := bank.NewOutput(to, coins)
@@ -95,10 +129,10 @@ func (c Commander) SignMessage(msg sdk.Msg, kb cryptokeys.Keybase, accountName s
 	}}
 
 	// TODO: fees
-	var fee sdk.StdFee
+	fee := sdk.NewStdFee(viper.GetInt64(client.FlagGas))
 
 	// marshal bytes
-	tx := sdk.NewStdTx(msg, fee, sigs)
+	tx := sdk.NewStdTx([]sdk.Msg{msg}, fee, sigs)
 
 	txBytes, err := c.Cdc.MarshalBinary(tx)
 	if err != nil {
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/cosmos/cosmos-sdk/client/context"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/bank"
+)
+
+// SupplyCmd queries the supply of coins
+func SupplyCmd(cdc *wire.Codec) *cobra.Command {
+	return &cobra.Command{
+		Use:   "supply [denom]",
+		Short: "Query the supply of coins, or of a denom",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errors.New("You can provide at most one denom")
+			}
+			var denom []byte
+			if len(args) == 1 {
+				denom = []byte(args[0])
+			}
+
+			ctx := context.NewCoreContextFromViper()
+			res, err := ctx.QueryCustom("bank/"+bank.QuerySupply, denom)
+			if err != nil {
+				return err
+			}
+
+			var supply sdk.Coins
+			err = cdc.UnmarshalJSON(res, &supply)
+			if err != nil {
+				return err
+			}
+			fmt.Println(supply)
+			return nil
+		},
+	}
+}
@@ -4,18 +4,31 @@ import (
 	"fmt"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
 )
 
 const moduleName = "bank"
 
-// CoinKeeper manages transfers between accounts
+// CoinKeeper manages transfers between accounts, and tracks the supply of
+// coins: the coins which it adds to accounts are minted, and the coins which
+// it subtracts from them are burned.
 type CoinKeeper struct {
 	am sdk.AccountMapper
+
+	// The (unexposed) key of the store of the supply.
+	key sdk.StoreKey
+	cdc *wire.Codec
 }
 
-// NewCoinKeeper returns a new CoinKeeper
-func NewCoinKeeper(am sdk.AccountMapper) CoinKeeper {
-	return CoinKeeper{am: am}
+// NewCoinKeeper returns a new CoinKeeper, which keeps the supply in the
+// store of key. The store may be shared, eg. with the AccountMapper.
+func NewCoinKeeper(am sdk.AccountMapper, key sdk.StoreKey) CoinKeeper {
+	return CoinKeeper{
+		am:  am,
+		key: key,
+		cdc: wire.NewCodec(),
+	}
 }
 
 // GetCoins returns the coins at the addr.
@@ -24,26 +37,48 @@ func (ck CoinKeeper) GetCoins(ctx sdk.Context, addr sdk.Address, amt sdk.Coins)
 	return acc.GetCoins()
 }
 
-// SubtractCoins subtracts amt from the coins at the addr.
+// SubtractCoins subtracts amt from the coins at the addr, and burns it.
+// It can't subtract the locked coins of vesting accounts.
 func (ck CoinKeeper) SubtractCoins(ctx sdk.Context, addr sdk.Address, amt sdk.Coins) (sdk.Coins, sdk.Error) {
+	newCoins, err := ck.subtractCoins(ctx, addr, amt)
+	if err != nil {
+		return amt, err
+	}
+	ck.burn(ctx, amt)
+	return newCoins, nil
+}
+
+func (ck CoinKeeper) subtractCoins(ctx sdk.Context, addr sdk.Address, amt sdk.Coins) (sdk.Coins, sdk.Error) {
 	acc := ck.am.GetAccount(ctx, addr)
 	if acc == nil {
 		return amt, sdk.ErrUnknownAddress(addr.String())
 	}
 
-	coins := acc.GetCoins()
-	newCoins := coins.Minus(amt)
-	if !newCoins.IsNotNegative() {
-		return amt, sdk.ErrInsufficientCoins(fmt.Sprintf("%s < %s", coins, amt))
+	spendable := auth.SpendableCoins(ctx, acc)
+	if !spendable.Minus(amt).IsNotNegative() {
+		return amt, sdk.ErrInsufficientCoins(fmt.Sprintf("%s < %s", spendable, amt))
 	}
 
+	newCoins := acc.GetCoins().Minus(amt)
 	acc.SetCoins(newCoins)
 	ck.am.SetAccount(ctx, acc)
 	return newCoins, nil
 }
 
-// AddCoins adds amt to the coins at the addr.
+// AddCoins mints amt, and adds it to the coins at the addr.
 func (ck CoinKeeper) AddCoins(ctx sdk.Context, addr sdk.Address, amt sdk.Coins) (sdk.Coins, sdk.Error) {
+	ck.mint(ctx, amt)
+	return ck.addCoins(ctx, addr, amt)
+}
+
+// PayFees adds amt, paid out of the fee pool, to the coins at the addr.
+// The fees are in the supply already: unlike AddCoins, it doesn't mint amt,
+// and the caller takes it out of the fee pool.
+func (ck CoinKeeper) PayFees(ctx sdk.Context, addr sdk.Address, amt sdk.Coins) (sdk.Coins, sdk.Error) {
+	return ck.addCoins(ctx, addr, amt)
+}
+
+func (ck CoinKeeper) addCoins(ctx sdk.Context, addr sdk.Address, amt sdk.Coins) (sdk.Coins, sdk.Error) {
 	acc := ck.am.GetAccount(ctx, addr)
 	if acc == nil {
 		acc = ck.am.NewAccountWithAddress(ctx, addr)
@@ -57,14 +92,57 @@ func (ck CoinKeeper) AddCoins(ctx sdk.Context, addr sdk.Address, amt sdk.Coins)
 	return newCoins, nil
 }
 
+// DelegateCoins subtracts amt from the coins at the addr to delegate it, and
+// moves it to the delegated coins, which stay in the supply. Unlike
+// SubtractCoins, it can subtract the locked coins of vesting accounts,
+// which record the delegation.
+func (ck CoinKeeper) DelegateCoins(ctx sdk.Context, addr sdk.Address, amt sdk.Coins) (sdk.Coins, sdk.Error) {
+	acc := ck.am.GetAccount(ctx, addr)
+	if acc == nil {
+		return amt, sdk.ErrUnknownAddress(addr.String())
+	}
+
+	coins := acc.GetCoins()
+	newCoins := coins.Minus(amt)
+	if !newCoins.IsNotNegative() {
+		return amt, sdk.ErrInsufficientCoins(fmt.Sprintf("%s < %s", coins, amt))
+	}
+
+	if vacc, ok := acc.(auth.VestingAccount); ok {
+		vacc.TrackDelegation(ctx, amt)
+	}
+	acc.SetCoins(newCoins)
+	ck.am.SetAccount(ctx, acc)
+	ck.delegate(ctx, amt)
+	return newCoins, nil
+}
+
+// UndelegateCoins moves amt out of the delegated coins, minting any of it
+// over them, and adds it to the coins at the addr when it is undelegated. Vesting accounts record the undelegation.
+func (ck CoinKeeper) UndelegateCoins(ctx sdk.Context, addr sdk.Address, amt sdk.Coins) (sdk.Coins, sdk.Error) {
+	acc := ck.am.GetAccount(ctx, addr)
+	if acc == nil {
+		acc = ck.am.NewAccountWithAddress(ctx, addr)
+	}
+
+	if vacc, ok := acc.(auth.VestingAccount); ok {
+		vacc.TrackUndelegation(amt)
+	}
+	newCoins := acc.GetCoins().Plus(amt)
+	acc.SetCoins(newCoins)
+	ck.am.SetAccount(ctx, acc)
+	ck.undelegate(ctx, amt)
+	return newCoins, nil
+}
+
 // SendCoins moves coins from one account to another
 func (ck CoinKeeper) SendCoins(ctx sdk.Context, fromAddr sdk.Address, toAddr sdk.Address, amt sdk.Coins) sdk.Error {
-	_, err := ck.SubtractCoins(ctx, fromAddr, amt)
+	_, err := ck.subtractCoins(ctx, fromAddr, amt)
 	if err != nil {
 		return err
 	}
 
-	_, err = ck.AddCoins(ctx, toAddr, amt)
+	_, err = ck.addCoins(ctx, toAddr, amt)
 	if err != nil {
 		return err
 	}
@@ -72,17 +150,18 @@ func (ck CoinKeeper) SendCoins(ctx sdk.Context, fromAddr sdk.Address, toAddr sdk
 	return nil
 }
 
-// InputOutputCoins handles a list of inputs and outputs
+// InputOutputCoins handles a list of inputs and outputs,
+// whose totals are equal
 func (ck CoinKeeper) InputOutputCoins(ctx sdk.Context, inputs []Input, outputs []Output) sdk.Error {
 	for _, in := range inputs {
-		_, err := ck.SubtractCoins(ctx, in.Address, in.Coins)
+		_, err := ck.subtractCoins(ctx, in.Address, in.Coins)
 		if err != nil {
 			return err
 		}
 	}
 
 	for _, out := range outputs {
-		_, err := ck.AddCoins(ctx, out.Address, out.Coins)
+		_, err := ck.addCoins(ctx, out.Address, out.Coins)
 		if err != nil {
 			return err
 		}
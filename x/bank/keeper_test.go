@@ -0,0 +1,84 @@
+package bank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+	oldwire "github.com/tendermint/go-wire"
+	dbm "github.com/tendermint/tmlibs/db"
+
+	"github.com/cosmos/cosmos-sdk/store"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+)
+
+func setupMultiStore() (sdk.MultiStore, *sdk.KVStoreKey) {
+	db := dbm.NewMemDB()
+	capKey := sdk.NewKVStoreKey("capkey")
+	ms := store.NewCommitMultiStore(db)
+	ms.MountStoreWithDB(capKey, sdk.StoreTypeIAVL, db)
+	ms.LoadLatestVersion()
+
+	var _ = oldwire.RegisterInterface(
+		struct{ sdk.Account }{},
+		oldwire.ConcreteType{&auth.BaseAccount{}, 0x1},
+		oldwire.ConcreteType{&auth.ContinuousVestingAccount{}, 0x2},
+		oldwire.ConcreteType{&auth.DelayedVestingAccount{}, 0x3},
+	)
+
+	return ms, capKey
+}
+
+// Test that vesting accounts can delegate their locked coins,
+// but not spend them.
+func TestCoinKeeperVesting(t *testing.T) {
+	ms, capKey := setupMultiStore()
+	ctx := sdk.NewContext(ms, abci.Header{Time: 150}, false, nil)
+	am := auth.NewAccountMapper(capKey, &auth.BaseAccount{})
+	ck := NewCoinKeeper(am, capKey)
+
+	// half of the coins are locked
+	addr := sdk.Address([]byte("vesting"))
+	other := sdk.Address([]byte("other"))
+	base := auth.NewBaseAccountWithAddress(addr)
+	base.SetCoins(sdk.Coins{{"atom", 100}})
+	acc, err := auth.NewContinuousVestingAccount(base, 100, 200, false)
+	require.Nil(t, err)
+	am.SetAccount(ctx, acc)
+
+	_, err = ck.SubtractCoins(ctx, addr, sdk.Coins{{"atom", 51}})
+	assert.NotNil(t, err)
+	err = ck.SendCoins(ctx, addr, other, sdk.Coins{{"atom", 51}})
+	assert.NotNil(t, err)
+	err = ck.SendCoins(ctx, addr, other, sdk.Coins{{"atom", 10}})
+	assert.Nil(t, err)
+
+	// the locked coins can be delegated, but not more than the coins
+	_, err = ck.DelegateCoins(ctx, addr, sdk.Coins{{"atom", 91}})
+	assert.NotNil(t, err)
+	coins, err := ck.DelegateCoins(ctx, addr, sdk.Coins{{"atom", 60}})
+	assert.Nil(t, err)
+	assert.Equal(t, sdk.Coins{{"atom", 30}}, coins)
+	vacc := am.GetAccount(ctx, addr).(*auth.ContinuousVestingAccount)
+	assert.Equal(t, sdk.Coins{{"atom", 50}}, vacc.DelegatedVesting)
+	assert.Equal(t, sdk.Coins{{"atom", 10}}, vacc.DelegatedFree)
+	assert.Equal(t, sdk.Coins{{"atom", 30}}, vacc.GetSpendableCoins(ctx))
+
+	// undelegated coins are free first
+	coins, err = ck.UndelegateCoins(ctx, addr, sdk.Coins{{"atom", 20}})
+	assert.Nil(t, err)
+	assert.Equal(t, sdk.Coins{{"atom", 50}}, coins)
+	_, err = ck.SubtractCoins(ctx, addr, sdk.Coins{{"atom", 41}})
+	assert.NotNil(t, err)
+	_, err = ck.SubtractCoins(ctx, addr, sdk.Coins{{"atom", 40}})
+	assert.Nil(t, err)
+
+	// other accounts delegate like they spend
+	_, err = ck.DelegateCoins(ctx, other, sdk.Coins{{"atom", 10}})
+	assert.Nil(t, err)
+	_, err = ck.UndelegateCoins(ctx, other, sdk.Coins{{"atom", 10}})
+	assert.Nil(t, err)
+	assert.Equal(t, sdk.Coins{{"atom", 10}}, am.GetAccount(ctx, other).GetCoins())
+}
@@ -22,7 +22,7 @@ func TestSendMsgType(t *testing.T) {
 	}
 
 	// TODO some failures for bad result
-	assert.Equal(t, msg.Type(), "bank")
+	assert.Equal(t, msg.Type(), "bank/send")
 }
 
 func TestInputValidation(t *testing.T) {
@@ -270,7 +270,7 @@ func TestIssueMsgType(t *testing.T) {
 	}
 
 	// TODO some failures for bad result
-	assert.Equal(t, msg.Type(), "bank")
+	assert.Equal(t, msg.Type(), "bank/issue")
 }
 
 func TestIssueMsgValidation(t *testing.T) {
@@ -0,0 +1,42 @@
+package bank
+
+import (
+	abci "github.com/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// nolint - paths answered by the bank querier, eg. "/custom/bank/supply"
+const (
+	QuerySupply = "supply"
+)
+
+// NewQuerier returns a Querier for the bank module. The supply query
+// returns the supply of coins, or of the denom in the data, if any.
+// Results are JSON encoded with the keeper's codec.
+func NewQuerier(ck CoinKeeper) sdk.Querier {
+	return func(ctx sdk.Context, path []string, req abci.RequestQuery) (res []byte, err sdk.Error) {
+		if len(path) == 0 {
+			return nil, sdk.ErrUnknownRequest("no bank query specified")
+		}
+		switch path[0] {
+		case QuerySupply:
+			supply := ck.GetSupply(ctx)
+			if len(req.Data) > 0 {
+				denom := string(req.Data)
+				supply = sdk.Coins{{denom, supply.AmountOf(denom)}}
+			}
+			return ck.marshalQueryResult(supply)
+		default:
+			return nil, sdk.ErrUnknownRequest("unknown bank query: " + path[0])
+		}
+	}
+}
+
+func (ck CoinKeeper) marshalQueryResult(o interface{}) ([]byte, sdk.Error) {
+	bz, err := ck.cdc.MarshalJSON(o)
+	if err != nil {
+		return nil, sdk.ErrInternal(err.Error())
+	}
+	return bz, nil
+}
@@ -0,0 +1,191 @@
+package bank
+
+import (
+	"encoding/json"
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+)
+
+// The supply is the total of the coins of the accounts, of the fee pool and
+// of the delegated coins, per denom. Moving coins between them, eg. to pay
+// fees or to delegate, doesn't change it. Coins which leave them, eg. sent
+// over IBC, are burned, and coins which enter them are minted.
+
+var (
+	// SupplyKey is the store key of the supply
+	SupplyKey = []byte("supply")
+	// DelegatedKey is the store key of the total of the delegated coins
+	DelegatedKey = []byte("delegated")
+)
+
+// GetSupply returns the supply of coins.
+func (ck CoinKeeper) GetSupply(ctx sdk.Context) sdk.Coins {
+	return ck.getCoins(ctx, SupplyKey)
+}
+
+func (ck CoinKeeper) setSupply(ctx sdk.Context, supply sdk.Coins) {
+	ck.setCoins(ctx, SupplyKey, supply)
+}
+
+// GetDelegated returns the total of the delegated coins.
+func (ck CoinKeeper) GetDelegated(ctx sdk.Context) sdk.Coins {
+	return ck.getCoins(ctx, DelegatedKey)
+}
+
+func (ck CoinKeeper) setDelegated(ctx sdk.Context, delegated sdk.Coins) {
+	ck.setCoins(ctx, DelegatedKey, delegated)
+}
+
+func (ck CoinKeeper) getCoins(ctx sdk.Context, key []byte) sdk.Coins {
+	store := ctx.KVStore(ck.key)
+	bz := store.Get(key)
+	if bz == nil {
+		return sdk.Coins{}
+	}
+
+	var coins sdk.Coins
+	err := ck.cdc.UnmarshalBinary(bz, &coins)
+	if err != nil {
+		panic(err)
+	}
+	return coins
+}
+
+func (ck CoinKeeper) setCoins(ctx sdk.Context, key []byte, coins sdk.Coins) {
+	store := ctx.KVStore(ck.key)
+	if coins.IsZero() {
+		store.Delete(key)
+		return
+	}
+	bz, err := ck.cdc.MarshalBinary(coins)
+	if err != nil {
+		panic(err)
+	}
+	store.Set(key, bz)
+}
+
+func (ck CoinKeeper) mint(ctx sdk.Context, amt sdk.Coins) {
+	ck.setSupply(ctx, ck.GetSupply(ctx).Plus(amt))
+}
+
+func (ck CoinKeeper) burn(ctx sdk.Context, amt sdk.Coins) {
+	ck.setSupply(ctx, ck.GetSupply(ctx).Minus(amt))
+}
+
+// delegate moves amt to the delegated coins
+func (ck CoinKeeper) delegate(ctx sdk.Context, amt sdk.Coins) {
+	ck.setDelegated(ctx, ck.GetDelegated(ctx).Plus(amt))
+}
+
+// undelegate moves amt out of the delegated coins. Any of amt over them,
+// eg. the rewards of the delegation, is minted.
+func (ck CoinKeeper) undelegate(ctx sdk.Context, amt sdk.Coins) {
+	delegated := ck.GetDelegated(ctx)
+	var minted sdk.Coins
+	for _, coin := range amt {
+		if over := coin.Amount - delegated.AmountOf(coin.Denom); over > 0 {
+			minted = append(minted, sdk.Coin{coin.Denom, over})
+		}
+	}
+	ck.setDelegated(ctx, delegated.Minus(amt.Minus(minted)))
+	ck.mint(ctx, minted)
+}
+
+// countSupply returns the total of the coins of the accounts, of the fee
+// pool of fck and of the delegated coins.
+func (ck CoinKeeper) countSupply(ctx sdk.Context, fck auth.FeeCollectionKeeper) sdk.Coins {
+	supply := fck.GetCollectedFees(ctx).Plus(ck.GetDelegated(ctx))
+	ck.am.IterateAccounts(ctx, func(acc sdk.Account) bool {
+		supply = supply.Plus(acc.GetCoins())
+		return false
+	})
+	return supply
+}
+
+// SupplyInvariant returns the invariant that the supply is the total of the
+// coins of the accounts, of the fee pool of fck and of the delegated coins. It iterates over all
+// the accounts, so it is meant for tests and audits, not for each block.
+func SupplyInvariant(ck CoinKeeper, fck auth.FeeCollectionKeeper) func(ctx sdk.Context) error {
+	return func(ctx sdk.Context) error {
+		return checkSupply(ck.GetSupply(ctx), ck.countSupply(ctx, fck))
+	}
+}
+
+// checkSupply returns an error listing the denoms whose supply isn't counted
+func checkSupply(supply, counted sdk.Coins) error {
+	var msg string
+	checked := make(map[string]bool)
+	for _, coins := range []sdk.Coins{supply, counted} {
+		for _, coin := range coins {
+			if checked[coin.Denom] {
+				continue
+			}
+			checked[coin.Denom] = true
+			s, c := supply.AmountOf(coin.Denom), counted.AmountOf(coin.Denom)
+			if s != c {
+				msg += fmt.Sprintf("\n\t%s: supply %d, counted %d", coin.Denom, s, c)
+			}
+		}
+	}
+	if msg != "" {
+		return fmt.Errorf("supply of coins is broken:%s", msg)
+	}
+	return nil
+}
+
+// SupplyGenesis is the genesis state of the supply
+type SupplyGenesis struct {
+	Supply    sdk.Coins `json:"supply,omitempty"`
+	Delegated sdk.Coins `json:"delegated,omitempty"`
+}
+
+// NewInitGenesis returns the InitGenesis of the supply, which counts the
+// coins of the genesis accounts and of the fee pool of fck: register it
+// after theirs. The genesis delegated coins, eg. the bonds of the genesis
+// validators, are counted too. If the genesis has a supply, the count must
+// match it.
+func NewInitGenesis(ck CoinKeeper, fck auth.FeeCollectionKeeper) sdk.InitGenesis {
+	return func(ctx sdk.Context, data json.RawMessage) error {
+		var genesis SupplyGenesis
+		if len(data) > 0 {
+			err := json.Unmarshal(data, &genesis)
+			if err != nil {
+				return err
+			}
+		}
+		if !genesis.Delegated.IsValid() || !genesis.Delegated.IsNotNegative() {
+			return sdk.ErrInvalidCoins(genesis.Delegated.String())
+		}
+		ck.setDelegated(ctx, genesis.Delegated)
+		supply := ck.countSupply(ctx, fck)
+		if genesis.Supply != nil {
+			if !genesis.Supply.IsValid() {
+				return sdk.ErrInvalidCoins(genesis.Supply.String())
+			}
+			if err := checkSupply(genesis.Supply, supply); err != nil {
+				return err
+			}
+		}
+		ck.setSupply(ctx, supply)
+		return nil
+	}
+}
+
+// ExportGenesis exports the supply and the delegated coins,
+// or nothing if there are no coins.
+func (ck CoinKeeper) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	genesis := SupplyGenesis{ck.GetSupply(ctx), ck.GetDelegated(ctx)}
+	if genesis.Supply.IsZero() {
+		return nil
+	}
+	if genesis.Delegated.IsZero() {
+		genesis.Delegated = nil
+	}
+	bz, err := json.Marshal(genesis)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
@@ -0,0 +1,146 @@
+package bank
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+)
+
+func TestSupply(t *testing.T) {
+	ms, capKey := setupMultiStore()
+	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
+	am := auth.NewAccountMapper(capKey, &auth.BaseAccount{})
+	fck := auth.NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	ck := NewCoinKeeper(am, capKey)
+	invariant := SupplyInvariant(ck, fck)
+	addr1 := sdk.Address([]byte("addr1"))
+	addr2 := sdk.Address([]byte("addr2"))
+
+	// minting and burning change the supply
+	assert.Empty(t, ck.GetSupply(ctx))
+	ck.AddCoins(ctx, addr1, sdk.Coins{{"atom", 100}, {"steak", 10}})
+	ck.AddCoins(ctx, addr2, sdk.Coins{{"atom", 50}})
+	_, err := ck.SubtractCoins(ctx, addr2, sdk.Coins{{"atom", 20}})
+	require.Nil(t, err)
+	_, err = ck.SubtractCoins(ctx, addr2, sdk.Coins{{"atom", 100}})
+	require.NotNil(t, err)
+	assert.Equal(t, sdk.Coins{{"atom", 130}, {"steak", 10}}, ck.GetSupply(ctx))
+	assert.Nil(t, invariant(ctx))
+
+	// moving coins doesn't
+	require.Nil(t, ck.SendCoins(ctx, addr1, addr2, sdk.Coins{{"atom", 10}}))
+	require.Nil(t, ck.InputOutputCoins(ctx,
+		[]Input{NewInput(addr2, sdk.Coins{{"atom", 5}})},
+		[]Output{NewOutput(addr1, sdk.Coins{{"atom", 5}})}))
+	assert.Equal(t, sdk.Coins{{"atom", 130}, {"steak", 10}}, ck.GetSupply(ctx))
+	assert.Nil(t, invariant(ctx))
+
+	// nor does moving them to the fee pool and back
+	acc := am.GetAccount(ctx, addr1)
+	acc.SetCoins(acc.GetCoins().Minus(sdk.Coins{{"steak", 4}}))
+	am.SetAccount(ctx, acc)
+	fck.AddCollectedFees(ctx, sdk.Coins{{"steak", 4}})
+	assert.Nil(t, invariant(ctx))
+	ck.PayFees(ctx, addr2, sdk.Coins{{"steak", 3}})
+	fck.ClearCollectedFees(ctx)
+	fck.AddCollectedFees(ctx, sdk.Coins{{"steak", 1}})
+	assert.Equal(t, sdk.Coins{{"atom", 130}, {"steak", 10}}, ck.GetSupply(ctx))
+	assert.Nil(t, invariant(ctx))
+
+	// nor does delegating them and undelegating them
+	_, err = ck.DelegateCoins(ctx, addr1, sdk.Coins{{"atom", 30}})
+	require.Nil(t, err)
+	assert.Equal(t, sdk.Coins{{"atom", 30}}, ck.GetDelegated(ctx))
+	assert.Equal(t, sdk.Coins{{"atom", 130}, {"steak", 10}}, ck.GetSupply(ctx))
+	assert.Nil(t, invariant(ctx))
+	_, err = ck.UndelegateCoins(ctx, addr1, sdk.Coins{{"atom", 20}})
+	require.Nil(t, err)
+	assert.Equal(t, sdk.Coins{{"atom", 10}}, ck.GetDelegated(ctx))
+	assert.Equal(t, sdk.Coins{{"atom", 130}, {"steak", 10}}, ck.GetSupply(ctx))
+	assert.Nil(t, invariant(ctx))
+
+	// but undelegating more than was delegated mints the rest
+	_, err = ck.UndelegateCoins(ctx, addr1, sdk.Coins{{"atom", 15}, {"steak", 2}})
+	require.Nil(t, err)
+	assert.Empty(t, ck.GetDelegated(ctx))
+	assert.Equal(t, sdk.Coins{{"atom", 135}, {"steak", 12}}, ck.GetSupply(ctx))
+	assert.Nil(t, invariant(ctx))
+
+	// coins which the bank didn't mint break the invariant
+	acc = am.GetAccount(ctx, addr2)
+	acc.SetCoins(acc.GetCoins().Plus(sdk.Coins{{"photon", 1}}))
+	am.SetAccount(ctx, acc)
+	broken := invariant(ctx)
+	require.NotNil(t, broken)
+	assert.Contains(t, broken.Error(), "photon: supply 0, counted 1")
+}
+
+func TestSupplyGenesis(t *testing.T) {
+	ms, capKey := setupMultiStore()
+	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
+	am := auth.NewAccountMapper(capKey, &auth.BaseAccount{})
+	fck := auth.NewFeeCollectionKeeper(wire.NewCodec(), capKey)
+	ck := NewCoinKeeper(am, capKey)
+	initGenesis := NewInitGenesis(ck, fck)
+
+	// the genesis supply is counted
+	acc := auth.NewBaseAccountWithAddress(sdk.Address([]byte("addr1")))
+	acc.SetCoins(sdk.Coins{{"atom", 100}})
+	am.SetAccount(ctx, &acc)
+	require.Nil(t, fck.InitGenesis(ctx, []byte(`[{"denom":"atom","amount":5}]`)))
+	assert.Nil(t, ck.ExportGenesis(ctx))
+	require.Nil(t, initGenesis(ctx, nil))
+	assert.Equal(t, sdk.Coins{{"atom", 105}}, ck.GetSupply(ctx))
+	assert.Nil(t, SupplyInvariant(ck, fck)(ctx))
+
+	// as are the genesis delegated coins
+	require.Nil(t, initGenesis(ctx, []byte(`{"delegated":[{"denom":"atom","amount":10}]}`)))
+	assert.Equal(t, sdk.Coins{{"atom", 10}}, ck.GetDelegated(ctx))
+	assert.Equal(t, sdk.Coins{{"atom", 115}}, ck.GetSupply(ctx))
+	assert.Nil(t, SupplyInvariant(ck, fck)(ctx))
+
+	// an exported supply must match the count
+	exported := ck.ExportGenesis(ctx)
+	assert.Equal(t, `{"supply":[{"denom":"atom","amount":115}],"delegated":[{"denom":"atom","amount":10}]}`, string(exported))
+	require.Nil(t, initGenesis(ctx, exported))
+	assert.Equal(t, sdk.Coins{{"atom", 10}}, ck.GetDelegated(ctx))
+	assert.NotNil(t, initGenesis(ctx, []byte(`{"supply":[{"denom":"atom","amount":115}]}`)))
+	assert.NotNil(t, initGenesis(ctx, []byte(`{"supply":[{"denom":"b","amount":1},{"denom":"a","amount":1}]}`)))
+	assert.NotNil(t, initGenesis(ctx, []byte(`{"delegated":[{"denom":"atom","amount":-1}]}`)))
+}
+
+func TestSupplyQuerier(t *testing.T) {
+	ms, capKey := setupMultiStore()
+	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
+	am := auth.NewAccountMapper(capKey, &auth.BaseAccount{})
+	ck := NewCoinKeeper(am, capKey)
+	querier := NewQuerier(ck)
+	ck.AddCoins(ctx, sdk.Address([]byte("addr1")), sdk.Coins{{"atom", 100}, {"steak", 10}})
+
+	cases := []struct {
+		denom  string
+		supply sdk.Coins
+	}{
+		{"", sdk.Coins{{"atom", 100}, {"steak", 10}}},
+		{"steak", sdk.Coins{{"steak", 10}}},
+		{"photon", sdk.Coins{{"photon", 0}}},
+	}
+	for _, tc := range cases {
+		res, err := querier(ctx, []string{QuerySupply}, abci.RequestQuery{Data: []byte(tc.denom)})
+		require.Nil(t, err)
+		var supply sdk.Coins
+		require.Nil(t, wire.NewCodec().UnmarshalJSON(res, &supply))
+		assert.Equal(t, tc.supply, supply, tc.denom)
+	}
+
+	_, err := querier(ctx, []string{"other"}, abci.RequestQuery{})
+	assert.NotNil(t, err)
+	_, err = querier(ctx, nil, abci.RequestQuery{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,41 @@
+package feegrant
+
+import (
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+const (
+	// feegrant errors reserve 500 - 599.
+	CodeInvalidAllowance sdk.CodeType = 501
+	CodeNoAllowance      sdk.CodeType = 502
+	CodeFeeLimitExceeded sdk.CodeType = 503
+	CodeExpired          sdk.CodeType = 504
+	CodeMsgNotAllowed    sdk.CodeType = 505
+)
+
+func ErrInvalidAllowance(msg string) sdk.Error {
+	return newError(CodeInvalidAllowance, "invalid fee allowance: "+msg)
+}
+
+func ErrNoAllowance() sdk.Error {
+	return newError(CodeNoAllowance, "no fee allowance")
+}
+
+func ErrFeeLimitExceeded(msg string) sdk.Error {
+	return newError(CodeFeeLimitExceeded, "fee exceeds the spend limit: "+msg)
+}
+
+func ErrExpired() sdk.Error {
+	return newError(CodeExpired, "fee allowance expired")
+}
+
+func ErrMsgNotAllowed(msgType string) sdk.Error {
+	return newError(CodeMsgNotAllowed, "fee allowance doesn't allow msg type "+msgType)
+}
+
+// -----------------------------
+// Helpers
+
+func newError(code sdk.CodeType, msg string) sdk.Error {
+	return sdk.NewError(code, msg)
+}
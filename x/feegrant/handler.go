@@ -0,0 +1,39 @@
+package feegrant
+
+import (
+	"reflect"
+
+	abci "github.com/tendermint/abci/types"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// NewHandler returns a handler for "feegrant" type messages.
+func NewHandler(k Keeper) sdk.Handler {
+	return func(ctx sdk.Context, msg sdk.Msg) sdk.Result {
+		switch msg := msg.(type) {
+		case MsgGrantFeeAllowance:
+			k.GrantFeeAllowance(ctx, msg.Granter, msg.Grantee, msg.Allowance)
+			return sdk.Result{}
+		case MsgRevokeFeeAllowance:
+			err := k.RevokeFeeAllowance(ctx, msg.Granter, msg.Grantee)
+			if err != nil {
+				return err.Result()
+			}
+			return sdk.Result{}
+		default:
+			errMsg := "Unrecognized feegrant Msg type: " + reflect.TypeOf(msg).Name()
+			return sdk.ErrUnknownRequest(errMsg).Result()
+		}
+	}
+}
+
+// NewBeginBlocker returns a BeginBlocker which deletes the fee allowances
+// expired by the time of the block. Unlike an EndBlocker, it leaves the
+// validator updates of the txs to BaseApp.
+func NewBeginBlocker(k Keeper) sdk.BeginBlocker {
+	return func(ctx sdk.Context, req abci.RequestBeginBlock) abci.ResponseBeginBlock {
+		k.DeleteExpiredAllowances(ctx)
+		return abci.ResponseBeginBlock{}
+	}
+}
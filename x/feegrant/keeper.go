@@ -0,0 +1,197 @@
+package feegrant
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
+)
+
+var _ auth.FeeGrantKeeper = Keeper{}
+
+// Keeper keeps the fee allowances of grantees from granters.
+type Keeper struct {
+	key sdk.StoreKey
+	cdc *wire.Codec
+}
+
+// NewKeeper returns a Keeper which keeps the fee allowances in the store of
+// key, which it must not share, as ExportGenesis exports the whole store.
+func NewKeeper(cdc *wire.Codec, key sdk.StoreKey) Keeper {
+	return Keeper{
+		key: key,
+		cdc: cdc,
+	}
+}
+
+var (
+	// the prefix of the store keys of the fee allowances
+	allowancePrefix = []byte{0x00}
+	// the prefix of the store keys of the expirations of the fee allowances
+	expirationPrefix = []byte{0x01}
+)
+
+// the granter and grantee of the fee allowance
+func grantKey(granter, grantee sdk.Address) []byte {
+	return append(append([]byte{byte(len(granter))}, granter...), grantee...)
+}
+
+// the store key of the fee allowance of grantee from granter
+func allowanceKey(granter, grantee sdk.Address) []byte {
+	return append(allowancePrefix, grantKey(granter, grantee)...)
+}
+
+// the store key of the expiration of the fee allowance of grantee from
+// granter, which orders the fee allowances by expiration
+func expirationKey(expiration int64, granter, grantee sdk.Address) []byte {
+	return append(expirationPrefixUntil(expiration), grantKey(granter, grantee)...)
+}
+
+// the prefix of the store keys of the expirations at expiration
+func expirationPrefixUntil(expiration int64) []byte {
+	key := make([]byte, len(expirationPrefix)+8)
+	copy(key, expirationPrefix)
+	binary.BigEndian.PutUint64(key[len(expirationPrefix):], uint64(expiration))
+	return key
+}
+
+// GetFeeAllowance returns the fee allowance of grantee from granter.
+func (k Keeper) GetFeeAllowance(ctx sdk.Context, granter, grantee sdk.Address) (allowance FeeAllowance, found bool) {
+	store := ctx.KVStore(k.key)
+	bz := store.Get(allowanceKey(granter, grantee))
+	if bz == nil {
+		return allowance, false
+	}
+	err := k.cdc.UnmarshalBinary(bz, &allowance)
+	if err != nil {
+		panic(err)
+	}
+	return allowance, true
+}
+
+// GrantFeeAllowance sets the fee allowance of grantee from granter,
+// replacing any previous one.
+func (k Keeper) GrantFeeAllowance(ctx sdk.Context, granter, grantee sdk.Address, allowance FeeAllowance) {
+	store := ctx.KVStore(k.key)
+	if previous, found := k.GetFeeAllowance(ctx, granter, grantee); found && previous.Expiration > 0 {
+		store.Delete(expirationKey(previous.Expiration, granter, grantee))
+	}
+	bz, err := k.cdc.MarshalBinary(allowance)
+	if err != nil {
+		panic(err)
+	}
+	store.Set(allowanceKey(granter, grantee), bz)
+	if allowance.Expiration > 0 {
+		store.Set(expirationKey(allowance.Expiration, granter, grantee), []byte{})
+	}
+}
+
+// RevokeFeeAllowance deletes the fee allowance of grantee from granter.
+func (k Keeper) RevokeFeeAllowance(ctx sdk.Context, granter, grantee sdk.Address) sdk.Error {
+	allowance, found := k.GetFeeAllowance(ctx, granter, grantee)
+	if !found {
+		return ErrNoAllowance()
+	}
+	k.deleteFeeAllowance(ctx, granter, grantee, allowance)
+	return nil
+}
+
+func (k Keeper) deleteFeeAllowance(ctx sdk.Context, granter, grantee sdk.Address, allowance FeeAllowance) {
+	store := ctx.KVStore(k.key)
+	store.Delete(allowanceKey(granter, grantee))
+	if allowance.Expiration > 0 {
+		store.Delete(expirationKey(allowance.Expiration, granter, grantee))
+	}
+}
+
+// UseGrantedFees checks that granter allows grantee to pay fee for msgs,
+// and takes fee out of the allowance, which is deleted once it is spent
+// or expired. Implements auth.FeeGrantKeeper.
+func (k Keeper) UseGrantedFees(ctx sdk.Context, granter, grantee sdk.Address, fee sdk.Coins, msgs []sdk.Msg) sdk.Error {
+	allowance, found := k.GetFeeAllowance(ctx, granter, grantee)
+	if !found {
+		return ErrNoAllowance()
+	}
+	rest, spent, err := allowance.use(ctx, fee, msgs)
+	if err != nil {
+		if err.ABCICode() == CodeExpired {
+			k.deleteFeeAllowance(ctx, granter, grantee, allowance)
+		}
+		return err
+	}
+	if spent {
+		k.deleteFeeAllowance(ctx, granter, grantee, allowance)
+		return nil
+	}
+	k.GrantFeeAllowance(ctx, granter, grantee, rest)
+	return nil
+}
+
+// DeleteExpiredAllowances deletes the fee allowances which expired by the
+// block time of ctx. The ante handler rolls back the txs which find their
+// allowance expired, with its deletion, so it is meant for BeginBlock.
+func (k Keeper) DeleteExpiredAllowances(ctx sdk.Context) {
+	store := ctx.KVStore(k.key)
+	end := expirationPrefixUntil(ctx.BlockHeader().Time + 1)
+	iter := store.Iterator(expirationPrefix, end)
+	var expired [][]byte
+	for ; iter.Valid(); iter.Next() {
+		expired = append(expired, iter.Key())
+	}
+	iter.Close()
+	for _, key := range expired {
+		store.Delete(key)
+		store.Delete(append(allowancePrefix, key[len(expirationPrefix)+8:]...))
+	}
+}
+
+// InitGenesis stores the grants exported by ExportGenesis.
+func (k Keeper) InitGenesis(ctx sdk.Context, data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var grants []Grant
+	if err := json.Unmarshal(data, &grants); err != nil {
+		return err
+	}
+	for _, grant := range grants {
+		if err := grant.Allowance.ValidateBasic(); err != nil {
+			return err
+		}
+		k.GrantFeeAllowance(ctx, grant.Granter, grant.Grantee, grant.Allowance)
+	}
+	return nil
+}
+
+// ExportGenesis returns all grants, ordered by granter and grantee,
+// or nothing if there are none.
+func (k Keeper) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	store := ctx.KVStore(k.key)
+	iter := store.Iterator(allowancePrefix, sdk.PrefixEndBytes(allowancePrefix))
+	defer iter.Close()
+	var grants []Grant
+	for ; iter.Valid(); iter.Next() {
+		var allowance FeeAllowance
+		err := k.cdc.UnmarshalBinary(iter.Value(), &allowance)
+		if err != nil {
+			panic(err)
+		}
+		key := iter.Key()[len(allowancePrefix):]
+		n := int(key[0])
+		grants = append(grants, Grant{
+			Granter:   key[1 : 1+n],
+			Grantee:   key[1+n:],
+			Allowance: allowance,
+		})
+	}
+	if len(grants) == 0 {
+		return nil
+	}
+	bz, err := json.Marshal(grants)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
@@ -0,0 +1,155 @@
+package feegrant
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	abci "github.com/tendermint/abci/types"
+
+	"github.com/cosmos/cosmos-sdk/mock"
+	sdk "github.com/cosmos/cosmos-sdk/types"
+	"github.com/cosmos/cosmos-sdk/wire"
+)
+
+var (
+	granter  = sdk.Address([]byte("granter"))
+	granter2 = sdk.Address([]byte("granter2"))
+	grantee  = sdk.Address([]byte("grantee"))
+)
+
+func setupKeeper() (sdk.Context, Keeper) {
+	key := sdk.NewKVStoreKey("feegrant")
+	ctx := sdk.NewContext(mock.SetupMultiStore(key), abci.Header{Time: 100}, false, nil)
+	return ctx, NewKeeper(wire.NewCodec(), key)
+}
+
+func TestUseGrantedFees(t *testing.T) {
+	ctx, k := setupKeeper()
+	send := sdk.NewTestMsg(grantee)
+
+	// no allowance
+	err := k.UseGrantedFees(ctx, granter, grantee, sdk.Coins{{"atom", 1}}, []sdk.Msg{send})
+	require.NotNil(t, err)
+	assert.Equal(t, CodeNoAllowance, err.ABCICode())
+
+	// the fees are taken out of the spend limit, until it is spent
+	k.GrantFeeAllowance(ctx, granter, grantee, FeeAllowance{SpendLimit: sdk.Coins{{"atom", 10}}})
+	err = k.UseGrantedFees(ctx, granter, grantee, sdk.Coins{{"atom", 11}}, []sdk.Msg{send})
+	require.NotNil(t, err)
+	assert.Equal(t, CodeFeeLimitExceeded, err.ABCICode())
+	err = k.UseGrantedFees(ctx, granter, grantee, sdk.Coins{{"atom", 4}}, []sdk.Msg{send})
+	require.Nil(t, err)
+	allowance, found := k.GetFeeAllowance(ctx, granter, grantee)
+	require.True(t, found)
+	assert.Equal(t, sdk.Coins{{"atom", 6}}, allowance.SpendLimit)
+	err = k.UseGrantedFees(ctx, granter, grantee, sdk.Coins{{"atom", 6}}, []sdk.Msg{send})
+	require.Nil(t, err)
+	_, found = k.GetFeeAllowance(ctx, granter, grantee)
+	assert.False(t, found)
+
+	// without a spend limit, any fee is allowed until the expiration
+	k.GrantFeeAllowance(ctx, granter, grantee, FeeAllowance{Expiration: 200})
+	err = k.UseGrantedFees(ctx, granter, grantee, sdk.Coins{{"atom", 1000}}, []sdk.Msg{send})
+	assert.Nil(t, err)
+	ctx = ctx.WithBlockHeader(abci.Header{Time: 200})
+	err = k.UseGrantedFees(ctx, granter, grantee, sdk.Coins{{"atom", 1}}, []sdk.Msg{send})
+	require.NotNil(t, err)
+	assert.Equal(t, CodeExpired, err.ABCICode())
+	// which deletes it
+	_, found = k.GetFeeAllowance(ctx, granter, grantee)
+	assert.False(t, found)
+
+	// only for the allowed msg types, which match exactly
+	k.GrantFeeAllowance(ctx, granter, grantee, FeeAllowance{AllowedMsgTypes: []string{"bank/issue", send.Type()}})
+	err = k.UseGrantedFees(ctx, granter, grantee, nil, []sdk.Msg{send, send})
+	assert.Nil(t, err)
+	for _, allowed := range []string{"bank/issue", "Test", "TestMsg/send"} {
+		k.GrantFeeAllowance(ctx, granter, grantee, FeeAllowance{AllowedMsgTypes: []string{allowed}})
+		err = k.UseGrantedFees(ctx, granter, grantee, nil, []sdk.Msg{send})
+		require.NotNil(t, err, allowed)
+		assert.Equal(t, CodeMsgNotAllowed, err.ABCICode())
+	}
+
+	// of the granter
+	err = k.UseGrantedFees(ctx, granter2, grantee, nil, []sdk.Msg{send})
+	assert.NotNil(t, err)
+	require.Nil(t, k.RevokeFeeAllowance(ctx, granter, grantee))
+	assert.NotNil(t, k.RevokeFeeAllowance(ctx, granter, grantee))
+	err = k.UseGrantedFees(ctx, granter, grantee, nil, []sdk.Msg{send})
+	assert.NotNil(t, err)
+}
+
+func TestDeleteExpiredAllowances(t *testing.T) {
+	ctx, k := setupKeeper()
+	beginBlocker := NewBeginBlocker(k)
+	k.GrantFeeAllowance(ctx, granter, grantee, FeeAllowance{Expiration: 300})
+	k.GrantFeeAllowance(ctx, granter2, grantee, FeeAllowance{Expiration: 200})
+	k.GrantFeeAllowance(ctx, grantee, granter, FeeAllowance{})
+	found := func(granter, grantee sdk.Address) bool {
+		_, found := k.GetFeeAllowance(ctx, granter, grantee)
+		return found
+	}
+
+	// the allowances are deleted once they expire
+	beginBlocker(ctx, abci.RequestBeginBlock{})
+	assert.True(t, found(granter, grantee))
+	assert.True(t, found(granter2, grantee))
+	ctx = ctx.WithBlockHeader(abci.Header{Time: 200})
+	beginBlocker(ctx, abci.RequestBeginBlock{})
+	assert.True(t, found(granter, grantee))
+	assert.False(t, found(granter2, grantee))
+
+	// by their latest expiration
+	k.GrantFeeAllowance(ctx, granter, grantee, FeeAllowance{Expiration: 1000})
+	ctx = ctx.WithBlockHeader(abci.Header{Time: 999})
+	beginBlocker(ctx, abci.RequestBeginBlock{})
+	assert.True(t, found(granter, grantee))
+	ctx = ctx.WithBlockHeader(abci.Header{Time: 1000})
+	beginBlocker(ctx, abci.RequestBeginBlock{})
+	assert.False(t, found(granter, grantee))
+
+	// and not at all without one
+	assert.True(t, found(grantee, granter))
+	var grants []Grant
+	require.Nil(t, json.Unmarshal(k.ExportGenesis(ctx), &grants))
+	assert.Equal(t, 1, len(grants))
+}
+
+func TestHandler(t *testing.T) {
+	ctx, k := setupKeeper()
+	handler := NewHandler(k)
+	allowance := FeeAllowance{SpendLimit: sdk.Coins{{"atom", 10}}}
+
+	res := handler(ctx, NewMsgGrantFeeAllowance(granter, grantee, allowance))
+	require.True(t, res.IsOK(), res.Log)
+	got, found := k.GetFeeAllowance(ctx, granter, grantee)
+	require.True(t, found)
+	assert.Equal(t, allowance.SpendLimit, got.SpendLimit)
+	res = handler(ctx, NewMsgRevokeFeeAllowance(granter, grantee))
+	require.True(t, res.IsOK(), res.Log)
+	_, found = k.GetFeeAllowance(ctx, granter, grantee)
+	assert.False(t, found)
+	res = handler(ctx, NewMsgRevokeFeeAllowance(granter, grantee))
+	assert.Equal(t, CodeNoAllowance, res.Code)
+}
+
+func TestGenesis(t *testing.T) {
+	ctx, k := setupKeeper()
+	assert.Nil(t, k.ExportGenesis(ctx))
+
+	k.GrantFeeAllowance(ctx, granter, grantee, FeeAllowance{SpendLimit: sdk.Coins{{"atom", 10}}})
+	k.GrantFeeAllowance(ctx, granter2, grantee, FeeAllowance{Expiration: 200, AllowedMsgTypes: []string{"bank/send"}})
+	exported := k.ExportGenesis(ctx)
+
+	ctx2, k2 := setupKeeper()
+	require.Nil(t, k2.InitGenesis(ctx2, exported))
+	assert.Equal(t, string(exported), string(k2.ExportGenesis(ctx2)))
+	allowance, found := k2.GetFeeAllowance(ctx2, granter2, grantee)
+	require.True(t, found)
+	assert.Equal(t, int64(200), allowance.Expiration)
+
+	bad := []byte(`[{"granter":"00","grantee":"01","allowance":{"spend_limit":[{"denom":"atom","amount":-1}]}}]`)
+	assert.NotNil(t, k2.InitGenesis(ctx2, bad))
+}
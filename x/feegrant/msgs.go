@@ -0,0 +1,97 @@
+package feegrant
+
+import (
+	"encoding/json"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// name to identify transaction types
+const MsgType = "feegrant"
+
+//Verify interface at compile time
+var _, _ sdk.Msg = MsgGrantFeeAllowance{}, MsgRevokeFeeAllowance{}
+
+//______________________________________________________________________
+
+// MsgGrantFeeAllowance lets the granter pay the fees of the grantee,
+// replacing any previous fee allowance
+type MsgGrantFeeAllowance struct {
+	Granter   sdk.Address  `json:"granter"`
+	Grantee   sdk.Address  `json:"grantee"`
+	Allowance FeeAllowance `json:"allowance"`
+}
+
+func NewMsgGrantFeeAllowance(granter, grantee sdk.Address, allowance FeeAllowance) MsgGrantFeeAllowance {
+	return MsgGrantFeeAllowance{
+		Granter:   granter,
+		Grantee:   grantee,
+		Allowance: allowance,
+	}
+}
+
+//nolint
+func (msg MsgGrantFeeAllowance) Type() string                            { return MsgType + "/grant" }
+func (msg MsgGrantFeeAllowance) Get(key interface{}) (value interface{}) { return nil }
+func (msg MsgGrantFeeAllowance) GetSigners() []sdk.Address               { return []sdk.Address{msg.Granter} }
+
+// get the bytes for the message signer to sign on
+func (msg MsgGrantFeeAllowance) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// quick validity check
+func (msg MsgGrantFeeAllowance) ValidateBasic() sdk.Error {
+	if len(msg.Granter) == 0 {
+		return sdk.ErrInvalidAddress("missing granter address")
+	}
+	if len(msg.Grantee) == 0 {
+		return sdk.ErrInvalidAddress("missing grantee address")
+	}
+	return msg.Allowance.ValidateBasic()
+}
+
+//______________________________________________________________________
+
+// MsgRevokeFeeAllowance deletes the fee allowance of the grantee
+// from the granter
+type MsgRevokeFeeAllowance struct {
+	Granter sdk.Address `json:"granter"`
+	Grantee sdk.Address `json:"grantee"`
+}
+
+func NewMsgRevokeFeeAllowance(granter, grantee sdk.Address) MsgRevokeFeeAllowance {
+	return MsgRevokeFeeAllowance{
+		Granter: granter,
+		Grantee: grantee,
+	}
+}
+
+//nolint
+func (msg MsgRevokeFeeAllowance) Type() string                            { return MsgType + "/revoke" }
+func (msg MsgRevokeFeeAllowance) Get(key interface{}) (value interface{}) { return nil }
+func (msg MsgRevokeFeeAllowance) GetSigners() []sdk.Address               { return []sdk.Address{msg.Granter} }
+
+// get the bytes for the message signer to sign on
+func (msg MsgRevokeFeeAllowance) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// quick validity check
+func (msg MsgRevokeFeeAllowance) ValidateBasic() sdk.Error {
+	if len(msg.Granter) == 0 {
+		return sdk.ErrInvalidAddress("missing granter address")
+	}
+	if len(msg.Grantee) == 0 {
+		return sdk.ErrInvalidAddress("missing grantee address")
+	}
+	return nil
+}
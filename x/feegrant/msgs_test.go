@@ -0,0 +1,35 @@
+package feegrant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestMsgGrantFeeAllowanceValidation(t *testing.T) {
+	cases := []struct {
+		valid bool
+		msg   MsgGrantFeeAllowance
+	}{
+		{true, NewMsgGrantFeeAllowance(granter, grantee, FeeAllowance{})},
+		{true, NewMsgGrantFeeAllowance(granter, grantee, FeeAllowance{SpendLimit: sdk.Coins{{"atom", 1}}, Expiration: 100})},
+		{false, NewMsgGrantFeeAllowance(nil, grantee, FeeAllowance{})},
+		{false, NewMsgGrantFeeAllowance(granter, nil, FeeAllowance{})},
+		{false, NewMsgGrantFeeAllowance(granter, grantee, FeeAllowance{SpendLimit: sdk.Coins{{"atom", 0}}})},
+		{false, NewMsgGrantFeeAllowance(granter, grantee, FeeAllowance{Expiration: -1})},
+	}
+	for i, tc := range cases {
+		err := tc.msg.ValidateBasic()
+		assert.Equal(t, tc.valid, err == nil, "case %d: %v", i, err)
+	}
+	assert.Equal(t, []sdk.Address{granter}, cases[0].msg.GetSigners())
+}
+
+func TestMsgRevokeFeeAllowanceValidation(t *testing.T) {
+	assert.Nil(t, NewMsgRevokeFeeAllowance(granter, grantee).ValidateBasic())
+	assert.NotNil(t, NewMsgRevokeFeeAllowance(granter, nil).ValidateBasic())
+	assert.NotNil(t, NewMsgRevokeFeeAllowance(nil, grantee).ValidateBasic())
+	assert.Equal(t, []sdk.Address{granter}, NewMsgRevokeFeeAllowance(granter, grantee).GetSigners())
+}
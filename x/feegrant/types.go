@@ -0,0 +1,72 @@
+package feegrant
+
+import (
+	"fmt"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// FeeAllowance lets a grantee have its fees paid by a granter. The fees
+// are taken out of the SpendLimit, if any, until the block time reaches
+// the Expiration, if any, for the txs whose Msgs all have one of the
+// AllowedMsgTypes, if any. Each Msg has a type of its own, eg. "bank/send",
+// which matches exactly: "bank" matches none of the bank Msgs.
+type FeeAllowance struct {
+	SpendLimit      sdk.Coins `json:"spend_limit,omitempty"`
+	Expiration      int64     `json:"expiration,omitempty"` // unix seconds
+	AllowedMsgTypes []string  `json:"allowed_msg_types,omitempty"`
+}
+
+// ValidateBasic checks that the spend limit is valid and positive, if any.
+func (fa FeeAllowance) ValidateBasic() sdk.Error {
+	if len(fa.SpendLimit) > 0 && (!fa.SpendLimit.IsValid() || !fa.SpendLimit.IsPositive()) {
+		return ErrInvalidAllowance(fmt.Sprintf("spend limit %s", fa.SpendLimit))
+	}
+	if fa.Expiration < 0 {
+		return ErrInvalidAllowance(fmt.Sprintf("expiration %d", fa.Expiration))
+	}
+	return nil
+}
+
+// use checks that the allowance allows fee for msgs at the block of ctx,
+// and returns the rest of the allowance, or whether it is spent.
+func (fa FeeAllowance) use(ctx sdk.Context, fee sdk.Coins, msgs []sdk.Msg) (rest FeeAllowance, spent bool, err sdk.Error) {
+	if fa.Expiration > 0 && ctx.BlockHeader().Time >= fa.Expiration {
+		return fa, false, ErrExpired()
+	}
+	for _, msg := range msgs {
+		if !fa.allows(msg.Type()) {
+			return fa, false, ErrMsgNotAllowed(msg.Type())
+		}
+	}
+	if len(fa.SpendLimit) == 0 {
+		return fa, false, nil
+	}
+	left := fa.SpendLimit.Minus(fee)
+	if !left.IsNotNegative() {
+		return fa, false, ErrFeeLimitExceeded(fmt.Sprintf("%s > %s", fee, fa.SpendLimit))
+	}
+	fa.SpendLimit = left
+	return fa, left.IsZero(), nil
+}
+
+// allows returns whether the allowance allows the Msg type msgType.
+func (fa FeeAllowance) allows(msgType string) bool {
+	if len(fa.AllowedMsgTypes) == 0 {
+		return true
+	}
+	for _, allowed := range fa.AllowedMsgTypes {
+		if msgType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant is the fee allowance of a grantee from a granter,
+// as carried over genesis
+type Grant struct {
+	Granter   sdk.Address  `json:"granter"`
+	Grantee   sdk.Address  `json:"grantee"`
+	Allowance FeeAllowance `json:"allowance"`
+}
@@ -14,6 +14,7 @@ import (
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	wire "github.com/cosmos/cosmos-sdk/wire"
 
+	"github.com/cosmos/cosmos-sdk/x/auth"
 	authcmd "github.com/cosmos/cosmos-sdk/x/auth/commands"
 	"github.com/cosmos/cosmos-sdk/x/ibc"
 )
@@ -154,7 +155,7 @@ func (c relayCommander) broadcastTx(seq int64, node string, tx []byte) error {
 }
 
 func (c relayCommander) getSequence(node string) int64 {
-	res, err := query(node, c.address, c.mainStore)
+	res, err := query(node, auth.AddressStoreKey(c.address), c.mainStore)
 	if err != nil {
 		panic(err)
 	}
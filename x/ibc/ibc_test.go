@@ -1,6 +1,7 @@
 package ibc
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -76,7 +77,7 @@ func TestIBC(t *testing.T) {
 	ctx := defaultContext(key)
 
 	am := auth.NewAccountMapper(key, &auth.BaseAccount{})
-	ck := bank.NewCoinKeeper(am)
+	ck := bank.NewCoinKeeper(am, key)
 
 	src := newAddress()
 	dest := newAddress()
@@ -145,3 +146,32 @@ func TestIBC(t *testing.T) {
 	igs = ibcm.GetIngressSequence(ctx, chainid)
 	assert.Equal(t, igs, int64(1))
 }
+
+func TestIBCGenesis(t *testing.T) {
+	cdc := makeCodec()
+
+	key := sdk.NewKVStoreKey("ibc")
+	ctx := defaultContext(key)
+	ibcm := NewIBCMapper(cdc, key)
+
+	mycoins := sdk.Coins{sdk.Coin{"mycoin", 10}}
+	packet := NewIBCPacket(newAddress(), newAddress(), mycoins, "srcchain", "destchain")
+	assert.Nil(t, ibcm.PostIBCPacket(ctx, packet))
+	assert.Nil(t, ibcm.PostIBCPacket(ctx, packet))
+	ibcm.getEgressLength(ctx.KVStore(key), "otherchain")
+	ibcm.SetIngressSequence(ctx, "srcchain", 5)
+
+	exported := ibcm.ExportGenesis(ctx)
+	var genesis IBCGenesis
+	assert.Nil(t, json.Unmarshal(exported, &genesis))
+	assert.Equal(t, map[string]int64{"srcchain": 5}, genesis.IngressSequences)
+	assert.Equal(t, []IBCPacket{packet, packet}, genesis.EgressPackets["destchain"])
+	assert.Empty(t, genesis.EgressPackets["otherchain"])
+
+	// the exported state reproduces the store
+	ctx2 := defaultContext(key)
+	assert.Nil(t, ibcm.InitGenesis(ctx2, exported))
+	assert.Equal(t, int64(5), ibcm.GetIngressSequence(ctx2, "srcchain"))
+	assert.Equal(t, int64(2), ibcm.getEgressLength(ctx2.KVStore(key), "destchain"))
+	assert.Equal(t, string(exported), string(ibcm.ExportGenesis(ctx2)))
+}
@@ -1,7 +1,9 @@
 package ibc
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	wire "github.com/cosmos/cosmos-sdk/wire"
@@ -54,6 +56,78 @@ func (ibcm IBCMapper) ReceiveIBCPacket(ctx sdk.Context, packet IBCPacket) sdk.Er
 	return nil
 }
 
+// InitGenesis stores the IBC state exported by ExportGenesis.
+// A missing genesis leaves the IBC store empty.
+func (ibcm IBCMapper) InitGenesis(ctx sdk.Context, data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var genesis IBCGenesis
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return err
+	}
+	for srcChain, sequence := range genesis.IngressSequences {
+		ibcm.SetIngressSequence(ctx, srcChain, sequence)
+	}
+	store := ctx.KVStore(ibcm.key)
+	for destChain, packets := range genesis.EgressPackets {
+		// chains without packets still have their length stored
+		ibcm.getEgressLength(store, destChain)
+		for _, packet := range packets {
+			if packet.DestChain != destChain {
+				return fmt.Errorf("egress packet for %s queued for %s", packet.DestChain, destChain)
+			}
+			if err := ibcm.PostIBCPacket(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ExportGenesis returns the IBC state in the format read by InitGenesis.
+func (ibcm IBCMapper) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	store := ctx.KVStore(ibcm.key)
+	genesis := IBCGenesis{
+		IngressSequences: make(map[string]int64),
+		EgressPackets:    make(map[string][]IBCPacket),
+	}
+
+	iter := store.Prefix(IngressSequenceKey("")).Iterator(nil, nil)
+	for ; iter.Valid(); iter.Next() {
+		var sequence int64
+		unmarshalBinaryPanic(ibcm.cdc, iter.Value(), &sequence)
+		genesis.IngressSequences[string(iter.Key())] = sequence
+	}
+	iter.Close()
+
+	// egress lengths are stored next to the packets; pick them out first
+	var destChains []string
+	iter = store.Prefix(EgressLengthKey("")).Iterator(nil, nil)
+	for ; iter.Valid(); iter.Next() {
+		destChain := string(iter.Key())
+		if !strings.Contains(destChain, "/") {
+			destChains = append(destChains, destChain)
+		}
+	}
+	iter.Close()
+
+	for _, destChain := range destChains {
+		length := ibcm.getEgressLength(store, destChain)
+		packets := make([]IBCPacket, length)
+		for i := int64(0); i < length; i++ {
+			unmarshalBinaryPanic(ibcm.cdc, store.Get(EgressKey(destChain, i)), &packets[i])
+		}
+		genesis.EgressPackets[destChain] = packets
+	}
+
+	bz, err := json.Marshal(genesis)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
 // --------------------------
 // Functions for accessing the underlying KVStore.
 
@@ -41,6 +41,17 @@ func (ibcp IBCPacket) ValidateBasic() sdk.Error {
 	return nil
 }
 
+// ----------------------------------
+// IBCGenesis
+
+// IBCGenesis is the IBC state carried over a chain restart:
+// the ingress sequence and the outgoing packets of every chain.
+// Chain IDs must not contain "/".
+type IBCGenesis struct {
+	IngressSequences map[string]int64       `json:"ingress_sequences"`
+	EgressPackets    map[string][]IBCPacket `json:"egress_packets"`
+}
+
 // ----------------------------------
 // IBCTransferMsg
 
@@ -50,7 +61,7 @@ type IBCTransferMsg struct {
 }
 
 func (msg IBCTransferMsg) Type() string {
-	return "ibc"
+	return "ibc/transfer"
 }
 
 func (msg IBCTransferMsg) Get(key interface{}) interface{} {
@@ -87,7 +98,7 @@ type IBCReceiveMsg struct {
 }
 
 func (msg IBCReceiveMsg) Type() string {
-	return "ibc"
+	return "ibc/receive"
 }
 
 func (msg IBCReceiveMsg) Get(key interface{}) interface{} {
@@ -37,7 +37,7 @@ func TestIBCTransferMsg(t *testing.T) {
 	packet := constructIBCPacket(true)
 	msg := IBCTransferMsg{packet}
 
-	assert.Equal(t, msg.Type(), "ibc")
+	assert.Equal(t, msg.Type(), "ibc/transfer")
 }
 
 func TestIBCTransferMsgValidation(t *testing.T) {
@@ -69,7 +69,7 @@ func TestIBCReceiveMsg(t *testing.T) {
 	packet := constructIBCPacket(true)
 	msg := IBCReceiveMsg{packet, sdk.Address([]byte("relayer")), 0}
 
-	assert.Equal(t, msg.Type(), "ibc")
+	assert.Equal(t, msg.Type(), "ibc/receive")
 }
 
 func TestIBCReceiveMsgValidation(t *testing.T) {
@@ -1,6 +1,8 @@
 package simplestake
 
 import (
+	"encoding/json"
+
 	crypto "github.com/tendermint/go-crypto"
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
@@ -28,6 +30,49 @@ func NewKeeper(key sdk.StoreKey, coinKeeper bank.CoinKeeper) Keeper {
 	}
 }
 
+// InitGenesis stores the bonds exported by ExportGenesis
+func (k Keeper) InitGenesis(ctx sdk.Context, data json.RawMessage) error {
+	if len(data) == 0 {
+		return nil
+	}
+	var bonds []GenesisBond
+	if err := json.Unmarshal(data, &bonds); err != nil {
+		return err
+	}
+	for _, bond := range bonds {
+		k.setBondInfo(ctx, bond.Address, bondInfo{
+			PubKey: bond.PubKey,
+			Power:  bond.Power,
+		})
+	}
+	return nil
+}
+
+// ExportGenesis returns all bonds, ordered by address
+func (k Keeper) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	store := ctx.KVStore(k.key)
+	iter := store.Iterator(nil, nil)
+	defer iter.Close()
+	bonds := []GenesisBond{}
+	for ; iter.Valid(); iter.Next() {
+		var bi bondInfo
+		err := k.cdc.UnmarshalBinary(iter.Value(), &bi)
+		if err != nil {
+			panic(err)
+		}
+		bonds = append(bonds, GenesisBond{
+			Address: iter.Key(),
+			PubKey:  bi.PubKey,
+			Power:   bi.Power,
+		})
+	}
+	bz, err := json.Marshal(bonds)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
 func (k Keeper) getBondInfo(ctx sdk.Context, addr sdk.Address) bondInfo {
 	store := ctx.KVStore(k.key)
 	bz := store.Get(addr)
@@ -56,12 +101,14 @@ func (k Keeper) deleteBondInfo(ctx sdk.Context, addr sdk.Address) {
 	store.Delete(addr)
 }
 
+// Bond delegates the stake of the addr, which may be locked in a vesting
+// account, and adds it to the power of its bond.
 func (k Keeper) Bond(ctx sdk.Context, addr sdk.Address, pubKey crypto.PubKey, stake sdk.Coin) (int64, sdk.Error) {
 	if stake.Denom != stakingToken {
 		return 0, ErrIncorrectStakingToken()
 	}
 
-	_, err := k.ck.SubtractCoins(ctx, addr, []sdk.Coin{stake})
+	_, err := k.ck.DelegateCoins(ctx, addr, []sdk.Coin{stake})
 	if err != nil {
 		return 0, err
 	}
@@ -80,6 +127,7 @@ func (k Keeper) Bond(ctx sdk.Context, addr sdk.Address, pubKey crypto.PubKey, st
 	return bi.Power, nil
 }
 
+// Unbond deletes the bond of the addr, and undelegates its stake.
 func (k Keeper) Unbond(ctx sdk.Context, addr sdk.Address) (crypto.PubKey, int64, sdk.Error) {
 	bi := k.getBondInfo(ctx, addr)
 	if bi.isEmpty() {
@@ -89,7 +137,7 @@ func (k Keeper) Unbond(ctx sdk.Context, addr sdk.Address) (crypto.PubKey, int64,
 
 	returnedBond := sdk.Coin{stakingToken, bi.Power}
 
-	_, err := k.ck.AddCoins(ctx, addr, []sdk.Coin{returnedBond})
+	_, err := k.ck.UndelegateCoins(ctx, addr, []sdk.Coin{returnedBond})
 	if err != nil {
 		return bi.PubKey, bi.Power, err
 	}
@@ -32,7 +32,7 @@ func TestKeeperGetSet(t *testing.T) {
 	ms, _, capKey := setupMultiStore()
 
 	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
-	stakeKeeper := NewKeeper(capKey, bank.NewCoinKeeper(nil))
+	stakeKeeper := NewKeeper(capKey, bank.NewCoinKeeper(nil, nil))
 	addr := sdk.Address([]byte("some-address"))
 
 	bi := stakeKeeper.getBondInfo(ctx, addr)
@@ -59,7 +59,7 @@ func TestBonding(t *testing.T) {
 	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
 
 	accountMapper := auth.NewAccountMapper(authKey, &auth.BaseAccount{})
-	coinKeeper := bank.NewCoinKeeper(accountMapper)
+	coinKeeper := bank.NewCoinKeeper(accountMapper, authKey)
 	stakeKeeper := NewKeeper(capKey, coinKeeper)
 	addr := sdk.Address([]byte("some-address"))
 	privKey := crypto.GenPrivKeyEd25519()
@@ -81,3 +81,24 @@ func TestBonding(t *testing.T) {
 	_, _, err = stakeKeeper.unbondWithoutCoins(ctx, addr)
 	assert.Equal(t, err, ErrInvalidUnbond())
 }
+
+func TestExportGenesis(t *testing.T) {
+	ms, _, capKey := setupMultiStore()
+
+	ctx := sdk.NewContext(ms, abci.Header{}, false, nil)
+	stakeKeeper := NewKeeper(capKey, bank.NewCoinKeeper(nil, nil))
+	addr := sdk.Address([]byte("some-address"))
+	pubKey := crypto.GenPrivKeyEd25519().PubKey()
+
+	_, err := stakeKeeper.bondWithoutCoins(ctx, addr, pubKey, sdk.Coin{"steak", 10})
+	assert.Nil(t, err)
+	exported := stakeKeeper.ExportGenesis(ctx)
+
+	ms2, _, capKey2 := setupMultiStore()
+	ctx2 := sdk.NewContext(ms2, abci.Header{}, false, nil)
+	stakeKeeper2 := NewKeeper(capKey2, bank.NewCoinKeeper(nil, nil))
+	assert.Nil(t, stakeKeeper2.InitGenesis(ctx2, exported))
+	bi := stakeKeeper2.getBondInfo(ctx2, addr)
+	assert.Equal(t, pubKey, bi.PubKey)
+	assert.Equal(t, int64(10), bi.Power)
+}
@@ -26,7 +26,7 @@ func NewBondMsg(addr sdk.Address, stake sdk.Coin, pubKey crypto.PubKey) BondMsg
 }
 
 func (msg BondMsg) Type() string {
-	return moduleName
+	return moduleName + "/bond"
 }
 
 func (msg BondMsg) ValidateBasic() sdk.Error {
@@ -71,7 +71,7 @@ func NewUnbondMsg(addr sdk.Address) UnbondMsg {
 }
 
 func (msg UnbondMsg) Type() string {
-	return moduleName
+	return moduleName + "/unbond"
 }
 
 func (msg UnbondMsg) ValidateBasic() sdk.Error {
@@ -1,6 +1,10 @@
 package simplestake
 
-import crypto "github.com/tendermint/go-crypto"
+import (
+	crypto "github.com/tendermint/go-crypto"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
 
 type bondInfo struct {
 	PubKey crypto.PubKey
@@ -13,3 +17,10 @@ func (bi bondInfo) isEmpty() bool {
 	}
 	return false
 }
+
+// GenesisBond is the bond of an address, as carried over genesis
+type GenesisBond struct {
+	Address sdk.Address   `json:"address"`
+	PubKey  crypto.PubKey `json:"pub_key"`
+	Power   int64         `json:"power"`
+}
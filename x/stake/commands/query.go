@@ -10,8 +10,11 @@ import (
 	"github.com/spf13/viper"
 
 	crypto "github.com/tendermint/go-crypto"
+	cmn "github.com/tendermint/tmlibs/common"
 
 	"github.com/cosmos/cosmos-sdk/client/context"
+	"github.com/cosmos/cosmos-sdk/client/core"
+	"github.com/cosmos/cosmos-sdk/store"
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire" // XXX fix
 	"github.com/cosmos/cosmos-sdk/x/stake"
@@ -31,6 +34,23 @@ var (
 	FlagDelegatorAddr = "delegator-address"
 )
 
+// querySubspace returns all the pairs of the store with keys with prefix,
+// following the pages of the result
+func querySubspace(ctx core.CoreContext, storeName string, prefix []byte) (pairs []cmn.KVPair, err error) {
+	query := store.SubspaceQuery{Prefix: prefix}
+	for {
+		result, err := ctx.QuerySubspace(storeName, query)
+		if err != nil {
+			return nil, err
+		}
+		pairs = append(pairs, result.Pairs...)
+		if len(result.NextKey) == 0 {
+			return pairs, nil
+		}
+		query.Start = result.NextKey
+	}
+}
+
 func init() {
 	//Add Flags
 	fsValAddr.String(FlagValidatorAddr, "", "Address of the validator/candidate")
@@ -45,19 +65,19 @@ func GetCmdQueryCandidates(cdc *wire.Codec, storeName string) *cobra.Command {
 		Short: "Query for the set of validator-candidates pubkeys",
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			key := PrefixedKey(stake.MsgType, stake.CandidatesKey)
-
 			ctx := context.NewCoreContextFromViper()
-			res, err := ctx.Query(key, storeName)
+			pairs, err := querySubspace(ctx, storeName, stake.CandidatesKey)
 			if err != nil {
 				return err
 			}
 
 			// parse out the candidates
-			candidates := new(stake.Candidates)
-			err = cdc.UnmarshalJSON(res, candidates)
-			if err != nil {
-				return err
+			candidates := make(stake.Candidates, len(pairs))
+			for i, pair := range pairs {
+				err = cdc.UnmarshalBinary(pair.Value, &candidates[i])
+				if err != nil {
+					return err
+				}
 			}
 			output, err := json.MarshalIndent(candidates, "", "  ")
 			if err != nil {
@@ -178,22 +198,21 @@ func GetCmdQueryDelegatorBonds(cdc *wire.Codec, storeName string) *cobra.Command
 			}
 			delegator := crypto.Address(bz)
 
-			key := PrefixedKey(stake.MsgType, stake.GetDelegatorBondsKey(delegator, cdc))
-
 			ctx := context.NewCoreContextFromViper()
-
-			res, err := ctx.Query(key, storeName)
+			pairs, err := querySubspace(ctx, storeName, stake.GetDelegatorBondsKey(delegator, cdc))
 			if err != nil {
 				return err
 			}
 
-			// parse out the candidates list
-			var candidates []crypto.PubKey
-			err = cdc.UnmarshalBinary(res, candidates)
-			if err != nil {
-				return err
+			// parse out the bonds
+			bonds := make([]stake.DelegatorBond, len(pairs))
+			for i, pair := range pairs {
+				err = cdc.UnmarshalBinary(pair.Value, &bonds[i])
+				if err != nil {
+					return err
+				}
 			}
-			output, err := json.MarshalIndent(candidates, "", "  ")
+			output, err := json.MarshalIndent(bonds, "", "  ")
 			if err != nil {
 				return err
 			}
@@ -229,6 +229,40 @@ func GetCmdUnbond(cdc *wire.Codec) *cobra.Command {
 	return cmd
 }
 
+// withdraw the fees credited to a bond
+func GetCmdWithdrawFees(cdc *wire.Codec) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "withdraw-fees",
+		Short: "withdraw the fees credited to the bond with a validator/candidate",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			delegatorAddr, err := sdk.GetAddress(viper.GetString(FlagAddressDelegator))
+			if err != nil {
+				return err
+			}
+			candidateAddr, err := sdk.GetAddress(viper.GetString(FlagAddressCandidate))
+			if err != nil {
+				return err
+			}
+
+			msg := stake.NewMsgWithdrawFees(delegatorAddr, candidateAddr)
+
+			// build and sign the transaction, then broadcast to Tendermint
+			ctx := context.NewCoreContextFromViper()
+			res, err := ctx.SignBuildBroadcast(ctx.FromAddressName, msg, cdc)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Committed at block %d. Hash: %s\n", res.Height, res.Hash.String())
+			return nil
+		},
+	}
+
+	cmd.Flags().AddFlagSet(fsDelegator)
+	cmd.Flags().String(FlagAddressCandidate, "", "hex address of the validator/candidate")
+	return cmd
+}
+
 //______________________________________________________________________________________
 
 // create the pubkey from a pubkey string
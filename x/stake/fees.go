@@ -0,0 +1,170 @@
+package stake
+
+import (
+	"math/big"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+// Fees collected by the ante handler are credited at the end of each block
+// to the validators and their delegators, and paid out lazily. The fees are
+// split between the validators in proportion to their voting power, and the
+// part of each validator is added to its FeesPerShare, the fees credited per
+// share of its delegator bonds, including its self-bond. So each block only
+// updates the validators, however many bonds there are.
+//
+// A bond is paid the fees credited to its shares since it was last paid
+// whenever its shares change, or with MsgWithdrawFees. Payments are liquid
+// coins credited to the delegator accounts: the fees were taken from
+// accounts, so the supply of the Pool stays the same. Until they are paid,
+// the fees credited stay in the fee pool, where the fees owed keep them
+// apart from the fees of the next blocks. Each validator owes its bonds the
+// whole coins it is credited, and the fees which aren't are credited in the
+// next blocks, as are the fees a validator still owes once it has no bonds.
+//
+// NOTE: only apps which run the stake module pay out the fee pool;
+// in the others, eg. basecoin and democoin, the fees accumulate in it.
+
+// credit the fees of the block to the bonds of the validators
+func (k Keeper) distributeFees(ctx sdk.Context) {
+	owed := k.getFeesOwed(ctx)
+	fees := k.feeCollectionKeeper.GetCollectedFees(ctx).Minus(owed)
+	if fees.IsZero() || !fees.IsNotNegative() {
+		return
+	}
+
+	// the candidates in the validator set, by power
+	candidates := k.getFeeCandidates(ctx)
+	totalPower := new(big.Rat)
+	for _, candidate := range candidates {
+		totalPower.Add(totalPower, candidate.Assets.GetRat())
+	}
+	if totalPower.Sign() == 0 {
+		return // keep the fees until there are validators
+	}
+
+	for _, candidate := range candidates {
+
+		// the part of the fees of the candidate, assets / totalPower,
+		// and the fees owed to each of its shares, times precision
+		part := mulCoins(fees, new(big.Rat).Quo(candidate.Assets.GetRat(), totalPower))
+		perShare := mulCoins(part, new(big.Rat).Quo(big.NewRat(precision, 1), candidate.Liabilities.GetRat()))
+		if perShare.IsZero() {
+			continue
+		}
+
+		// the candidate owes its part of the fees which are credited
+		credited := sdk.Coins{}
+		for _, coin := range part {
+			if perShare.AmountOf(coin.Denom) > 0 {
+				credited = append(credited, coin)
+			}
+		}
+		candidate.FeesPerShare = candidate.FeesPerShare.Plus(perShare)
+		candidate.FeesOwed = candidate.FeesOwed.Plus(credited)
+		k.setCandidate(ctx, candidate)
+		owed = owed.Plus(credited)
+	}
+	k.setFeesOwed(ctx, owed)
+}
+
+// get the candidates of the validator set which are credited fees,
+// from largest to smallest
+func (k Keeper) getFeeCandidates(ctx sdk.Context) (candidates []Candidate) {
+	store := ctx.KVStore(k.storeKey)
+	maxValidators := k.GetParams(ctx).MaxValidators
+
+	iterator := store.ReverseIterator(subspace(ValidatorsKey)) // largest to smallest
+	defer iterator.Close()
+	for i := 0; iterator.Valid() && i < int(maxValidators); iterator.Next() {
+		var validator Validator
+		err := k.cdc.UnmarshalBinary(iterator.Value(), &validator)
+		if err != nil {
+			panic(err)
+		}
+		i++
+
+		candidate, found := k.GetCandidate(ctx, validator.Address)
+		if !found || candidate.Status == Revoked ||
+			!candidate.Assets.GT(sdk.ZeroRat) || !candidate.Liabilities.GT(sdk.ZeroRat) {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
+// get the coins times the fraction, rounded down
+func mulCoins(coins sdk.Coins, fraction *big.Rat) sdk.Coins {
+	product := sdk.Coins{}
+	for _, coin := range coins {
+		amount := new(big.Rat).Mul(big.NewRat(coin.Amount, 1), fraction)
+		whole := new(big.Int).Quo(amount.Num(), amount.Denom())
+		if whole.Sign() > 0 {
+			product = append(product, sdk.Coin{coin.Denom, whole.Int64()})
+		}
+	}
+	return product
+}
+
+// get the fees owed to shares for the fees per share, rounded down
+func feePayment(shares sdk.Rat, perShare sdk.Coins) sdk.Coins {
+	payment := sdk.Coins{}
+	for _, coin := range perShare {
+		amount := new(big.Rat).Mul(shares.GetRat(), big.NewRat(coin.Amount, precision))
+		whole := new(big.Int).Quo(amount.Num(), amount.Denom())
+		if whole.Sign() > 0 {
+			payment = append(payment, sdk.Coin{coin.Denom, whole.Int64()})
+		}
+	}
+	return payment
+}
+
+// pay the bond the fees credited to its shares since it was last paid,
+// and return it paid up to the FeesPerShare of its candidate, and the
+// candidate which owes the payment no more, for the caller to save
+func (k Keeper) payBondFees(ctx sdk.Context, bond DelegatorBond, candidate Candidate) (DelegatorBond, Candidate, sdk.Error) {
+	payment := feePayment(bond.Shares, candidate.FeesPerShare.Minus(bond.FeesPerShare))
+	bond.FeesPerShare = candidate.FeesPerShare
+	if payment.IsZero() {
+		return bond, candidate, nil
+	}
+	_, err := k.coinKeeper.PayFees(ctx, bond.DelegatorAddr, payment)
+	if err != nil {
+		return bond, candidate, err
+	}
+	candidate.FeesOwed = candidate.FeesOwed.Minus(payment)
+	k.setFeesOwed(ctx, k.getFeesOwed(ctx).Minus(payment))
+	fees := k.feeCollectionKeeper.GetCollectedFees(ctx)
+	k.feeCollectionKeeper.ClearCollectedFees(ctx)
+	k.feeCollectionKeeper.AddCollectedFees(ctx, fees.Minus(payment))
+	return bond, candidate, nil
+}
+
+// get the fees credited to the bonds which they weren't paid yet
+func (k Keeper) getFeesOwed(ctx sdk.Context) sdk.Coins {
+	store := ctx.KVStore(k.storeKey)
+	bz := store.Get(FeesOwedKey)
+	if bz == nil {
+		return sdk.Coins{}
+	}
+	var owed sdk.Coins
+	err := k.cdc.UnmarshalBinary(bz, &owed)
+	if err != nil {
+		panic(err)
+	}
+	return owed
+}
+
+func (k Keeper) setFeesOwed(ctx sdk.Context, owed sdk.Coins) {
+	store := ctx.KVStore(k.storeKey)
+	if owed.IsZero() {
+		store.Delete(FeesOwedKey)
+		return
+	}
+	bz, err := k.cdc.MarshalBinary(owed)
+	if err != nil {
+		panic(err)
+	}
+	store.Set(FeesOwedKey, bz)
+}
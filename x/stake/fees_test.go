@@ -0,0 +1,159 @@
+package stake
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/cosmos/cosmos-sdk/types"
+)
+
+func TestDistributeFees(t *testing.T) {
+	ctx, accMapper, keeper := createTestInput(t, false, 1000)
+	fck := keeper.feeCollectionKeeper
+
+	// no validators, the fees stay in the fee pool
+	fck.AddCollectedFees(ctx, sdk.Coins{{"fermion", 1000}, {"photon", 7}})
+	keeper.Tick(ctx)
+	assert.Equal(t, sdk.Coins{{"fermion", 1000}, {"photon", 7}}, fck.GetCollectedFees(ctx))
+	assert.True(t, keeper.getFeesOwed(ctx).IsZero())
+
+	// two candidates with a power of 400 and 100,
+	// a fourth of the first one's power is delegated
+	supply := keeper.coinKeeper.GetSupply(ctx)
+	got := handleMsgDeclareCandidacy(ctx, newTestMsgDeclareCandidacy(addrs[0], pks[0], 300), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	got = handleMsgDelegate(ctx, newTestMsgDelegate(addrs[2], addrs[0], 100), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	got = handleMsgDeclareCandidacy(ctx, newTestMsgDeclareCandidacy(addrs[1], pks[1], 100), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	assert.Equal(t, sdk.Coins{{"fermion", 500}}, keeper.coinKeeper.GetDelegated(ctx))
+
+	coinsOf := func(i int) sdk.Coins {
+		return accMapper.GetAccount(ctx, addrs[i]).GetCoins()
+	}
+
+	// the fees are credited to the bonds, not paid yet, in whole coins:
+	// the photon left is credited in the next blocks
+	keeper.Tick(ctx)
+	assert.Equal(t, sdk.Coins{{"fermion", 700}}, coinsOf(0))
+	assert.Equal(t, sdk.Coins{{"fermion", 1000}, {"photon", 6}}, keeper.getFeesOwed(ctx))
+	keeper.Tick(ctx)
+	assert.Equal(t, sdk.Coins{{"fermion", 1000}, {"photon", 6}}, keeper.getFeesOwed(ctx))
+	candidate, found := keeper.GetCandidate(ctx, addrs[0])
+	require.True(t, found)
+	assert.Equal(t, sdk.Coins{{"fermion", 800}, {"photon", 5}}, candidate.FeesOwed)
+
+	withdraw := func(delegator, candidate int) {
+		got := handleMsgWithdrawFees(ctx, NewMsgWithdrawFees(addrs[delegator], addrs[candidate]), keeper)
+		require.True(t, got.IsOK(), "%v", got)
+	}
+	withdraw(0, 0)
+	withdraw(2, 0)
+	withdraw(1, 1)
+	withdraw(1, 1) // paid already
+
+	// 80% of the fees for the first candidate, shared 3:1 with its delegator
+	assert.Equal(t, sdk.Coins{{"fermion", 700 + 600}, {"photon", 3}}, coinsOf(0))
+	assert.Equal(t, sdk.Coins{{"fermion", 900 + 200}, {"photon", 1}}, coinsOf(2))
+	// 20% for the second candidate
+	assert.Equal(t, sdk.Coins{{"fermion", 900 + 200}, {"photon", 1}}, coinsOf(1))
+
+	// the remainder stays in the fee pool, where the first candidate
+	// owes the photon its bonds can't be paid in whole coins
+	assert.Equal(t, sdk.Coins{{"photon", 2}}, fck.GetCollectedFees(ctx))
+	assert.Equal(t, sdk.Coins{{"photon", 1}}, keeper.getFeesOwed(ctx))
+	candidate, found = keeper.GetCandidate(ctx, addrs[0])
+	require.True(t, found)
+	assert.Equal(t, sdk.Coins{{"photon", 1}}, candidate.FeesOwed)
+
+	// the supply of coins and the bonded supply are unchanged: the
+	// delegated coins stay in it, and the fees were paid out of the fee pool
+	assert.Equal(t, supply, keeper.coinKeeper.GetSupply(ctx))
+	pool := keeper.GetPool(ctx)
+	assert.Equal(t, int64(500), pool.BondedPool+pool.UnbondedPool)
+
+	// the fees of the current shares are paid before they change
+	fck.AddCollectedFees(ctx, sdk.Coins{{"fermion", 100}})
+	keeper.Tick(ctx)
+	got = handleMsgDelegate(ctx, newTestMsgDelegate(addrs[2], addrs[0], 100), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	assert.Equal(t, sdk.Coins{{"fermion", 1100 + 20 - 100}, {"photon", 1}}, coinsOf(2))
+	got = handleMsgUnbond(ctx, NewMsgUnbond(addrs[1], addrs[1], "50"), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	assert.Equal(t, sdk.Coins{{"fermion", 1100 + 20 + 50}, {"photon", 1}}, coinsOf(1))
+	assert.Equal(t, sdk.Coins{{"fermion", 60}, {"photon", 1}}, keeper.getFeesOwed(ctx))
+}
+
+func TestDistributeFeesRevoked(t *testing.T) {
+	ctx, accMapper, keeper := createTestInput(t, false, 1000)
+	fck := keeper.feeCollectionKeeper
+
+	got := handleMsgDeclareCandidacy(ctx, newTestMsgDeclareCandidacy(addrs[0], pks[0], 100), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	got = handleMsgDeclareCandidacy(ctx, newTestMsgDeclareCandidacy(addrs[1], pks[1], 100), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	got = handleMsgDelegate(ctx, newTestMsgDelegate(addrs[2], addrs[1], 100), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+
+	// the owner of the second candidate unbonds, revoking it
+	got = handleMsgUnbond(ctx, NewMsgUnbond(addrs[1], addrs[1], "100"), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	candidate, found := keeper.GetCandidate(ctx, addrs[1])
+	require.True(t, found)
+	require.Equal(t, Revoked, candidate.Status)
+
+	// revoked candidates are not credited
+	fck.AddCollectedFees(ctx, sdk.Coins{{"fermion", 100}})
+	keeper.Tick(ctx)
+	got = handleMsgWithdrawFees(ctx, NewMsgWithdrawFees(addrs[0], addrs[0]), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	got = handleMsgWithdrawFees(ctx, NewMsgWithdrawFees(addrs[2], addrs[1]), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	assert.Equal(t, sdk.Coins{{"fermion", 1000}}, accMapper.GetAccount(ctx, addrs[0]).GetCoins())
+	assert.Equal(t, sdk.Coins{{"fermion", 900}}, accMapper.GetAccount(ctx, addrs[2]).GetCoins())
+	assert.True(t, fck.GetCollectedFees(ctx).IsZero())
+
+	// nor are those without a bond
+	got = handleMsgWithdrawFees(ctx, NewMsgWithdrawFees(addrs[2], addrs[0]), keeper)
+	assert.Equal(t, ErrNoDelegatorForAddress().ABCICode(), got.Code)
+}
+
+func TestDistributeFeesRemoved(t *testing.T) {
+	ctx, accMapper, keeper := createTestInput(t, false, 1000)
+	fck := keeper.feeCollectionKeeper
+
+	got := handleMsgDeclareCandidacy(ctx, newTestMsgDeclareCandidacy(addrs[0], pks[0], 100), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	got = handleMsgDeclareCandidacy(ctx, newTestMsgDeclareCandidacy(addrs[1], pks[1], 200), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	got = handleMsgDelegate(ctx, newTestMsgDelegate(addrs[2], addrs[1], 100), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+
+	// the second candidate is credited 2 photons, which its 300 shares
+	// can't be paid in whole coins; the photon left stays in the fee pool
+	fck.AddCollectedFees(ctx, sdk.Coins{{"photon", 3}})
+	keeper.Tick(ctx)
+	assert.Equal(t, sdk.Coins{{"photon", 2}}, keeper.getFeesOwed(ctx))
+
+	// its bonds are paid a photon as they unbond, removing it
+	got = handleMsgUnbond(ctx, NewMsgUnbond(addrs[2], addrs[1], "100"), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	got = handleMsgUnbond(ctx, NewMsgUnbond(addrs[1], addrs[1], "200"), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	_, found := keeper.GetCandidate(ctx, addrs[1])
+	require.False(t, found)
+	assert.Equal(t, sdk.Coins{{"fermion", 1000}, {"photon", 1}}, accMapper.GetAccount(ctx, addrs[1]).GetCoins())
+	assert.Equal(t, sdk.Coins{{"fermion", 1000}}, accMapper.GetAccount(ctx, addrs[2]).GetCoins())
+
+	// the photon it still owed is credited in the next block, with the other
+	assert.True(t, keeper.getFeesOwed(ctx).IsZero())
+	keeper.Tick(ctx)
+	assert.Equal(t, sdk.Coins{{"photon", 2}}, keeper.getFeesOwed(ctx))
+	got = handleMsgWithdrawFees(ctx, NewMsgWithdrawFees(addrs[0], addrs[0]), keeper)
+	require.True(t, got.IsOK(), "%v", got)
+	assert.Equal(t, sdk.Coins{{"fermion", 900}, {"photon", 2}}, accMapper.GetAccount(ctx, addrs[0]).GetCoins())
+	assert.True(t, keeper.getFeesOwed(ctx).IsZero())
+	assert.True(t, fck.GetCollectedFees(ctx).IsZero())
+}
@@ -14,6 +14,7 @@ const (
 	GasEditCandidacy    int64 = 20
 	GasDelegate         int64 = 20
 	GasUnbond           int64 = 20
+	GasWithdrawFees     int64 = 20
 )
 
 //_______________________________________________________________________
@@ -30,6 +31,8 @@ func NewHandler(k Keeper, ck bank.CoinKeeper) sdk.Handler {
 			return handleMsgDelegate(ctx, msg, k)
 		case MsgUnbond:
 			return handleMsgUnbond(ctx, msg, k)
+		case MsgWithdrawFees:
+			return handleMsgWithdrawFees(ctx, msg, k)
 		default:
 			return sdk.ErrTxDecode("invalid message parse in staking module").Result()
 		}
@@ -145,9 +148,15 @@ func delegate(ctx sdk.Context, k Keeper, delegatorAddr sdk.Address,
 		}
 	}
 
+	// Pay the fees of the current shares, before adding to them
+	bond, candidate, err := k.payBondFees(ctx, bond, candidate)
+	if err != nil {
+		return err
+	}
+
 	// Account new shares, save
 	pool := k.GetPool(ctx)
-	_, err := k.coinKeeper.SubtractCoins(ctx, bond.DelegatorAddr, sdk.Coins{bondAmt})
+	_, err = k.coinKeeper.DelegateCoins(ctx, bond.DelegatorAddr, sdk.Coins{bondAmt})
 	if err != nil {
 		return err
 	}
@@ -200,6 +209,12 @@ func handleMsgUnbond(ctx sdk.Context, msg MsgUnbond, k Keeper) sdk.Result {
 		}
 	}
 
+	// pay the fees of the current shares, before removing some
+	bond, candidate, err = k.payBondFees(ctx, bond, candidate)
+	if err != nil {
+		return err.Result()
+	}
+
 	// retrieve the amount of bonds to remove (TODO remove redundancy already serialized)
 	if msg.Shares == "MAX" {
 		shares = bond.Shares
@@ -228,7 +243,7 @@ func handleMsgUnbond(ctx sdk.Context, msg MsgUnbond, k Keeper) sdk.Result {
 	p := k.GetPool(ctx)
 	p, candidate, returnAmount := p.candidateRemoveShares(candidate, shares)
 	returnCoins := sdk.Coins{{k.GetParams(ctx).BondDenom, returnAmount}}
-	k.coinKeeper.AddCoins(ctx, bond.DelegatorAddr, returnCoins)
+	k.coinKeeper.UndelegateCoins(ctx, bond.DelegatorAddr, returnCoins)
 
 	/////////////////////////////////////
 
@@ -246,6 +261,9 @@ func handleMsgUnbond(ctx sdk.Context, msg MsgUnbond, k Keeper) sdk.Result {
 
 	// deduct shares from the candidate
 	if candidate.Liabilities.IsZero() {
+		// the bonds were all paid, so the fees the candidate still owes
+		// are credited in the next blocks
+		k.setFeesOwed(ctx, k.getFeesOwed(ctx).Minus(candidate.FeesOwed))
 		k.removeCandidate(ctx, candidate.Address)
 	} else {
 		k.setCandidate(ctx, candidate)
@@ -254,6 +272,31 @@ func handleMsgUnbond(ctx sdk.Context, msg MsgUnbond, k Keeper) sdk.Result {
 	return sdk.Result{}
 }
 
+func handleMsgWithdrawFees(ctx sdk.Context, msg MsgWithdrawFees, k Keeper) sdk.Result {
+
+	bond, found := k.getDelegatorBond(ctx, msg.DelegatorAddr, msg.CandidateAddr)
+	if !found {
+		return ErrNoDelegatorForAddress().Result()
+	}
+	candidate, found := k.GetCandidate(ctx, msg.CandidateAddr)
+	if !found {
+		return ErrNoCandidateForAddress().Result()
+	}
+	if ctx.IsCheckTx() {
+		return sdk.Result{
+			GasUsed: GasWithdrawFees,
+		}
+	}
+
+	bond, candidate, err := k.payBondFees(ctx, bond, candidate)
+	if err != nil {
+		return err.Result()
+	}
+	k.setDelegatorBond(ctx, bond)
+	k.setCandidate(ctx, candidate)
+	return sdk.Result{}
+}
+
 // TODO use or remove
 //// Perform all the actions required to bond tokens to a delegator bond from their account
 //func BondCoins(ctx sdk.Context, k Keeper, bond DelegatorBond,
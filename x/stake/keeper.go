@@ -6,26 +6,34 @@ import (
 
 	sdk "github.com/cosmos/cosmos-sdk/types"
 	"github.com/cosmos/cosmos-sdk/wire"
+	"github.com/cosmos/cosmos-sdk/x/auth"
 	"github.com/cosmos/cosmos-sdk/x/bank"
 	abci "github.com/tendermint/abci/types"
 )
 
 // keeper of the staking store
 type Keeper struct {
-	storeKey   sdk.StoreKey
-	cdc        *wire.Codec
-	coinKeeper bank.CoinKeeper
+	storeKey            sdk.StoreKey
+	transientKey        sdk.StoreKey // store of the data of the block, eg. validator updates
+	cdc                 *wire.Codec
+	coinKeeper          bank.CoinKeeper
+	feeCollectionKeeper auth.FeeCollectionKeeper
 
 	// caches
 	gs     Pool
 	params Params
 }
 
-func NewKeeper(ctx sdk.Context, cdc *wire.Codec, key sdk.StoreKey, ck bank.CoinKeeper) Keeper {
+// The store of tkey must be a transient store.
+func NewKeeper(ctx sdk.Context, cdc *wire.Codec, key, tkey sdk.StoreKey,
+	ck bank.CoinKeeper, fck auth.FeeCollectionKeeper) Keeper {
+
 	keeper := Keeper{
-		storeKey:   key,
-		cdc:        cdc,
-		coinKeeper: ck,
+		storeKey:            key,
+		transientKey:        tkey,
+		cdc:                 cdc,
+		coinKeeper:          ck,
+		feeCollectionKeeper: fck,
 	}
 	return keeper
 }
@@ -38,9 +46,32 @@ func (k Keeper) InitGenesis(ctx sdk.Context, data json.RawMessage) error {
 	}
 	k.setPool(ctx, state.Pool)
 	k.setParams(ctx, state.Params)
+	for _, candidate := range state.Candidates {
+		k.setCandidate(ctx, candidate)
+	}
+	for _, bond := range state.Bonds {
+		k.setDelegatorBond(ctx, bond)
+	}
+	k.setFeesOwed(ctx, state.FeesOwed)
 	return nil
 }
 
+// ExportGenesis - export the staking state in the format read by InitGenesis
+func (k Keeper) ExportGenesis(ctx sdk.Context) json.RawMessage {
+	state := GenesisState{
+		Pool:       k.GetPool(ctx),
+		Params:     k.GetParams(ctx),
+		Candidates: k.getAllCandidates(ctx),
+		Bonds:      k.getAllDelegatorBonds(ctx),
+		FeesOwed:   k.getFeesOwed(ctx),
+	}
+	bz, err := json.Marshal(state)
+	if err != nil {
+		panic(err)
+	}
+	return bz
+}
+
 //_________________________________________________________________________
 
 // get a single candidate
@@ -81,6 +112,22 @@ func (k Keeper) GetCandidates(ctx sdk.Context, maxRetrieve int16) (candidates Ca
 	return candidates[:i] // trim
 }
 
+// get the set of all candidates, ordered by address
+func (k Keeper) getAllCandidates(ctx sdk.Context) (candidates Candidates) {
+	store := ctx.KVStore(k.storeKey)
+	iterator := store.Iterator(subspace(CandidatesKey))
+	defer iterator.Close()
+	for ; iterator.Valid(); iterator.Next() {
+		var candidate Candidate
+		err := k.cdc.UnmarshalBinary(iterator.Value(), &candidate)
+		if err != nil {
+			panic(err)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
+
 func (k Keeper) setCandidate(ctx sdk.Context, candidate Candidate) {
 	store := ctx.KVStore(k.storeKey)
 	address := candidate.Address
@@ -128,7 +175,8 @@ func (k Keeper) setCandidate(ctx sdk.Context, candidate Candidate) {
 		if err != nil {
 			panic(err)
 		}
-		store.Set(GetAccUpdateValidatorKey(validator.Address), bz)
+		tstore := ctx.KVStore(k.transientKey)
+		tstore.Set(GetAccUpdateValidatorKey(validator.Address), bz)
 	}
 	return
 }
@@ -155,7 +203,8 @@ func (k Keeper) removeCandidate(ctx sdk.Context, address sdk.Address) {
 	if err != nil {
 		panic(err)
 	}
-	store.Set(GetAccUpdateValidatorKey(address), bz)
+	tstore := ctx.KVStore(k.transientKey)
+	tstore.Set(GetAccUpdateValidatorKey(address), bz)
 	store.Delete(GetRecentValidatorKey(address))
 }
 
@@ -209,6 +258,7 @@ func (k Keeper) GetValidators(ctx sdk.Context) (validators []Validator) {
 	}
 
 	// add any kicked out validators to the acc change
+	tstore := ctx.KVStore(k.transientKey)
 	iterator = store.Iterator(subspace(ToKickOutValidatorsKey))
 	for ; iterator.Valid(); iterator.Next() {
 		key := iterator.Key()
@@ -226,7 +276,7 @@ func (k Keeper) GetValidators(ctx sdk.Context) (validators []Validator) {
 			panic(err)
 		}
 
-		store.Set(GetAccUpdateValidatorKey(addr), bz)
+		tstore.Set(GetAccUpdateValidatorKey(addr), bz)
 		store.Delete(key)
 	}
 	iterator.Close()
@@ -241,9 +291,9 @@ func (k Keeper) isNewValidator(ctx sdk.Context, store sdk.KVStore, address sdk.A
 	// add the actual validator power sorted store
 	maxVal := k.GetParams(ctx).MaxValidators
 	iterator := store.ReverseIterator(subspace(ValidatorsKey)) // largest to smallest
+	defer iterator.Close()
 	for i := 0; ; i++ {
 		if !iterator.Valid() || i > int(maxVal-1) {
-			iterator.Close()
 			break
 		}
 		bz := iterator.Value()
@@ -271,11 +321,12 @@ func (k Keeper) IsRecentValidator(ctx sdk.Context, address sdk.Address) bool {
 }
 
 //_________________________________________________________________________
-// Accumulated updates to the validator set
+// Accumulated updates to the validator set, kept in the transient
+// store so they are cleared at the end of each block
 
 // get the most recently updated validators
 func (k Keeper) getAccUpdateValidators(ctx sdk.Context) (updates []abci.Validator) {
-	store := ctx.KVStore(k.storeKey)
+	store := ctx.KVStore(k.transientKey)
 
 	iterator := store.Iterator(subspace(AccUpdateValidatorsKey)) //smallest to largest
 	for ; iterator.Valid(); iterator.Next() {
@@ -291,18 +342,6 @@ func (k Keeper) getAccUpdateValidators(ctx sdk.Context) (updates []abci.Validato
 	return
 }
 
-// remove all validator update entries
-func (k Keeper) clearAccUpdateValidators(ctx sdk.Context) {
-	store := ctx.KVStore(k.storeKey)
-
-	// delete subspace
-	iterator := store.Iterator(subspace(AccUpdateValidatorsKey))
-	for ; iterator.Valid(); iterator.Next() {
-		store.Delete(iterator.Key())
-	}
-	iterator.Close()
-}
-
 //_____________________________________________________________________
 
 func (k Keeper) getDelegatorBond(ctx sdk.Context,
@@ -346,6 +385,22 @@ func (k Keeper) getDelegatorBonds(ctx sdk.Context, delegator sdk.Address, maxRet
 	return bonds[:i] // trim
 }
 
+// load the bonds of all delegators
+func (k Keeper) getAllDelegatorBonds(ctx sdk.Context) (bonds []DelegatorBond) {
+	store := ctx.KVStore(k.storeKey)
+	iterator := store.Iterator(subspace(DelegatorBondKeyPrefix))
+	defer iterator.Close()
+	for ; iterator.Valid(); iterator.Next() {
+		var bond DelegatorBond
+		err := k.cdc.UnmarshalBinary(iterator.Value(), &bond)
+		if err != nil {
+			panic(err)
+		}
+		bonds = append(bonds, bond)
+	}
+	return bonds
+}
+
 func (k Keeper) setDelegatorBond(ctx sdk.Context, bond DelegatorBond) {
 	store := ctx.KVStore(k.storeKey)
 	b, err := k.cdc.MarshalBinary(bond)
@@ -14,12 +14,14 @@ var (
 	PoolKey                = []byte{0x01} // key for global parameters relating to staking
 	CandidatesKey          = []byte{0x02} // prefix for each key to a candidate
 	ValidatorsKey          = []byte{0x03} // prefix for each key to a validator
-	AccUpdateValidatorsKey = []byte{0x04} // prefix for each key to a validator which is being updated
+	AccUpdateValidatorsKey = []byte{0x04} // prefix for each key to a validator which is being updated, in the transient store
 	RecentValidatorsKey    = []byte{0x05} // prefix for each key to the last updated validator group
 
 	ToKickOutValidatorsKey = []byte{0x06} // prefix for each key to the last updated validator group
 
 	DelegatorBondKeyPrefix = []byte{0x07} // prefix for each key to a delegator's bond
+
+	FeesOwedKey = []byte{0x08} // key for the fees credited to the bonds which they weren't paid yet
 )
 
 const maxDigitsForAccount = 12 // ~220,000,000 atoms created at launch
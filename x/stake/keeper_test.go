@@ -147,11 +147,11 @@ func TestBond(t *testing.T) {
 	// add some more records
 	keeper.setCandidate(ctx, candidates[1])
 	keeper.setCandidate(ctx, candidates[2])
-	bond1to2 := DelegatorBond{addrDels[0], addrVals[1], sdk.NewRat(9)}
-	bond1to3 := DelegatorBond{addrDels[0], addrVals[2], sdk.NewRat(9)}
-	bond2to1 := DelegatorBond{addrDels[1], addrVals[0], sdk.NewRat(9)}
-	bond2to2 := DelegatorBond{addrDels[1], addrVals[1], sdk.NewRat(9)}
-	bond2to3 := DelegatorBond{addrDels[1], addrVals[2], sdk.NewRat(9)}
+	bond1to2 := DelegatorBond{DelegatorAddr: addrDels[0], CandidateAddr: addrVals[1], Shares: sdk.NewRat(9)}
+	bond1to3 := DelegatorBond{DelegatorAddr: addrDels[0], CandidateAddr: addrVals[2], Shares: sdk.NewRat(9)}
+	bond2to1 := DelegatorBond{DelegatorAddr: addrDels[1], CandidateAddr: addrVals[0], Shares: sdk.NewRat(9)}
+	bond2to2 := DelegatorBond{DelegatorAddr: addrDels[1], CandidateAddr: addrVals[1], Shares: sdk.NewRat(9)}
+	bond2to3 := DelegatorBond{DelegatorAddr: addrDels[1], CandidateAddr: addrVals[2], Shares: sdk.NewRat(9)}
 	keeper.setDelegatorBond(ctx, bond1to2)
 	keeper.setDelegatorBond(ctx, bond1to3)
 	keeper.setDelegatorBond(ctx, bond2to1)
@@ -288,9 +288,13 @@ func TestClearAccUpdateValidators(t *testing.T) {
 
 	acc := keeper.getAccUpdateValidators(ctx)
 	assert.Equal(t, len(amts), len(acc))
-	keeper.clearAccUpdateValidators(ctx)
+
+	// the transient store is cleared at commit, unlike the candidates
+	commitTestInput(ctx)
 	acc = keeper.getAccUpdateValidators(ctx)
 	assert.Equal(t, 0, len(acc))
+	_, found := keeper.GetCandidate(ctx, addrs[0])
+	assert.True(t, found)
 }
 
 // test the mechanism which keeps track of a validator set change
@@ -350,7 +354,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	// test identical,
 	//  candidate set: {c1, c3} -> {c1, c3}
 	//  accUpdate set: {} -> {}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 2, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
 
@@ -363,7 +367,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	// test single value change
 	//  candidate set: {c1, c3} -> {c1', c3}
 	//  accUpdate set: {} -> {c1'}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 2, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
 
@@ -380,7 +384,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	// test multiple value change
 	//  candidate set: {c1, c3} -> {c1', c3'}
 	//  accUpdate set: {c1, c3} -> {c1', c3'}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 2, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
 
@@ -399,7 +403,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	// test validtor added at the beginning
 	//  candidate set: {c1, c3} -> {c0, c1, c3}
 	//  accUpdate set: {} -> {c0}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 2, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
 
@@ -413,7 +417,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	// test validator added at the middle
 	//  candidate set: {c0, c1, c3} -> {c0, c1, c2, c3]
 	//  accUpdate set: {} -> {c2}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 3, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
 
@@ -428,7 +432,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	//  candidate set: {c0, c1, c2, c3} -> {c0, c1, c2, c3, c4}
 	//  validator set: {c0, c1, c2, c3} -> {c0, c1, c2, c3}
 	//  accUpdate set: {} -> {}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 4, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 4, len(keeper.GetValidators(ctx)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
@@ -443,7 +447,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	//  candidate set: {c0, c1, c2, c3, c4} -> {c0, c1, c2, c3, c4}
 	//  validator set: {c0, c1, c2, c3}     -> {c0, c1, c2, c3}
 	//  accUpdate set: {}     -> {}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 5, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 4, len(keeper.GetValidators(ctx)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
@@ -459,7 +463,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	//  candidate set: {c0, c1, c2, c3, c4} -> {c0, c1, c2, c3, c4}
 	//  validator set: {c0, c1, c2, c3}     -> {c1, c2, c3, c4}
 	//  accUpdate set: {}     -> {c0, c4}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 5, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 4, len(keeper.GetValidators(ctx)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
@@ -487,7 +491,7 @@ func TestGetAccUpdateValidators(t *testing.T) {
 	//  candidate set: {c0, c1, c2, c3, c4} -> {}
 	//  validator set: {c1, c2, c3, c4}  -> {}
 	//  accUpdate set: {} -> {c1, c2, c3, c4}
-	keeper.clearAccUpdateValidators(ctx)
+	commitTestInput(ctx)
 	assert.Equal(t, 5, len(keeper.GetCandidates(ctx, 5)))
 	assert.Equal(t, 4, len(keeper.GetValidators(ctx)))
 	assert.Equal(t, 0, len(keeper.getAccUpdateValidators(ctx)))
@@ -612,3 +616,47 @@ func TestInitGenesis(t *testing.T) {
 	require.Equal(t, keeper.GetPool(ctx), initialPool())
 	require.Equal(t, keeper.GetParams(ctx), defaultParams())
 }
+
+func TestExportGenesis(t *testing.T) {
+	ctx, _, keeper := createTestInput(t, false, 0)
+
+	// add some candidates and bonds to the default genesis state
+	amts := []int64{9, 8, 7}
+	for i, amt := range amts {
+		keeper.setCandidate(ctx, Candidate{
+			Status:      Bonded,
+			Address:     addrVals[i],
+			PubKey:      pks[i],
+			Assets:      sdk.NewRat(amt),
+			Liabilities: sdk.NewRat(amt),
+		})
+		keeper.setDelegatorBond(ctx, DelegatorBond{
+			DelegatorAddr: addrDels[i%2],
+			CandidateAddr: addrVals[i],
+			Shares:        sdk.NewRat(amt),
+		})
+	}
+	exported := keeper.ExportGenesis(ctx)
+
+	var state GenesisState
+	err := json.Unmarshal(exported, &state)
+	require.Nil(t, err)
+	assert.Equal(t, initialPool(), state.Pool)
+	assert.Equal(t, defaultParams(), state.Params)
+	assert.Len(t, state.Candidates, 3)
+	assert.Len(t, state.Bonds, 3)
+
+	// the exported state initializes an identical keeper
+	ctx2, _, keeper2 := createTestInput(t, false, 0)
+	err = keeper2.InitGenesis(ctx2, exported)
+	require.Nil(t, err)
+	for i := range amts {
+		candidate, found := keeper2.GetCandidate(ctx2, addrVals[i])
+		require.True(t, found)
+		assert.Equal(t, pks[i], candidate.PubKey)
+		assert.Equal(t, sdk.NewRat(amts[i]), candidate.Assets)
+		_, found = keeper2.getDelegatorBond(ctx2, addrDels[i%2], addrVals[i])
+		assert.True(t, found)
+	}
+	assert.Equal(t, string(exported), string(keeper2.ExportGenesis(ctx2)))
+}
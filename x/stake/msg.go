@@ -18,7 +18,7 @@ const MsgType = "stake"
 const StakingToken = "fermion"
 
 //Verify interface at compile time
-var _, _, _, _ sdk.Msg = &MsgDeclareCandidacy{}, &MsgEditCandidacy{}, &MsgDelegate{}, &MsgUnbond{}
+var _, _, _, _, _ sdk.Msg = &MsgDeclareCandidacy{}, &MsgEditCandidacy{}, &MsgDelegate{}, &MsgUnbond{}, &MsgWithdrawFees{}
 
 //______________________________________________________________________
 
@@ -41,7 +41,7 @@ func NewMsgDeclareCandidacy(candidateAddr sdk.Address, pubkey crypto.PubKey,
 }
 
 //nolint
-func (msg MsgDeclareCandidacy) Type() string                            { return MsgType } //TODO update "stake/declarecandidacy"
+func (msg MsgDeclareCandidacy) Type() string                            { return MsgType + "/declarecandidacy" }
 func (msg MsgDeclareCandidacy) Get(key interface{}) (value interface{}) { return nil }
 func (msg MsgDeclareCandidacy) GetSigners() []sdk.Address               { return []sdk.Address{msg.CandidateAddr} }
 func (msg MsgDeclareCandidacy) String() string {
@@ -92,7 +92,7 @@ func NewMsgEditCandidacy(candidateAddr sdk.Address, description Description) Msg
 }
 
 //nolint
-func (msg MsgEditCandidacy) Type() string                            { return MsgType } //TODO update "stake/msgeditcandidacy"
+func (msg MsgEditCandidacy) Type() string                            { return MsgType + "/editcandidacy" }
 func (msg MsgEditCandidacy) Get(key interface{}) (value interface{}) { return nil }
 func (msg MsgEditCandidacy) GetSigners() []sdk.Address               { return []sdk.Address{msg.CandidateAddr} }
 func (msg MsgEditCandidacy) String() string {
@@ -138,7 +138,7 @@ func NewMsgDelegate(delegatorAddr, candidateAddr sdk.Address, bond sdk.Coin) Msg
 }
 
 //nolint
-func (msg MsgDelegate) Type() string                            { return MsgType } //TODO update "stake/msgeditcandidacy"
+func (msg MsgDelegate) Type() string                            { return MsgType + "/delegate" }
 func (msg MsgDelegate) Get(key interface{}) (value interface{}) { return nil }
 func (msg MsgDelegate) GetSigners() []sdk.Address               { return []sdk.Address{msg.DelegatorAddr} }
 func (msg MsgDelegate) String() string {
@@ -190,7 +190,7 @@ func NewMsgUnbond(delegatorAddr, candidateAddr sdk.Address, shares string) MsgUn
 }
 
 //nolint
-func (msg MsgUnbond) Type() string                            { return MsgType } //TODO update "stake/msgeditcandidacy"
+func (msg MsgUnbond) Type() string                            { return MsgType + "/unbond" }
 func (msg MsgUnbond) Get(key interface{}) (value interface{}) { return nil }
 func (msg MsgUnbond) GetSigners() []sdk.Address               { return []sdk.Address{msg.DelegatorAddr} }
 func (msg MsgUnbond) String() string {
@@ -225,3 +225,46 @@ func (msg MsgUnbond) ValidateBasic() sdk.Error {
 	}
 	return nil
 }
+
+//______________________________________________________________________
+
+// MsgWithdrawFees - struct for withdrawing the fees credited to a bond
+type MsgWithdrawFees struct {
+	DelegatorAddr sdk.Address `json:"delegator_addr"`
+	CandidateAddr sdk.Address `json:"candidate_addr"`
+}
+
+func NewMsgWithdrawFees(delegatorAddr, candidateAddr sdk.Address) MsgWithdrawFees {
+	return MsgWithdrawFees{
+		DelegatorAddr: delegatorAddr,
+		CandidateAddr: candidateAddr,
+	}
+}
+
+//nolint
+func (msg MsgWithdrawFees) Type() string                            { return MsgType + "/withdrawfees" }
+func (msg MsgWithdrawFees) Get(key interface{}) (value interface{}) { return nil }
+func (msg MsgWithdrawFees) GetSigners() []sdk.Address               { return []sdk.Address{msg.DelegatorAddr} }
+func (msg MsgWithdrawFees) String() string {
+	return fmt.Sprintf("MsgWithdrawFees{Delegator: %v, Candidate: %v}", msg.DelegatorAddr, msg.CandidateAddr)
+}
+
+// get the bytes for the message signer to sign on
+func (msg MsgWithdrawFees) GetSignBytes() []byte {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// quick validity check
+func (msg MsgWithdrawFees) ValidateBasic() sdk.Error {
+	if msg.DelegatorAddr == nil {
+		return ErrBadDelegatorAddr()
+	}
+	if msg.CandidateAddr == nil {
+		return ErrBadCandidateAddr()
+	}
+	return nil
+}
@@ -129,6 +129,29 @@ func TestMsgUnbond(t *testing.T) {
 	}
 }
 
+func TestMsgWithdrawFees(t *testing.T) {
+	tests := []struct {
+		name          string
+		delegatorAddr sdk.Address
+		candidateAddr sdk.Address
+		expectPass    bool
+	}{
+		{"basic good", addrs[0], addrs[1], true},
+		{"self bond", addrs[0], addrs[0], true},
+		{"empty delegator", emptyAddr, addrs[0], false},
+		{"empty candidate", addrs[0], emptyAddr, false},
+	}
+
+	for _, tc := range tests {
+		msg := NewMsgWithdrawFees(tc.delegatorAddr, tc.candidateAddr)
+		if tc.expectPass {
+			assert.Nil(t, msg.ValidateBasic(), "test: %v", tc.name)
+		} else {
+			assert.NotNil(t, msg.ValidateBasic(), "test: %v", tc.name)
+		}
+	}
+}
+
 // TODO introduce with go-amino
 //func TestSerializeMsg(t *testing.T) {
 
@@ -132,21 +132,25 @@ func paramsNoInflation() Params {
 func createTestInput(t *testing.T, isCheckTx bool, initCoins int64) (sdk.Context, sdk.AccountMapper, Keeper) {
 	db := dbm.NewMemDB()
 	keyStake := sdk.NewKVStoreKey("stake")
+	keyTransient := sdk.NewKVStoreKey("transient_stake")
 	keyMain := keyStake //sdk.NewKVStoreKey("main") //TODO fix multistore
 
 	ms := store.NewCommitMultiStore(db)
 	ms.MountStoreWithDB(keyStake, sdk.StoreTypeIAVL, db)
+	ms.MountStoreWithDB(keyTransient, sdk.StoreTypeTransient, nil)
 	err := ms.LoadLatestVersion()
 	require.Nil(t, err)
 
-	ctx := sdk.NewContext(ms, abci.Header{ChainID: "foochainid"}, isCheckTx, nil)
+	ctx := sdk.NewContext(ms, abci.Header{ChainID: "foochainid"}, isCheckTx, nil).
+		WithValue(testMultiStoreKey{}, ms)
 	cdc := makeTestCodec()
 	accountMapper := auth.NewAccountMapperSealed(
 		keyMain,             // target store
 		&auth.BaseAccount{}, // prototype
 	)
-	ck := bank.NewCoinKeeper(accountMapper)
-	keeper := NewKeeper(ctx, cdc, keyStake, ck)
+	ck := bank.NewCoinKeeper(accountMapper, keyMain)
+	fck := auth.NewFeeCollectionKeeper(cdc, keyMain)
+	keeper := NewKeeper(ctx, cdc, keyStake, keyTransient, ck, fck)
 	keeper.setPool(ctx, initialPool())
 	keeper.setParams(ctx, defaultParams())
 
@@ -160,6 +164,15 @@ func createTestInput(t *testing.T, isCheckTx bool, initCoins int64) (sdk.Context
 	return ctx, accountMapper, keeper
 }
 
+// key of the multistore in the contexts of createTestInput
+type testMultiStoreKey struct{}
+
+// commit the multistore of a context of createTestInput,
+// which clears its transient store
+func commitTestInput(ctx sdk.Context) {
+	ctx.Value(testMultiStoreKey{}).(sdk.CommitMultiStore).Commit()
+}
+
 func newPubKey(pk string) (res crypto.PubKey) {
 	pkBytes, err := hex.DecodeString(pk)
 	if err != nil {
@@ -26,6 +26,9 @@ func (k Keeper) Tick(ctx sdk.Context) (change []abci.Validator) {
 	// save the params
 	k.setPool(ctx, p)
 
+	// Pay the fees of the block to the validators
+	k.distributeFees(ctx)
+
 	change = k.getAccUpdateValidators(ctx)
 
 	return
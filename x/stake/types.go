@@ -33,8 +33,11 @@ type Pool struct {
 
 // GenesisState - all staking state that must be provided at genesis
 type GenesisState struct {
-	Pool   Pool   `json:"pool"`
-	Params Params `json:"params"`
+	Pool       Pool            `json:"pool"`
+	Params     Params          `json:"params"`
+	Candidates []Candidate     `json:"candidates"`
+	Bonds      []DelegatorBond `json:"bonds"`
+	FeesOwed   sdk.Coins       `json:"fees_owed,omitempty"`
 }
 
 //_______________________________________________________________________________________________________
@@ -63,6 +66,11 @@ type Candidate struct {
 	Assets      sdk.Rat         `json:"assets"`      // total shares of a global hold pools
 	Liabilities sdk.Rat         `json:"liabilities"` // total shares issued to a candidate's delegators
 	Description Description     `json:"description"` // Description terms for the candidate
+
+	// fees credited per share of the candidate's bonds, times precision
+	FeesPerShare sdk.Coins `json:"fees_per_share,omitempty"`
+	// fees credited to the candidate which its bonds weren't paid yet
+	FeesOwed sdk.Coins `json:"fees_owed,omitempty"`
 }
 
 // NewCandidate - initialize a new candidate
@@ -164,4 +172,7 @@ type DelegatorBond struct {
 	DelegatorAddr sdk.Address `json:"delegatoraddr"`
 	CandidateAddr sdk.Address `json:"candidate_addr"`
 	Shares        sdk.Rat     `json:"shares"`
+
+	// FeesPerShare of the candidate when the bond was last paid its fees
+	FeesPerShare sdk.Coins `json:"fees_per_share,omitempty"`
 }